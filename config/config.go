@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -31,3 +32,110 @@ func ConnectDB() *mongo.Client {
 
 	return client
 }
+
+type NotificationConfig struct {
+	WebhookURL   string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	SMTPTo       []string
+}
+
+func LoadNotificationConfig() NotificationConfig {
+	cfg := NotificationConfig{
+		WebhookURL:   os.Getenv("LOW_STOCK_WEBHOOK_URL"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     os.Getenv("SMTP_PORT"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:     os.Getenv("SMTP_FROM"),
+	}
+	if cfg.SMTPPort == "" {
+		cfg.SMTPPort = "1025"
+	}
+	for _, to := range strings.Split(os.Getenv("LOW_STOCK_EMAIL_TO"), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			cfg.SMTPTo = append(cfg.SMTPTo, to)
+		}
+	}
+	return cfg
+}
+
+type DocumentConfig struct {
+	CompanyName    string
+	CompanyAddress string
+	CompanyTaxID   string
+	CompanyBranch  string
+	CompanyPhone   string
+	FontPath       string
+	BoldFontPath   string
+}
+
+func LoadDocumentConfig() DocumentConfig {
+	cfg := DocumentConfig{
+		CompanyName:    os.Getenv("COMPANY_NAME"),
+		CompanyAddress: os.Getenv("COMPANY_ADDRESS"),
+		CompanyTaxID:   os.Getenv("COMPANY_TAX_ID"),
+		CompanyBranch:  os.Getenv("COMPANY_BRANCH"),
+		CompanyPhone:   os.Getenv("COMPANY_PHONE"),
+		FontPath:       os.Getenv("PDF_FONT_PATH"),
+		BoldFontPath:   os.Getenv("PDF_FONT_BOLD_PATH"),
+	}
+	if cfg.CompanyBranch == "" {
+		cfg.CompanyBranch = "00000"
+	}
+	return cfg
+}
+
+type PaymentConfig struct {
+	PromptPayID       string
+	FakeWebhookSecret string
+}
+
+func LoadPaymentConfig() PaymentConfig {
+	return PaymentConfig{
+		PromptPayID:       os.Getenv("PROMPTPAY_ID"),
+		FakeWebhookSecret: os.Getenv("PAYMENT_FAKE_WEBHOOK_SECRET"),
+	}
+}
+
+type OutboxConfig struct {
+	LogEvents       bool
+	AllowStandalone bool // ยอมให้ทำงานบน MongoDB ที่ไม่รองรับ transaction ใช้สำหรับเครื่องพัฒนาเท่านั้น
+}
+
+func LoadOutboxConfig() OutboxConfig {
+	return OutboxConfig{
+		LogEvents:       os.Getenv("OUTBOX_LOG_EVENTS") == "true",
+		AllowStandalone: os.Getenv("OUTBOX_ALLOW_STANDALONE") == "true",
+	}
+}
+
+type AddressConfig struct {
+	DatasetPath string
+}
+
+// LoadAddressConfig ถ้าไม่ตั้ง THAI_ADDRESS_DATASET จะใช้ชุดข้อมูลรหัสไปรษณีย์ที่ฝังมากับโปรแกรม
+func LoadAddressConfig() AddressConfig {
+	return AddressConfig{
+		DatasetPath: os.Getenv("THAI_ADDRESS_DATASET"),
+	}
+}
+
+type StreamConfig struct {
+	AllowedOrigins []string
+}
+
+// LoadStreamConfig อ่าน STREAM_ALLOWED_ORIGINS เป็นรายการ origin คั่นด้วย comma เช่น https://admin.example.com
+// หน้าเว็บโดเมนเดียวกับ API เปิด WebSocket ได้เสมอโดยไม่ต้องใส่ไว้
+func LoadStreamConfig() StreamConfig {
+	var cfg StreamConfig
+	for _, origin := range strings.Split(os.Getenv("STREAM_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.AllowedOrigins = append(cfg.AllowedOrigins, origin)
+		}
+	}
+	return cfg
+}
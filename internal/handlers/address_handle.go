@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/thaiaddress"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const addressSearchLimit = 20
+
+type AddressHandle struct {
+	CustomerRepo repositories.CustomerRepositoryInterface
+	Dataset      *thaiaddress.Dataset
+}
+
+type AddressRequest struct {
+	Label           string `json:"label" form:"label"`
+	Recipient       string `json:"recipient" form:"recipient" binding:"required"`
+	Phone           string `json:"phone" form:"phone" binding:"required"`
+	HouseNo         string `json:"house_no" form:"house_no" binding:"required"`
+	Subdistrict     string `json:"subdistrict" form:"subdistrict" binding:"required"`
+	District        string `json:"district" form:"district" binding:"required"`
+	Province        string `json:"province" form:"province" binding:"required"`
+	Postcode        string `json:"postcode" form:"postcode" binding:"required,len=5,numeric"`
+	DefaultShipping bool   `json:"default_shipping" form:"default_shipping"`
+	DefaultBilling  bool   `json:"default_billing" form:"default_billing"`
+}
+
+func NewAddressHandle(customerRepo repositories.CustomerRepositoryInterface, dataset *thaiaddress.Dataset) *AddressHandle {
+	return &AddressHandle{CustomerRepo: customerRepo, Dataset: dataset}
+}
+
+// Lookup ค้นตำบลจากรหัสไปรษณีย์ (?postcode=) หรือจากชื่อ (?q=) สำหรับเติมฟอร์มที่อยู่
+func (h *AddressHandle) Lookup(c *gin.Context) {
+	var entries []thaiaddress.Entry
+	if postcode := c.Query("postcode"); postcode != "" {
+		entries = h.Dataset.Lookup(postcode)
+	} else if q := c.Query("q"); q != "" {
+		entries = h.Dataset.Search(q, addressSearchLimit)
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "postcode or q is required"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":   len(entries),
+		"results": entries,
+	})
+}
+
+func (h *AddressHandle) GetAddresses(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	customer, _, ok := h.loadCustomer(ctx, c)
+	if !ok {
+		return
+	}
+
+	addresses := customer.Addresses
+	if addresses == nil {
+		addresses = []models.Address{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"total":     len(addresses),
+		"addresses": addresses,
+	})
+}
+
+func (h *AddressHandle) CreateAddress(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var input AddressRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	customer, role, ok := h.loadCustomer(ctx, c)
+	if !ok {
+		return
+	}
+
+	address, err := resolveAddress(h.Dataset, input)
+	if err != nil {
+		writeAddressError(c, err)
+		return
+	}
+	address.ID = primitive.NewObjectID()
+	customer.Addresses = setDefaultAddress(append(customer.Addresses, address), address.ID, input.DefaultShipping, input.DefaultBilling)
+
+	if _, err := h.CustomerRepo.Update(ctx, customer.ID, bson.M{"addresses": customer.Addresses}, role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save address"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Address created successfully",
+		"address":   customer.FindAddress(address.ID),
+		"addresses": customer.Addresses,
+	})
+}
+
+func (h *AddressHandle) UpdateAddress(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	addressID, err := primitive.ObjectIDFromHex(c.Param("addressId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address ID"})
+		return
+	}
+	var input AddressRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	customer, role, ok := h.loadCustomer(ctx, c)
+	if !ok {
+		return
+	}
+	existing := customer.FindAddress(addressID)
+	if existing == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
+		return
+	}
+
+	address, err := resolveAddress(h.Dataset, input)
+	if err != nil {
+		writeAddressError(c, err)
+		return
+	}
+	address.ID = addressID
+	address.IsDefaultShipping = existing.IsDefaultShipping
+	address.IsDefaultBilling = existing.IsDefaultBilling
+	*existing = address
+	customer.Addresses = setDefaultAddress(customer.Addresses, addressID, input.DefaultShipping, input.DefaultBilling)
+
+	if _, err := h.CustomerRepo.Update(ctx, customer.ID, bson.M{"addresses": customer.Addresses}, role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save address"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Address updated successfully",
+		"address":   customer.FindAddress(addressID),
+		"addresses": customer.Addresses,
+	})
+}
+
+// DeleteAddress ลบที่อยู่ออกจากสมุด ออเดอร์เดิมยังเก็บสำเนาที่อยู่ไว้ตามเดิม
+func (h *AddressHandle) DeleteAddress(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	addressID, err := primitive.ObjectIDFromHex(c.Param("addressId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address ID"})
+		return
+	}
+	customer, role, ok := h.loadCustomer(ctx, c)
+	if !ok {
+		return
+	}
+	removed := customer.FindAddress(addressID)
+	if removed == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
+		return
+	}
+
+	addresses := []models.Address{}
+	for _, address := range customer.Addresses {
+		if address.ID != addressID {
+			addresses = append(addresses, address)
+		}
+	}
+	// ที่อยู่หลักถูกลบ ให้ที่อยู่แรกที่เหลือเป็นที่อยู่หลักแทน
+	if len(addresses) > 0 {
+		addresses = setDefaultAddress(addresses, addresses[0].ID, removed.IsDefaultShipping, removed.IsDefaultBilling)
+	}
+
+	if _, err := h.CustomerRepo.Update(ctx, customer.ID, bson.M{"addresses": addresses}, role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete address"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Address deleted successfully",
+		"addresses": addresses,
+	})
+}
+
+func (h *AddressHandle) loadCustomer(ctx context.Context, c *gin.Context) (*models.Customer, string, bool) {
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can manage customer addresses"})
+		return nil, "", false
+	}
+	role := roleVar.(string)
+
+	customerID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID"})
+		return nil, "", false
+	}
+	customer, err := h.CustomerRepo.FindByID(ctx, customerID, role)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return nil, "", false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, "", false
+	}
+	return customer, role, true
+}
+
+// resolveAddress ตรวจที่อยู่กับชุดข้อมูลรหัสไปรษณีย์ และใช้ชื่อตำบล อำเภอ จังหวัดตามชุดข้อมูล
+// รหัสไปรษณีย์ที่ไม่อยู่ในชุดข้อมูลจะรับตามที่กรอกและทำเครื่องหมายว่ายังไม่ได้ตรวจ
+func resolveAddress(dataset *thaiaddress.Dataset, input AddressRequest) (models.Address, error) {
+	entry, err := dataset.Resolve(input.Subdistrict, input.District, input.Province, input.Postcode)
+	unverified := err == thaiaddress.ErrUnknownPostcode
+	if unverified {
+		entry, err = thaiaddress.Unlisted(input.Subdistrict, input.District, input.Province, input.Postcode), nil
+	}
+	if err != nil {
+		return models.Address{}, err
+	}
+	return models.Address{
+		Label:       strings.TrimSpace(input.Label),
+		Recipient:   strings.TrimSpace(input.Recipient),
+		Phone:       strings.TrimSpace(input.Phone),
+		HouseNo:     strings.TrimSpace(input.HouseNo),
+		Subdistrict: entry.Subdistrict,
+		District:    entry.District,
+		Province:    entry.Province,
+		Postcode:    entry.Postcode,
+		Unverified:  unverified,
+	}, nil
+}
+
+// setDefaultAddress ตั้งที่อยู่ id เป็นที่อยู่หลัก ที่อยู่แรกของลูกค้าเป็นที่อยู่หลักเสมอ
+func setDefaultAddress(addresses []models.Address, id primitive.ObjectID, shipping bool, billing bool) []models.Address {
+	if len(addresses) == 1 {
+		shipping, billing = true, true
+	}
+	for i := range addresses {
+		if shipping {
+			addresses[i].IsDefaultShipping = addresses[i].ID == id
+		}
+		if billing {
+			addresses[i].IsDefaultBilling = addresses[i].ID == id
+		}
+	}
+	return addresses
+}
+
+func writeAddressError(c *gin.Context, err error) {
+	switch err {
+	case thaiaddress.ErrUnknownPostcode:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown postcode"})
+	case thaiaddress.ErrMismatch:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subdistrict, district and province do not match the postcode"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
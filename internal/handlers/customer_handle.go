@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/money"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/outbox"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/rfm"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const favouriteProductLimit = 5
+
+type CustomerHandle struct {
+	CustomerRepo repositories.CustomerRepositoryInterface
+	OrderRepo    repositories.OrderRepositoryInterface
+	ReportRepo   repositories.ReportRepositoryInterface
+	Events       outbox.Recorder
+}
+
+type MergeCustomersRequest struct {
+	SurvivorID   string   `json:"survivor_id" form:"survivor_id" binding:"required"`
+	DuplicateIDs []string `json:"duplicate_ids" form:"duplicate_ids" binding:"required,min=1"`
+}
+
+func NewCustomerHandle(customerRepo repositories.CustomerRepositoryInterface, orderRepo repositories.OrderRepositoryInterface, reportRepo repositories.ReportRepositoryInterface, events outbox.Recorder) *CustomerHandle {
+	return &CustomerHandle{CustomerRepo: customerRepo, OrderRepo: orderRepo, ReportRepo: reportRepo, Events: events}
+}
+
+func (h *CustomerHandle) GetCustomers(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can view customers"})
+		return
+	}
+
+	customers, err := h.CustomerRepo.FindAll(ctx, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":     len(customers),
+		"customers": customers,
+	})
+}
+
+func (h *CustomerHandle) GetCustomerOrders(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter, ok := customerReportFilter(c)
+	if !ok {
+		return
+	}
+	customer, ok := h.loadCustomer(ctx, c, filter.Role)
+	if !ok {
+		return
+	}
+
+	orders, err := h.OrderRepo.FindByCustomer(ctx, customer.ID, filter.UserID, filter.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"customer": customer,
+		"total":    len(orders),
+		"orders":   orders,
+	})
+}
+
+// GetCustomerSummary สรุปยอดซื้อตลอดอายุของลูกค้า ไม่นับออเดอร์ที่ถูกยกเลิก
+func (h *CustomerHandle) GetCustomerSummary(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter, ok := customerReportFilter(c)
+	if !ok {
+		return
+	}
+	customer, ok := h.loadCustomer(ctx, c, filter.Role)
+	if !ok {
+		return
+	}
+	filter.CustomerID = customer.ID
+
+	stats, err := h.ReportRepo.CustomerStats(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	favourites, err := h.ReportRepo.TopProducts(ctx, filter, "quantity", favouriteProductLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary := gin.H{
+		"orders":              0,
+		"lifetime_value":      models.Money{Amount: 0, Currency: filter.Currency},
+		"revenue":             models.Money{Amount: 0, Currency: filter.Currency},
+		"refunded":            models.Money{Amount: 0, Currency: filter.Currency},
+		"average_order_value": models.Money{Amount: 0, Currency: filter.Currency},
+		"first_order_at":      nil,
+		"last_order_at":       nil,
+	}
+	if len(stats) > 0 {
+		s := stats[0]
+		summary["orders"] = s.Orders
+		summary["lifetime_value"] = models.Money{Amount: s.LifetimeValue, Currency: filter.Currency}
+		summary["revenue"] = models.Money{Amount: s.Revenue, Currency: filter.Currency}
+		summary["refunded"] = models.Money{Amount: s.Refunded, Currency: filter.Currency}
+		summary["average_order_value"] = models.Money{Amount: (s.Revenue + int64(s.Orders)/2) / int64(s.Orders), Currency: filter.Currency}
+		summary["first_order_at"] = s.FirstOrderAt
+		summary["last_order_at"] = s.LastOrderAt
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"customer":            customer,
+		"summary":             summary,
+		"favourite_products":  favourites,
+		"favourite_sorted_by": "quantity",
+	})
+}
+
+// GetRFM แบ่งกลุ่มลูกค้าตาม recency/frequency/monetary กรองด้วย segment หรือ inactive_days
+// เพื่อหาลูกค้าที่ไม่ได้ซื้อมานาน
+func (h *CustomerHandle) GetRFM(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	filter, ok := customerReportFilter(c)
+	if !ok {
+		return
+	}
+
+	segment := c.Query("segment")
+	if segment != "" && !validSegment(segment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown segment", "segments": rfm.Segments})
+		return
+	}
+	loc, err := time.LoadLocation(c.DefaultQuery("tz", defaultReportTimezone))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
+		return
+	}
+	inactiveDays := 0
+	if value := c.Query("inactive_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "inactive_days must be a non-negative number"})
+			return
+		}
+		inactiveDays = days
+	}
+
+	stats, err := h.ReportRepo.CustomerStats(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// คะแนนคิดจากลูกค้าทั้งหมดก่อนแล้วจึงกรอง เพื่อให้คะแนนไม่เปลี่ยนตามตัวกรอง
+	customers := []models.CustomerRFM{}
+	counts := map[string]int{}
+	now := time.Now().In(loc)
+	for _, row := range rfm.Score(stats, now) {
+		counts[row.Segment]++
+		if segment != "" && row.Segment != segment {
+			continue
+		}
+		if row.DaysSinceLastOrder < inactiveDays {
+			continue
+		}
+		customers = append(customers, row)
+	}
+
+	if format := c.Query("format"); format != "" {
+		records := make([][]string, 0, len(customers))
+		for _, row := range customers {
+			records = append(records, []string{
+				row.CustomerID.Hex(),
+				row.FullName,
+				row.Email,
+				row.Phone,
+				strconv.Itoa(row.Orders),
+				money.Format(models.Money{Amount: row.LifetimeValue, Currency: filter.Currency}),
+				row.LastOrderAt.In(loc).Format("2006-01-02"),
+				strconv.Itoa(row.DaysSinceLastOrder),
+				row.Score,
+				row.Segment,
+			})
+		}
+		writeReport(c, format, "customer-rfm-"+now.Format("20060102"), []string{"customer_id", "full_name", "email", "phone", "orders", "lifetime_value", "last_order", "days_since_last_order", "rfm", "segment"}, records)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"as_of":     now.Format("2006-01-02"),
+		"timezone":  loc.String(),
+		"currency":  filter.Currency,
+		"segments":  counts,
+		"total":     len(customers),
+		"customers": customers,
+	})
+}
+
+// MergeCustomers รวมลูกค้าซ้ำเข้ากับลูกค้าหลัก ย้ายออเดอร์ทั้งหมดไปที่ลูกค้าหลัก
+// และเติมเบอร์โทร/ที่อยู่ที่ลูกค้าหลักยังไม่มีจากรายที่ถูกรวม
+func (h *CustomerHandle) MergeCustomers(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can merge customers"})
+		return
+	}
+	role := roleVar.(string)
+
+	var input MergeCustomersRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	survivorID, err := primitive.ObjectIDFromHex(input.SurvivorID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid survivor ID"})
+		return
+	}
+
+	survivor, err := h.CustomerRepo.FindByID(ctx, survivorID, role)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found", "customer_id": input.SurvivorID})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if survivor.MergedInto != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Survivor has already been merged into another customer", "merged_into": survivor.MergedInto})
+		return
+	}
+
+	var duplicateIDs []primitive.ObjectID
+	fields := bson.M{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, hex := range input.DuplicateIDs {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID", "customer_id": hex})
+			return
+		}
+		if id == survivorID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Survivor cannot be merged into itself"})
+			return
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		duplicate, err := h.CustomerRepo.FindByID(ctx, id, role)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found", "customer_id": hex})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if duplicate.MergedInto != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Customer has already been merged", "customer_id": hex, "merged_into": duplicate.MergedInto})
+			return
+		}
+		duplicateIDs = append(duplicateIDs, id)
+
+		if survivor.Phone == "" && duplicate.Phone != "" {
+			survivor.Phone = duplicate.Phone
+			fields["phone"] = duplicate.Phone
+		}
+		if survivor.Address == "" && duplicate.Address != "" {
+			survivor.Address = duplicate.Address
+			fields["address"] = duplicate.Address
+		}
+	}
+
+	var ordersMoved int64
+	err = h.Events.Transaction(ctx, func(ctx context.Context) error {
+		result, err := h.OrderRepo.ReassignCustomer(ctx, duplicateIDs, survivorID, role)
+		if err != nil {
+			return err
+		}
+		ordersMoved = result.ModifiedCount
+		if _, err := h.CustomerRepo.MarkMerged(ctx, duplicateIDs, survivorID, role); err != nil {
+			return err
+		}
+		if len(fields) > 0 {
+			if _, err := h.CustomerRepo.Update(ctx, survivorID, fields, role); err != nil {
+				return err
+			}
+		}
+		return h.Events.Record(ctx, survivorID.Hex(), "customer.merged", gin.H{
+			"customer_id":  survivorID,
+			"merged_ids":   duplicateIDs,
+			"orders_moved": ordersMoved,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge customers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Customers merged successfully",
+		"customer":     survivor,
+		"merged_ids":   duplicateIDs,
+		"orders_moved": ordersMoved,
+	})
+}
+
+func (h *CustomerHandle) loadCustomer(ctx context.Context, c *gin.Context, role string) (*models.Customer, bool) {
+	customerID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID"})
+		return nil, false
+	}
+	customer, err := h.CustomerRepo.FindByID(ctx, customerID, role)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return nil, false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return customer, true
+}
+
+// customerReportFilter คือ ReportFilter ที่ไม่จำกัดช่วงเวลา ใช้สรุปยอดตลอดอายุของลูกค้า
+func customerReportFilter(c *gin.Context) (repositories.ReportFilter, bool) {
+	var filter repositories.ReportFilter
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can view customers"})
+		return filter, false
+	}
+	userIdVar, _ := c.Get("userId")
+	userID, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return filter, false
+	}
+
+	currency := money.NormalizeCurrency(c.DefaultQuery("currency", money.DefaultCurrency()))
+	if !money.ValidCurrency(currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency"})
+		return filter, false
+	}
+
+	filter = repositories.ReportFilter{
+		Currency: currency,
+		UserID:   userID,
+		Role:     roleVar.(string),
+	}
+	return filter, true
+}
+
+func validSegment(segment string) bool {
+	for _, s := range rfm.Segments {
+		if s == segment {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/document"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	parcelLabel       = "label"
+	parcelPackingSlip = "packing-slip"
+
+	defaultParcelBatch = 50
+	maxParcelBatch     = 200
+)
+
+var documentPrefixes = map[string]string{
+	models.DocumentTaxInvoice: "INV",
+	models.DocumentReceipt:    "RC",
+}
+
+type DocumentHandle struct {
+	OrderRepo    repositories.OrderRepositoryInterface
+	CustomerRepo repositories.CustomerRepositoryInterface
+	ProductRepo  repositories.ProductRepositoryInterface
+	DocumentRepo repositories.DocumentRepositoryInterface
+	CounterRepo  repositories.CounterRepositoryInterface
+	ShipmentRepo repositories.ShipmentRepositoryInterface
+	Renderer     *document.Renderer
+}
+
+func NewDocumentHandle(orderRepo repositories.OrderRepositoryInterface, customerRepo repositories.CustomerRepositoryInterface, productRepo repositories.ProductRepositoryInterface, documentRepo repositories.DocumentRepositoryInterface, counterRepo repositories.CounterRepositoryInterface, shipmentRepo repositories.ShipmentRepositoryInterface, renderer *document.Renderer) *DocumentHandle {
+	return &DocumentHandle{OrderRepo: orderRepo, CustomerRepo: customerRepo, ProductRepo: productRepo, DocumentRepo: documentRepo, CounterRepo: counterRepo, ShipmentRepo: shipmentRepo, Renderer: renderer}
+}
+
+func (h *DocumentHandle) GetInvoicePDF(c *gin.Context) {
+	h.serveDocument(c, models.DocumentTaxInvoice)
+}
+
+func (h *DocumentHandle) GetReceiptPDF(c *gin.Context) {
+	h.serveDocument(c, models.DocumentReceipt)
+}
+
+// serveDocument ตรวจว่าออเดอร์ชำระแล้ว แล้วส่งเอกสารที่เคยออกไว้ถ้ามี ถ้ายังไม่มีจึงออกเลขที่ใหม่ สร้าง PDF และเก็บสำเนาไว้
+func (h *DocumentHandle) serveDocument(c *gin.Context, docType string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can issue documents"})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	userID, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	order, err := h.OrderRepo.FindByID(ctx, orderID, roleVar.(string))
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if !isPaidStatus(order.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Documents can only be issued for paid orders"})
+		return
+	}
+
+	// ตรวจออเดอร์ก่อนส่งสำเนาที่เก็บไว้ เพื่อไม่ให้ผู้ที่ไม่เห็นออเดอร์หรือออเดอร์ที่ไม่ได้ชำระแล้วได้เอกสารไป
+	existing, err := h.DocumentRepo.FindByOrder(ctx, orderID, docType)
+	if err == nil {
+		writePDF(c, existing)
+		return
+	} else if err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if h.Renderer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PDF font is not configured"})
+		return
+	}
+
+	var customer models.Customer
+	if !order.CustomerID.IsZero() {
+		found, err := h.CustomerRepo.FindByID(ctx, order.CustomerID, roleVar.(string))
+		if err != nil && err != mongo.ErrNoDocuments {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load customer"})
+			return
+		}
+		if found != nil {
+			customer = *found
+		}
+	}
+
+	issuedAt := time.Now()
+	seq, err := h.CounterRepo.Next(ctx, fmt.Sprintf("%s-%d", docType, issuedAt.Year()))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to allocate document number"})
+		return
+	}
+	number := fmt.Sprintf("%s%d-%06d", documentPrefixes[docType], issuedAt.Year(), seq)
+
+	content, err := h.Renderer.Render(document.Data{
+		Type:     docType,
+		Number:   number,
+		IssuedAt: issuedAt,
+		Customer: customer,
+		Order:    *order,
+		Lines:    documentLines(ctx, h.ProductRepo, order.Items),
+	})
+	if err != nil {
+		log.Printf("failed to render %s for order %s: %v", docType, orderID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render document"})
+		return
+	}
+
+	checksum := sha256.Sum256(content)
+	doc := models.Document{
+		OrderID:   orderID,
+		Type:      docType,
+		Number:    number,
+		Content:   content,
+		Checksum:  hex.EncodeToString(checksum[:]),
+		CreatedBy: userID,
+		CreatedAt: issuedAt,
+	}
+	if err := h.DocumentRepo.Insert(ctx, &doc); err != nil {
+		// มีคำขออื่นออกเอกสารของออเดอร์นี้ไปก่อน ส่งฉบับนั้นแทน เลขที่ที่จองไว้จะถูกข้ามไปแต่ไม่ซ้ำ
+		if mongo.IsDuplicateKeyError(err) {
+			if existing, err := h.DocumentRepo.FindByOrder(ctx, orderID, docType); err == nil {
+				writePDF(c, existing)
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store document"})
+		return
+	}
+
+	writePDF(c, &doc)
+}
+
+func (h *DocumentHandle) GetLabelPDF(c *gin.Context) {
+	h.serveParcel(c, parcelLabel)
+}
+
+func (h *DocumentHandle) GetPackingSlipPDF(c *gin.Context) {
+	h.serveParcel(c, parcelPackingSlip)
+}
+
+// GetPaidLabelsPDF พิมพ์ใบปะหน้าของออเดอร์ที่ชำระแล้วแต่ยังไม่ได้จัดส่งและไม่มีรายการรอสต็อกในไฟล์เดียว
+// ครั้งละไม่เกิน limit ออเดอร์ ถ้ายังมีหน้าถัดไปจะบอกเลขหน้าใน header X-Next-Page
+func (h *DocumentHandle) GetPaidLabelsPDF(c *gin.Context) {
+	h.servePaidParcels(c, parcelLabel)
+}
+
+func (h *DocumentHandle) GetPaidPackingSlipsPDF(c *gin.Context) {
+	h.servePaidParcels(c, parcelPackingSlip)
+}
+
+// serveParcel ใบปะหน้าและใบรายการสินค้าไม่มีเลขที่เอกสาร สร้างใหม่ทุกครั้งจากข้อมูลล่าสุดของออเดอร์
+func (h *DocumentHandle) serveParcel(c *gin.Context, kind string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can print shipping documents"})
+		return
+	}
+	role := roleVar.(string)
+
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	order, err := h.OrderRepo.FindByID(ctx, orderID, role)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !isPaidStatus(order.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Shipping documents can only be printed for paid orders"})
+		return
+	}
+	if h.Renderer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PDF font is not configured"})
+		return
+	}
+
+	parcel, err := h.parcel(ctx, *order, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load order details"})
+		return
+	}
+	h.writeParcels(c, kind, fmt.Sprintf("%s-%s", kind, orderID.Hex()), []document.Parcel{parcel})
+}
+
+func (h *DocumentHandle) servePaidParcels(c *gin.Context, kind string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can print shipping documents"})
+		return
+	}
+	role := roleVar.(string)
+
+	userIdVar, _ := c.Get("userId")
+	userID, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	limit := defaultParcelBatch
+	if limitStr := c.Query("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 || n > maxParcelBatch {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Limit must be between 1 and %d", maxParcelBatch)})
+			return
+		}
+		limit = n
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Page must be a positive number"})
+		return
+	}
+
+	// อ่านเกินมาหนึ่งรายการเพื่อรู้ว่ายังมีหน้าถัดไป
+	orders, err := h.OrderRepo.FindReadyToShip(ctx, userID, role, (page-1)*limit, limit+1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if len(orders) > limit {
+		orders = orders[:limit]
+		c.Header("X-Next-Page", strconv.Itoa(page+1))
+	}
+	if len(orders) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No paid orders ready to print"})
+		return
+	}
+	if h.Renderer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PDF font is not configured"})
+		return
+	}
+
+	parcels := make([]document.Parcel, 0, len(orders))
+	for _, order := range orders {
+		parcel, err := h.parcel(ctx, order, role)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load order details"})
+			return
+		}
+		parcels = append(parcels, parcel)
+	}
+	h.writeParcels(c, kind, fmt.Sprintf("%ss-%s", kind, time.Now().Format("20060102-150405")), parcels)
+}
+
+// parcel รวมข้อมูลผู้รับ สินค้า และเลขพัสดุของออเดอร์ ใช้ที่อยู่จัดส่งที่เก็บไว้กับออเดอร์ก่อน
+// ออเดอร์เก่าที่ไม่มีสำเนาที่อยู่จึงใช้ที่อยู่จัดส่งหลักหรือที่อยู่แบบข้อความของลูกค้า
+func (h *DocumentHandle) parcel(ctx context.Context, order models.Order, role string) (document.Parcel, error) {
+	parcel := document.Parcel{Order: order, Lines: documentLines(ctx, h.ProductRepo, order.Items), TrackingNumber: order.Tracking_number}
+
+	address := order.ShippingAddress
+	if !order.CustomerID.IsZero() {
+		customer, err := h.CustomerRepo.FindByID(ctx, order.CustomerID, role)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return parcel, err
+		}
+		if customer != nil {
+			parcel.Recipient, parcel.Phone, parcel.Address = customer.FullName, customer.Phone, customer.Address
+			if address == nil {
+				address = customer.DefaultShippingAddress()
+			}
+		}
+	}
+	if address != nil {
+		parcel.Recipient, parcel.Phone, parcel.Postcode = address.Recipient, address.Phone, address.Postcode
+		parcel.Address = address.String()
+	}
+
+	if order.Shipping != nil {
+		parcel.CarrierName, parcel.Service = order.Shipping.CarrierName, order.Shipping.Service
+	}
+	shipments, err := h.ShipmentRepo.FindByOrder(ctx, order.ID, role)
+	if err != nil {
+		return parcel, err
+	}
+	// กล่องล่าสุดที่ยังไม่ยกเลิกคือกล่องที่กำลังจะพิมพ์ใบปะหน้า
+	for i := len(shipments) - 1; i >= 0; i-- {
+		if shipments[i].Status != models.ShipmentCancelled && shipments[i].TrackingNumber != "" {
+			parcel.TrackingNumber = shipments[i].TrackingNumber
+			if parcel.Service == "" {
+				parcel.Service = shipments[i].Service
+			}
+			break
+		}
+	}
+	return parcel, nil
+}
+
+func (h *DocumentHandle) writeParcels(c *gin.Context, kind string, filename string, parcels []document.Parcel) {
+	var content []byte
+	var err error
+	if kind == parcelLabel {
+		content, err = h.Renderer.RenderLabels(parcels, time.Now())
+	} else {
+		content, err = h.Renderer.RenderPackingSlips(parcels, time.Now())
+	}
+	if err != nil {
+		log.Printf("failed to render %s: %v", kind, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render document"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, filename))
+	c.Data(http.StatusOK, "application/pdf", content)
+}
+
+// documentLines ใช้ชื่อและรหัสสินค้าปัจจุบัน สินค้าที่ถูกลบไปแล้วยังหาได้จากรายการที่ลบแบบ soft delete
+func documentLines(ctx context.Context, productRepo repositories.ProductRepositoryInterface, items []models.OrderItem) []document.Line {
+	lines := make([]document.Line, len(items))
+	for i, item := range items {
+		lines[i] = document.Line{Name: item.ProductID.Hex(), Item: item}
+		product, err := productRepo.FindByID(ctx, item.ProductID, true)
+		if err == mongo.ErrNoDocuments {
+			product, err = productRepo.FindByID(ctx, item.ProductID, false)
+		}
+		if err == nil {
+			lines[i].Name, lines[i].SKU = product.Name, product.SKU
+		}
+	}
+	return lines
+}
+
+func isPaidStatus(status string) bool {
+	switch strings.ToLower(status) {
+	case "paid", "shipped", "delivered", "completed":
+		return true
+	}
+	return false
+}
+
+func writePDF(c *gin.Context, doc *models.Document) {
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, doc.Number))
+	c.Header("ETag", `"`+doc.Checksum+`"`)
+	c.Data(http.StatusOK, "application/pdf", doc.Content)
+}
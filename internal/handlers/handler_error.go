@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type handlerError struct {
+	Status  int
+	Message string
+}
+
+func (e *handlerError) Error() string {
+	return e.Message
+}
+
+func writeHandlerError(c *gin.Context, err error) {
+	if he, ok := err.(*handlerError); ok {
+		c.JSON(he.Status, gin.H{"error": he.Message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
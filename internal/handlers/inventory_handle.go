@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type InventoryHandle struct {
+	ProductRepo  repositories.ProductRepositoryInterface
+	AlertRepo    repositories.StockAlertRepositoryInterface
+	LocationRepo repositories.LocationRepositoryInterface
+	TransferRepo repositories.StockTransferRepositoryInterface
+}
+
+func NewInventoryHandle(productRepo repositories.ProductRepositoryInterface, alertRepo repositories.StockAlertRepositoryInterface, locationRepo repositories.LocationRepositoryInterface, transferRepo repositories.StockTransferRepositoryInterface) *InventoryHandle {
+	return &InventoryHandle{ProductRepo: productRepo, AlertRepo: alertRepo, LocationRepo: locationRepo, TransferRepo: transferRepo}
+}
+
+type StockTransferRequest struct {
+	ProductID      string `json:"product_id" form:"product_id" binding:"required"`
+	FromLocationID string `json:"from_location_id" form:"from_location_id" binding:"required"`
+	ToLocationID   string `json:"to_location_id" form:"to_location_id" binding:"required"`
+	Quantity       int    `json:"quantity" form:"quantity" binding:"required,min=1"`
+	Note           string `json:"note" form:"note"`
+}
+
+type LowStockItem struct {
+	ProductID       string `json:"product_id"`
+	SKU             string `json:"sku"`
+	Name            string `json:"name"`
+	Stock           int    `json:"stock"`
+	ReorderPoint    int    `json:"reorder_point"`
+	ReorderQuantity int    `json:"reorder_quantity"`
+	Shortfall       int    `json:"shortfall"`
+}
+
+func (h *InventoryHandle) GetLowStock(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can view inventory"})
+		return
+	}
+
+	products, err := h.ProductRepo.FindLowStock(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]LowStockItem, 0, len(products))
+	for _, product := range products {
+		items = append(items, LowStockItem{
+			ProductID:       product.ID.Hex(),
+			SKU:             product.SKU,
+			Name:            product.Name,
+			Stock:           product.Stock,
+			ReorderPoint:    product.ReorderPoint,
+			ReorderQuantity: product.ReorderQuantity,
+			Shortfall:       product.ReorderPoint - product.Stock,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":    len(items),
+		"products": items,
+	})
+}
+
+func (h *InventoryHandle) GetStockAlerts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can view inventory"})
+		return
+	}
+
+	alerts, err := h.AlertRepo.FindAll(ctx, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":  len(alerts),
+		"alerts": alerts,
+	})
+}
+
+func (h *InventoryHandle) TransferStock(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can transfer stock"})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	createBy, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var input StockTransferRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	productID, err := primitive.ObjectIDFromHex(input.ProductID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	fromID, err := primitive.ObjectIDFromHex(input.FromLocationID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid source location ID"})
+		return
+	}
+	toID, err := primitive.ObjectIDFromHex(input.ToLocationID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid destination location ID"})
+		return
+	}
+	if fromID == toID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Source and destination must differ"})
+		return
+	}
+
+	if location, err := h.LocationRepo.FindByID(ctx, toID); err == mongo.ErrNoDocuments || (err == nil && !location.IsActive) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Destination location not found or inactive"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	err = h.ProductRepo.TransferLocationStock(ctx, productID, fromID, toID, input.Quantity)
+	if err == repositories.ErrInsufficientStock {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient stock at source location"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock"})
+		return
+	}
+
+	transfer := models.StockTransfer{
+		ProductID:      productID,
+		FromLocationID: fromID,
+		ToLocationID:   toID,
+		Quantity:       input.Quantity,
+		Note:           input.Note,
+		CreatedBy:      createBy,
+		CreatedAt:      time.Now(),
+	}
+	if err := h.TransferRepo.Insert(ctx, &transfer); err != nil {
+		log.Printf("failed to record stock transfer for %s: %v", productID.Hex(), err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Stock transferred successfully", "transfer": transfer})
+}
+
+func (h *InventoryHandle) GetStockTransfers(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can view inventory"})
+		return
+	}
+
+	filter := bson.M{}
+	if productIDStr := c.Query("product_id"); productIDStr != "" {
+		productID, err := primitive.ObjectIDFromHex(productIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		filter["product_id"] = productID
+	}
+
+	transfers, err := h.TransferRepo.FindAll(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":     len(transfers),
+		"transfers": transfers,
+	})
+}
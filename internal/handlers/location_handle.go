@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LocationRequest struct {
+	Code     string `json:"code" form:"code" binding:"required"`
+	Name     string `json:"name" form:"name" binding:"required"`
+	Type     string `json:"type" form:"type" binding:"required,oneof=shop warehouse"`
+	IsActive *bool  `json:"is_active" form:"is_active"`
+}
+
+type LocationHandle struct {
+	LocationRepo repositories.LocationRepositoryInterface
+}
+
+func NewLocationHandle(repo repositories.LocationRepositoryInterface) *LocationHandle {
+	return &LocationHandle{LocationRepo: repo}
+}
+
+func (h *LocationHandle) GetLocations(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	locations, err := h.LocationRepo.FindAll(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":     len(locations),
+		"locations": locations,
+	})
+}
+
+func (h *LocationHandle) CreateLocation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can create locations"})
+		return
+	}
+
+	var input LocationRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	location := models.Location{
+		Code:      input.Code,
+		Name:      input.Name,
+		Type:      input.Type,
+		IsActive:  true,
+		CreatedAt: time.Now(),
+	}
+	if err := h.LocationRepo.Insert(ctx, &location, roleVar.(string)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create location"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Location created successfully", "location_id": location.ID.Hex()})
+}
+
+func (h *LocationHandle) UpdateLocation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can update locations"})
+		return
+	}
+
+	locationID, err := primitive.ObjectIDFromHex(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+
+	var input LocationRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update := bson.M{"code": input.Code, "name": input.Name, "type": input.Type}
+	if input.IsActive != nil {
+		update["is_active"] = *input.IsActive
+	}
+
+	result, err := h.LocationRepo.Update(ctx, locationID, update, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update location"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Location updated successfully"})
+}
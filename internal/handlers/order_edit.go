@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/webhook"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// EditOrderItemsRequest คือรายการสินค้าทั้งหมดหลังแก้ไข สินค้าที่ไม่อยู่ในรายการจะถูกลบออกจากออเดอร์
+type EditOrderItemsRequest struct {
+	Items   []OrderItemRequest `json:"items" form:"items" binding:"required,dive"`
+	Reprice bool               `json:"reprice" form:"reprice"` // false คือรายการเดิมใช้ราคา ณ ตอนสั่งซื้อ
+	Reason  string             `json:"reason" form:"reason"`
+}
+
+// orderEdit คือผลการเทียบรายการเดิมกับรายการใหม่ Products เรียงตรงกับ Items
+type orderEdit struct {
+	Items    []models.OrderItem
+	Products []*models.Product
+	Reserve  []models.OrderItem // สต็อกที่ต้องจองเพิ่ม
+	Release  []models.OrderItem // สต็อกที่ต้องคืน
+	Changes  []models.OrderItemChange
+}
+
+func (h *OrderHandle) EditOrderItems(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var input EditOrderItemsRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	userID, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can edit orders"})
+		return
+	}
+	role := roleVar.(string)
+
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	order, err := h.OrderRep.FindByID(ctx, orderID, role)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !strings.EqualFold(order.Status, "Pending") {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only pending orders can be edited"})
+		return
+	}
+
+	// เริ่มแพ็กส่งไปแล้วบางส่วนก็แก้ไม่ได้ เพราะจำนวนที่ส่งไปแล้วจะไม่ตรงกับออเดอร์
+	shipments, err := h.ShipmentRep.FindByOrder(ctx, orderID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	for _, shipment := range shipments {
+		if shipment.Status != models.ShipmentCancelled {
+			c.JSON(http.StatusConflict, gin.H{"error": "Order already has shipments and can no longer be edited"})
+			return
+		}
+	}
+
+	onOrder := map[primitive.ObjectID]bool{}
+	for _, item := range order.Items {
+		onOrder[item.ProductID] = true
+	}
+	requested, products, err := h.loadOrderItems(ctx, mergeOrderItemRequests(input.Items), onOrder)
+	if err != nil {
+		writeHandlerError(c, err)
+		return
+	}
+
+	waiting, err := h.waitingBackorders(ctx, products)
+	if err != nil {
+		writeHandlerError(c, err)
+		return
+	}
+	edit, err := planOrderEdit(order.Items, requested, products, waiting, input.Reprice, h.ProductRep.DefaultLocation())
+	if err != nil {
+		writeHandlerError(c, err)
+		return
+	}
+	if len(edit.Changes) == 0 && !input.Reprice {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No changes to apply"})
+		return
+	}
+
+	currency := order.TotalAmount.Currency
+	for _, item := range edit.Items {
+		if item.UnitPrice.Currency != currency {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "All items must be priced in the order currency " + currency})
+			return
+		}
+	}
+
+	priced, err := h.priceEditedOrder(ctx, edit.Items, edit.Products, order.Discounts)
+	if err != nil {
+		writeHandlerError(c, err)
+		return
+	}
+
+	// น้ำหนักพัสดุเปลี่ยนตามรายการ จึงคิดค่าส่งใหม่ด้วยผู้ให้บริการและบริการเดิม
+	shippingMethod := order.Shipping
+	if order.Shipping != nil {
+		shippingInput := OrderRequest{CarrierID: order.Shipping.CarrierID.Hex(), ShippingService: order.Shipping.Service}
+		shippingMethod, err = h.quoteShipping(ctx, shippingInput, order.ShippingAddress, priced.Items, edit.Products, priced.Total)
+		if err != nil {
+			writeHandlerError(c, err)
+			return
+		}
+	}
+	shippingTotal, err := h.addShipping(priced, shippingMethod)
+	if err != nil {
+		writeHandlerError(c, err)
+		return
+	}
+	total := priced.Total
+	if order.PaidAmount.Amount > total.Amount {
+		c.JSON(http.StatusConflict, gin.H{"error": "New total is less than the amount already paid"})
+		return
+	}
+
+	update := bson.M{
+		"items":          priced.Items,
+		"subtotal":       priced.Subtotal,
+		"discounts":      priced.Discounts,
+		"discount_total": priced.DiscountTotal,
+		"tax_total":      priced.TaxTotal,
+		"shipping_total": shippingTotal,
+		"shipping":       shippingMethod,
+		"total_amount":   total,
+	}
+	// ลดยอดลงจนเท่ากับที่ชำระมาแล้ว ถือว่าชำระครบเหมือนตอนรับชำระ
+	if order.PaidAmount.Amount > 0 && order.PaidAmount.Amount == total.Amount {
+		update["status"] = "Paid"
+		update["paid_at"] = time.Now()
+	}
+	revision := models.OrderRevision{
+		Changes:       edit.Changes,
+		Repriced:      input.Reprice,
+		PreviousTotal: order.TotalAmount,
+		NewTotal:      total,
+		Reason:        strings.TrimSpace(input.Reason),
+		ChangedBy:     userID,
+		ChangedAt:     time.Now(),
+	}
+
+	added := promotionsNotIn(priced.Discounts, order.Discounts)
+	dropped := promotionsNotIn(order.Discounts, priced.Discounts)
+	if err := h.reservePromotions(ctx, added); err != nil {
+		writeHandlerError(c, err)
+		return
+	}
+
+	var reserved []models.OrderItem
+	for _, item := range edit.Reserve {
+		if item.Reserved() == 0 {
+			continue
+		}
+		err := h.ProductRep.UpdateLocationStock(ctx, item.ProductID, item.LocationID, -item.Reserved())
+		if err != nil {
+			releaseStock(ctx, h.ProductRep, reserved)
+			h.releasePromotions(ctx, added)
+			if err == repositories.ErrInsufficientStock {
+				c.JSON(http.StatusConflict, gin.H{"error": "Insufficient stock to increase the order, please retry"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock"})
+			return
+		}
+		reserved = append(reserved, item)
+	}
+
+	var updated *models.Order
+	err = h.Events.Transaction(ctx, func(ctx context.Context) error {
+		res, err := h.OrderRep.ApplyRevision(ctx, orderID, len(order.History), order.Items, update, revision, role)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return &handlerError{http.StatusConflict, "Order was changed by another request, please retry"}
+		}
+		updated, err = h.OrderRep.FindByID(ctx, orderID, role)
+		if err != nil {
+			return err
+		}
+		if err := h.Events.Record(ctx, orderID.Hex(), webhook.EventOrderItemsChanged, gin.H{
+			"order_id": orderID.Hex(),
+			"revision": revision,
+			"order":    updated,
+		}); err != nil {
+			return err
+		}
+		return recordStatusChanged(ctx, h.Events, order.Status, updated)
+	})
+	if err != nil {
+		releaseStock(ctx, h.ProductRep, reserved)
+		h.releasePromotions(ctx, added)
+		if _, ok := err.(*handlerError); ok {
+			writeHandlerError(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+		return
+	}
+
+	releaseStock(ctx, h.ProductRep, edit.Release)
+	h.releasePromotions(ctx, dropped)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order updated successfully", "order": updated})
+}
+
+// planOrderEdit เทียบรายการเดิมกับรายการใหม่ รายการเดิมคงคลังและราคาเดิมไว้เว้นแต่ขอคิดราคาใหม่
+// จำนวนที่เพิ่มจองจากคลังเดิมของรายการนั้น สินค้าที่เพิ่มใหม่เลือกคลังแบบเดียวกับตอนสร้างออเดอร์
+// waiting คือจำนวนที่ออเดอร์ต่าง ๆ รอสต็อกอยู่ ถ้ามีคนรอ จำนวนที่เพิ่มจะต่อคิวแทนการตัดสต็อก
+func planOrderEdit(current []models.OrderItem, requested []models.OrderItem, products []*models.Product, waiting map[primitive.ObjectID]int, reprice bool, defaultLocation primitive.ObjectID) (*orderEdit, error) {
+	edit := &orderEdit{}
+	var added []models.OrderItem
+	var addedProducts []*models.Product
+
+	wanted := map[primitive.ObjectID]bool{}
+	for i, req := range requested {
+		wanted[req.ProductID] = true
+		from, remaining := 0, req.Quantity
+		last := -1
+		for _, line := range current {
+			if line.ProductID != req.ProductID {
+				continue
+			}
+			from += line.Quantity
+			keep := min(line.Quantity, remaining)
+			remaining -= keep
+			// ส่วนที่ลดลงตัดจากจำนวนที่ยังรอสต็อกก่อน ที่เหลือจึงคืนเข้าคลัง
+			waiting := max(line.Backordered-(line.Quantity-keep), 0)
+			if release := line.Reserved() - (keep - waiting); release > 0 {
+				edit.Release = append(edit.Release, models.OrderItem{ProductID: line.ProductID, LocationID: line.LocationID, Quantity: release})
+			}
+			if keep == 0 {
+				continue
+			}
+			item := line
+			item.Quantity, item.Backordered = keep, waiting
+			if reprice {
+				item.UnitPrice, item.TaxClass = req.UnitPrice, req.TaxClass
+			}
+			edit.Items = append(edit.Items, item)
+			edit.Products = append(edit.Products, products[i])
+			last = len(edit.Items) - 1
+		}
+
+		if remaining > 0 && !products[i].IsActive {
+			return nil, &handlerError{http.StatusBadRequest, fmt.Sprintf("Product %s is no longer available", products[i].Name)}
+		}
+		switch {
+		case remaining > 0 && last >= 0:
+			item := &edit.Items[last]
+			reserve := min(products[i].StockAt(item.LocationID), remaining)
+			if queued := waiting[req.ProductID]; queued > 0 && (products[i].AllowBackorder || products[i].Stock-queued < remaining) {
+				// สต็อกที่มีเป็นของออเดอร์ที่รออยู่ก่อน
+				reserve = 0
+			}
+			if reserve < remaining && !products[i].AllowBackorder {
+				return nil, &handlerError{http.StatusBadRequest, fmt.Sprintf("Insufficient stock for %s", products[i].Name)}
+			}
+			item.Quantity += remaining
+			item.Backordered += remaining - reserve
+			if reserve > 0 {
+				edit.Reserve = append(edit.Reserve, models.OrderItem{ProductID: item.ProductID, LocationID: item.LocationID, Quantity: reserve})
+			}
+		case remaining > 0:
+			if req.Quantity > products[i].Stock-waiting[req.ProductID] && !products[i].AllowBackorder {
+				return nil, &handlerError{http.StatusBadRequest, fmt.Sprintf("Insufficient stock for %s", products[i].Name)}
+			}
+			added = append(added, req)
+			addedProducts = append(addedProducts, products[i])
+		}
+		if from != req.Quantity {
+			edit.Changes = append(edit.Changes, models.OrderItemChange{ProductID: req.ProductID, From: from, To: req.Quantity})
+		}
+	}
+
+	removed := map[primitive.ObjectID]int{}
+	for _, line := range current {
+		if wanted[line.ProductID] {
+			continue
+		}
+		edit.Release = append(edit.Release, line)
+		if index, ok := removed[line.ProductID]; ok {
+			edit.Changes[index].From += line.Quantity
+			continue
+		}
+		removed[line.ProductID] = len(edit.Changes)
+		edit.Changes = append(edit.Changes, models.OrderItemChange{ProductID: line.ProductID, From: line.Quantity, To: 0})
+	}
+
+	if len(added) > 0 {
+		markBackorders(added, addedProducts, primitive.NilObjectID, waiting)
+		if err := allocateLocations(added, addedProducts, primitive.NilObjectID, defaultLocation); err != nil {
+			return nil, &handlerError{http.StatusBadRequest, err.Error()}
+		}
+		edit.Items = append(edit.Items, added...)
+		edit.Products = append(edit.Products, addedProducts...)
+		edit.Reserve = append(edit.Reserve, added...)
+	}
+	return edit, nil
+}
+
+func mergeOrderItemRequests(items []OrderItemRequest) []OrderItemRequest {
+	merged := []OrderItemRequest{}
+	index := map[string]int{}
+	for _, item := range items {
+		key := strings.ToLower(item.ProductID)
+		if i, ok := index[key]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+func promotionsNotIn(applied []models.AppliedPromotion, other []models.AppliedPromotion) []models.AppliedPromotion {
+	var result []models.AppliedPromotion
+	for _, promo := range applied {
+		found := false
+		for _, o := range other {
+			if o.PromotionID == promo.PromotionID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, promo)
+		}
+	}
+	return result
+}
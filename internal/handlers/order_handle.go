@@ -3,12 +3,18 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/outbox"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/shipping"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/tax"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/thaiaddress"
 	"github.com/simple-business-management-api/go-backend-api/internal/pkg/utility"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/webhook"
 	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -16,9 +22,15 @@ import (
 )
 
 type OrderHandle struct {
-	OrderRep    repositories.OrderRepositoryInterface
-	ProductRep  repositories.ProductRepositoryInterface
-	CustomerRep repositories.CustomerRepositoryInterface
+	OrderRep     repositories.OrderRepositoryInterface
+	ProductRep   repositories.ProductRepositoryInterface
+	CustomerRep  repositories.CustomerRepositoryInterface
+	PromotionRep repositories.PromotionRepositoryInterface
+	TaxCalc      *tax.Calculator
+	Events       outbox.Recorder
+	Addresses    *thaiaddress.Dataset
+	CarrierRep   repositories.CarrierRepositoryInterface
+	ShipmentRep  repositories.ShipmentRepositoryInterface
 }
 
 type OrderItemRequest struct {
@@ -28,10 +40,21 @@ type OrderItemRequest struct {
 
 type OrderRequest struct {
 	Items            []OrderItemRequest `json:"items" form:"items" binding:"required,dive"`
+	LocationID       string             `json:"location_id" form:"location_id"`
 	CustomerFullName string             `json:"customer_fullname" form:"customer_fullname" binding:"required"`
 	CustomerEmail    string             `json:"customer_email" form:"customer_email" binding:"required,email"`
 	CustomerPhone    string             `json:"customer_phone" form:"customer_phone" binding:"required"`
 	CustomerAddress  string             `json:"customer_address" form:"customer_address" binding:"required"`
+	CouponCode       string             `json:"coupon_code" form:"coupon_code"`
+	UpdateCustomer   bool               `json:"update_customer" form:"update_customer"` // แก้ที่อยู่ของลูกค้าเดิมตามที่ส่งมา
+	// ที่อยู่จัดส่งเลือกจากสมุดที่อยู่ด้วย shipping_address_id หรือส่งที่อยู่ใหม่มาใน shipping_address
+	// ถ้าไม่ระบุใช้ที่อยู่หลักของลูกค้า ที่อยู่ใบกำกับภาษีถ้าไม่ระบุใช้ที่อยู่หลักหรือที่อยู่จัดส่ง
+	ShippingAddressID string          `json:"shipping_address_id" form:"shipping_address_id"`
+	ShippingAddress   *AddressRequest `json:"shipping_address" form:"-"`
+	BillingAddressID  string          `json:"billing_address_id" form:"billing_address_id"`
+	// ไม่ระบุ carrier_id คือรับสินค้าเองไม่มีค่าส่ง ไม่ระบุ shipping_service ใช้บริการที่ถูกที่สุด
+	CarrierID       string `json:"carrier_id" form:"carrier_id"`
+	ShippingService string `json:"shipping_service" form:"shipping_service"`
 }
 
 type UpdateOrderRequest struct {
@@ -40,8 +63,124 @@ type UpdateOrderRequest struct {
 	TrackingNumber string `json:"tracking_number" form:"tracking_number"`
 }
 
-func NewOrderHandle(orderRepo repositories.OrderRepositoryInterface, customerRepo repositories.CustomerRepositoryInterface, productRepo repositories.ProductRepositoryInterface) *OrderHandle {
-	return &OrderHandle{OrderRep: orderRepo, CustomerRep: customerRepo, ProductRep: productRepo}
+func NewOrderHandle(orderRepo repositories.OrderRepositoryInterface, customerRepo repositories.CustomerRepositoryInterface, productRepo repositories.ProductRepositoryInterface, promotionRepo repositories.PromotionRepositoryInterface, taxCalc *tax.Calculator, events outbox.Recorder, addresses *thaiaddress.Dataset, carrierRepo repositories.CarrierRepositoryInterface, shipmentRepo repositories.ShipmentRepositoryInterface) *OrderHandle {
+	return &OrderHandle{OrderRep: orderRepo, CustomerRep: customerRepo, ProductRep: productRepo, PromotionRep: promotionRepo, TaxCalc: taxCalc, Events: events, Addresses: addresses, CarrierRep: carrierRepo, ShipmentRep: shipmentRepo}
+}
+
+// findOrCreateCustomer ใช้ลูกค้าเดิมที่อีเมลหรือเบอร์โทรตรงกัน ถ้าไม่พบจึงสร้างใหม่
+func (h *OrderHandle) findOrCreateCustomer(ctx context.Context, input OrderRequest, role string) (*models.Customer, error) {
+	customer, err := h.CustomerRep.FindMatch(ctx, input.CustomerEmail, input.CustomerPhone, role)
+	if err == mongo.ErrNoDocuments {
+		customer = &models.Customer{
+			FullName:  input.CustomerFullName,
+			Email:     input.CustomerEmail,
+			Phone:     input.CustomerPhone,
+			Address:   input.CustomerAddress,
+			CreatedAt: time.Now(),
+		}
+		result, err := h.CustomerRep.Insert(ctx, customer, role)
+		if err != nil {
+			return nil, err
+		}
+		customer.ID = result.InsertedID.(primitive.ObjectID)
+		return customer, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if input.UpdateCustomer && input.CustomerAddress != customer.Address {
+		if _, err := h.CustomerRep.Update(ctx, customer.ID, bson.M{"address": input.CustomerAddress}, role); err != nil {
+			return nil, err
+		}
+		customer.Address = input.CustomerAddress
+	}
+	return customer, nil
+}
+
+// orderAddresses เลือกที่อยู่จัดส่งและที่อยู่ใบกำกับภาษีของออเดอร์ คืนเป็นสำเนาที่เก็บกับออเดอร์
+// ที่อยู่ใหม่ที่ส่งมากับออเดอร์จะถูกบันทึกลงสมุดที่อยู่ของลูกค้าด้วย
+func (h *OrderHandle) orderAddresses(ctx context.Context, customer *models.Customer, input OrderRequest, role string) (*models.Address, *models.Address, error) {
+	var shipping *models.Address
+	switch {
+	case input.ShippingAddress != nil:
+		address, err := resolveAddress(h.Addresses, *input.ShippingAddress)
+		if err == thaiaddress.ErrUnknownPostcode || err == thaiaddress.ErrMismatch {
+			return nil, nil, &handlerError{http.StatusBadRequest, "Invalid shipping address: " + err.Error()}
+		} else if err != nil {
+			return nil, nil, err
+		}
+		address.ID = primitive.NewObjectID()
+		customer.Addresses = setDefaultAddress(append(customer.Addresses, address), address.ID, input.ShippingAddress.DefaultShipping, input.ShippingAddress.DefaultBilling)
+		if _, err := h.CustomerRep.Update(ctx, customer.ID, bson.M{"addresses": customer.Addresses}, role); err != nil {
+			return nil, nil, err
+		}
+		shipping = customer.FindAddress(address.ID)
+	case input.ShippingAddressID != "":
+		id, err := primitive.ObjectIDFromHex(input.ShippingAddressID)
+		if err != nil {
+			return nil, nil, &handlerError{http.StatusBadRequest, "Invalid shipping address ID"}
+		}
+		if shipping = customer.FindAddress(id); shipping == nil {
+			return nil, nil, &handlerError{http.StatusNotFound, "Shipping address not found"}
+		}
+	default:
+		shipping = customer.DefaultShippingAddress()
+	}
+
+	billing := customer.DefaultBillingAddress()
+	if input.BillingAddressID != "" {
+		id, err := primitive.ObjectIDFromHex(input.BillingAddressID)
+		if err != nil {
+			return nil, nil, &handlerError{http.StatusBadRequest, "Invalid billing address ID"}
+		}
+		if billing = customer.FindAddress(id); billing == nil {
+			return nil, nil, &handlerError{http.StatusNotFound, "Billing address not found"}
+		}
+	}
+	if billing == nil {
+		billing = shipping
+	}
+
+	if shipping == nil {
+		return nil, nil, nil
+	}
+	return shipping.Snapshot(), billing.Snapshot(), nil
+}
+
+// quoteShipping คิดค่าส่งจากน้ำหนักสินค้าและจังหวัดของที่อยู่จัดส่ง คืน nil ถ้าลูกค้ารับสินค้าเอง
+func (h *OrderHandle) quoteShipping(ctx context.Context, input OrderRequest, address *models.Address, items []models.OrderItem, products []*models.Product, total models.Money) (*models.ShippingMethod, error) {
+	if input.CarrierID == "" {
+		return nil, nil
+	}
+	if address == nil {
+		return nil, &handlerError{http.StatusBadRequest, "Shipping address is required for delivery"}
+	}
+	carrierID, err := primitive.ObjectIDFromHex(input.CarrierID)
+	if err != nil {
+		return nil, &handlerError{http.StatusBadRequest, "Invalid carrier ID"}
+	}
+	carrier, err := h.CarrierRep.FindByID(ctx, carrierID)
+	if err == mongo.ErrNoDocuments || (err == nil && !carrier.IsActive) {
+		return nil, &handlerError{http.StatusNotFound, "Carrier not found or inactive"}
+	} else if err != nil {
+		return nil, err
+	}
+
+	weight := 0
+	for i, item := range items {
+		weight += products[i].WeightGrams * item.Quantity
+	}
+
+	var method *models.ShippingMethod
+	if input.ShippingService == "" {
+		method, err = shipping.Cheapest(carrier, address.Province, weight, total)
+	} else {
+		method, err = shipping.Quote(carrier, input.ShippingService, address.Province, weight, total)
+	}
+	if err == shipping.ErrNoRate || err == shipping.ErrCurrencyMismatch {
+		return nil, &handlerError{http.StatusBadRequest, err.Error()}
+	}
+	return method, err
 }
 
 func (h *OrderHandle) CreateOrders(c *gin.Context) {
@@ -67,82 +206,141 @@ func (h *OrderHandle) CreateOrders(c *gin.Context) {
 		return
 	}
 
-	var customer models.Customer
-	_, err := h.CustomerRep.FindByEmail(ctx, input.CustomerEmail, RoleVar.(string))
-	if err == mongo.ErrNoDocuments {
-		customer = models.Customer{
-			FullName:  input.CustomerFullName,
-			Email:     input.CustomerEmail,
-			Phone:     input.CustomerPhone,
-			Address:   input.CustomerAddress,
-			CreatedAt: time.Now(),
-		}
-		result, err := h.CustomerRep.Insert(ctx, &customer, RoleVar.(string))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create customer"})
-			return
-		}
-		customer.ID = result.InsertedID.(primitive.ObjectID)
+	customer, err := h.findOrCreateCustomer(ctx, input, RoleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create customer"})
+		return
+	}
+
+	shippingAddress, billingAddress, err := h.orderAddresses(ctx, customer, input, RoleVar.(string))
+	if err != nil {
+		writeHandlerError(c, err)
+		return
+	}
+
+	if _, err := h.placeOrder(ctx, input, customer, shippingAddress, billingAddress, nil, Create_by, RoleVar.(string)); err != nil {
+		writeHandlerError(c, err)
+		return
 	}
 
-	var orderItems []models.OrderItem
-	var totalAmount float64
+	c.JSON(http.StatusCreated, gin.H{"message": "Order placed successfully"})
+}
 
-	for _, item := range input.Items {
-		productID, err := primitive.ObjectIDFromHex(item.ProductID)
+// placeOrder ตรวจสต็อก คิดราคา จองโปรโมชัน ตัดสต็อก แล้วบันทึกออเดอร์ ใช้ทั้งตอนสร้างออเดอร์และแปลงใบเสนอราคา
+// ถ้ามี quotation ราคาต่อหน่วยและค่าส่งจะใช้ตามที่เสนอไว้ ส่วนลดและภาษียังคิดใหม่ตามปกติ
+func (h *OrderHandle) placeOrder(ctx context.Context, input OrderRequest, customer *models.Customer, shippingAddress *models.Address, billingAddress *models.Address, quotation *models.Quotation, createdBy primitive.ObjectID, role string) (*models.Order, error) {
+	var fulfilFrom primitive.ObjectID
+	if input.LocationID != "" {
+		var err error
+		fulfilFrom, err = primitive.ObjectIDFromHex(input.LocationID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID: " + item.ProductID})
-			return
+			return nil, &handlerError{http.StatusBadRequest, "Invalid location ID"}
 		}
+	}
 
-		var product *models.Product
-		product, err = h.ProductRep.FindByID(ctx, productID, true)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found: " + item.ProductID})
-			return
+	orderItems, products, err := h.loadOrderItems(ctx, input.Items, nil)
+	if err != nil {
+		return nil, err
+	}
+	if quotation != nil {
+		for i := range orderItems {
+			if price, ok := quotation.UnitPrice(orderItems[i].ProductID); ok {
+				orderItems[i].UnitPrice = price
+			}
 		}
+	}
 
-		if item.Quantity > product.Stock {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Insufficient stock for %s", product.Name)})
-			return
+	waiting, err := h.waitingBackorders(ctx, products)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range orderItems {
+		if item.Quantity > products[i].Stock-waiting[item.ProductID] && !products[i].AllowBackorder {
+			return nil, &handlerError{http.StatusBadRequest, fmt.Sprintf("Insufficient stock for %s", products[i].Name)}
 		}
+	}
 
-		orderItems = append(orderItems, models.OrderItem{
-			ProductID: productID,
-			Quantity:  item.Quantity,
-			UnitPrice: product.Price,
-		})
+	priced, err := h.priceOrder(ctx, orderItems, products, input.CouponCode)
+	if err != nil {
+		return nil, err
+	}
+	orderItems = priced.Items
 
-		totalAmount += float64(item.Quantity) * product.Price
+	var shippingMethod *models.ShippingMethod
+	if quotation != nil {
+		shippingMethod = quotation.Shipping
+	} else {
+		shippingMethod, err = h.quoteShipping(ctx, input, shippingAddress, orderItems, products, priced.Total)
+		if err != nil {
+			return nil, err
+		}
+	}
+	shippingTotal, err := h.addShipping(priced, shippingMethod)
+	if err != nil {
+		return nil, err
 	}
 
-	order := models.Order{
-		CustomerID:      customer.ID,
-		CreatedBy:       Create_by,
-		Status:          "Pending",
-		TotalAmount:     totalAmount,
-		Items:           orderItems,
-		CreatedAt:       time.Now(),
-		Tracking_number: utility.GenerateTrackingNumber(),
-		Note:            "อยู่ระหว่างดําเนินการ",
+	markBackorders(orderItems, products, fulfilFrom, waiting)
+	if err := allocateLocations(orderItems, products, fulfilFrom, h.ProductRep.DefaultLocation()); err != nil {
+		return nil, &handlerError{http.StatusBadRequest, err.Error()}
 	}
 
-	err = h.OrderRep.Insert(ctx, &order, RoleVar.(string))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
-		return
+	if err := h.reservePromotions(ctx, priced.Discounts); err != nil {
+		return nil, err
 	}
 
-	for _, item := range input.Items {
-		productID, _ := primitive.ObjectIDFromHex(item.ProductID)
-		err := h.ProductRep.UpdateStock(ctx, productID, -item.Quantity)
+	var reserved []models.OrderItem
+	for _, item := range orderItems {
+		if item.Reserved() == 0 {
+			continue
+		}
+		err := h.ProductRep.UpdateLocationStock(ctx, item.ProductID, item.LocationID, -item.Reserved())
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock"})
-			return
+			releaseStock(ctx, h.ProductRep, reserved)
+			h.releasePromotions(ctx, priced.Discounts)
+			if err == repositories.ErrInsufficientStock {
+				return nil, &handlerError{http.StatusConflict, "Stock changed while placing order, please retry"}
+			}
+			return nil, &handlerError{http.StatusInternalServerError, "Failed to update stock"}
 		}
+		reserved = append(reserved, item)
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Order placed successfully"})
+	order := models.Order{
+		CustomerID:       customer.ID,
+		CreatedBy:        createdBy,
+		Status:           "Pending",
+		PricesIncludeTax: h.TaxCalc.PricesIncludeTax,
+		Subtotal:         priced.Subtotal,
+		Discounts:        priced.Discounts,
+		DiscountTotal:    priced.DiscountTotal,
+		TaxTotal:         priced.TaxTotal,
+		ShippingTotal:    shippingTotal,
+		TotalAmount:      priced.Total,
+		Items:            orderItems,
+		ShippingAddress:  shippingAddress,
+		BillingAddress:   billingAddress,
+		Shipping:         shippingMethod,
+		CreatedAt:        time.Now(),
+		Tracking_number:  utility.GenerateTrackingNumber(),
+		Note:             "อยู่ระหว่างดําเนินการ",
+	}
+	if quotation != nil {
+		order.QuotationID = &quotation.ID
+	}
+
+	err = h.Events.Transaction(ctx, func(ctx context.Context) error {
+		if err := h.OrderRep.Insert(ctx, &order, role); err != nil {
+			return err
+		}
+		return h.Events.Record(ctx, order.ID.Hex(), webhook.EventOrderCreated, order)
+	})
+	if err != nil {
+		releaseStock(ctx, h.ProductRep, reserved)
+		h.releasePromotions(ctx, priced.Discounts)
+		return nil, &handlerError{http.StatusInternalServerError, "Failed to create order"}
+	}
+	return &order, nil
 }
 
 func (h *OrderHandle) GetOrders(c *gin.Context) {
@@ -206,8 +404,7 @@ func (h *OrderHandle) UpdateOrder(c *gin.Context) {
 		return
 	}
 
-	var order models.Order
-	_, err = h.OrderRep.FindByID(ctx, orderIDHex, roleVar.(string))
+	order, err := h.OrderRep.FindByID(ctx, orderIDHex, roleVar.(string))
 	if err == mongo.ErrNoDocuments {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
@@ -231,15 +428,29 @@ func (h *OrderHandle) UpdateOrder(c *gin.Context) {
 		update["tracking_number"] = input.TrackingNumber
 	}
 
-	res, err := h.OrderRep.Update(ctx, orderIDHex, update, roleVar.(string))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
-		return
-	}
-
-	if res.MatchedCount == 0 {
+	err = h.Events.Transaction(ctx, func(ctx context.Context) error {
+		res, err := h.OrderRep.Update(ctx, orderIDHex, update, roleVar.(string))
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return mongo.ErrNoDocuments
+		}
+		if input.Status == order.Status {
+			return nil
+		}
+		updated, err := h.OrderRep.FindByID(ctx, orderIDHex, roleVar.(string))
+		if err != nil {
+			return err
+		}
+		return recordStatusChanged(ctx, h.Events, order.Status, updated)
+	})
+	if err == mongo.ErrNoDocuments {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Order updated successfully"})
@@ -290,3 +501,110 @@ func (h *OrderHandle) DeleteOrder(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Order deleted successfully"})
 }
+
+// waitingBackorders คือจำนวนที่ออเดอร์ที่สั่งก่อนยังรอสต็อกของสินค้าเหล่านี้อยู่
+// สต็อกส่วนนี้เป็นของออเดอร์เหล่านั้น แม้งานจัดสรรเบื้องหลังจะยังไม่ได้ตัดให้
+func (h *OrderHandle) waitingBackorders(ctx context.Context, products []*models.Product) (map[primitive.ObjectID]int, error) {
+	ids := make([]primitive.ObjectID, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	waiting, err := h.OrderRep.BackorderedQuantities(ctx, ids)
+	if err != nil {
+		return nil, &handlerError{http.StatusInternalServerError, "Failed to check backorders"}
+	}
+	return waiting, nil
+}
+
+// markBackorders กำหนดจำนวนที่ต้องรอสต็อกของสินค้าที่เปิดรับ backorder
+// ถ้ามีออเดอร์ก่อนหน้ารอสินค้านั้นอยู่ รายการใหม่จะต่อคิวทั้งหมดเพื่อให้ออเดอร์ที่สั่งก่อนได้สต็อกก่อน
+// ไม่อย่างนั้นส่วนที่ไม่ต้องรอจะตัดจากคลังที่ระบุ หรือคลังที่มีของมากที่สุดถ้าไม่ระบุ
+func markBackorders(items []models.OrderItem, products []*models.Product, fulfilFrom primitive.ObjectID, waiting map[primitive.ObjectID]int) {
+	for i, item := range items {
+		if !products[i].AllowBackorder {
+			continue
+		}
+		if waiting[item.ProductID] > 0 {
+			items[i].Backordered = item.Quantity
+			continue
+		}
+		available := products[i].StockAt(fulfilFrom)
+		if fulfilFrom.IsZero() {
+			_, available = products[i].BestLocation()
+		}
+		items[i].Backordered = max(item.Quantity-available, 0)
+	}
+}
+
+// allocateLocations เลือกคลังที่ใช้ตัดสต็อกให้แต่ละรายการ ถ้าระบุคลังมาจะใช้คลังนั้นทั้งออเดอร์
+// ถ้าไม่ระบุจะพยายามหาคลังเดียวที่มีของครบทุกรายการก่อน (เริ่มจากคลังเริ่มต้น) แล้วค่อยแยกเป็นรายการ
+// นับเฉพาะจำนวนที่ต้องตัดสต็อกทันที ไม่รวมจำนวนที่ backorder
+func allocateLocations(items []models.OrderItem, products []*models.Product, fulfilFrom primitive.ObjectID, defaultLocation primitive.ObjectID) error {
+	if !fulfilFrom.IsZero() {
+		for i, item := range items {
+			if products[i].StockAt(fulfilFrom) < item.Reserved() {
+				return fmt.Errorf("Insufficient stock for %s at selected location", products[i].Name)
+			}
+			items[i].LocationID = fulfilFrom
+		}
+		return nil
+	}
+
+	candidates := []primitive.ObjectID{defaultLocation}
+	for _, product := range products {
+		for _, location := range product.Locations {
+			if !containsObjectID(candidates, location.LocationID) {
+				candidates = append(candidates, location.LocationID)
+			}
+		}
+	}
+
+	for _, locationID := range candidates {
+		canFulfil := true
+		for i, item := range items {
+			if products[i].StockAt(locationID) < item.Reserved() {
+				canFulfil = false
+				break
+			}
+		}
+		if canFulfil {
+			for i := range items {
+				items[i].LocationID = locationID
+			}
+			return nil
+		}
+	}
+
+	for i, item := range items {
+		if item.Reserved() == 0 {
+			items[i].LocationID = defaultLocation
+			continue
+		}
+		best, bestQty := products[i].BestLocation()
+		if bestQty < item.Reserved() {
+			return fmt.Errorf("Insufficient stock for %s at any single location", products[i].Name)
+		}
+		items[i].LocationID = best
+	}
+	return nil
+}
+
+func releaseStock(ctx context.Context, productRepo repositories.ProductRepositoryInterface, items []models.OrderItem) {
+	for _, item := range items {
+		if item.Reserved() == 0 {
+			continue
+		}
+		if err := productRepo.UpdateLocationStock(ctx, item.ProductID, item.LocationID, item.Reserved()); err != nil {
+			log.Printf("failed to release %d stock of %s: %v", item.Reserved(), item.ProductID.Hex(), err)
+		}
+	}
+}
+
+func containsObjectID(ids []primitive.ObjectID, target primitive.ObjectID) bool {
+	for _, id := range ids {
+		if id == target {
+			return true
+		}
+	}
+	return false
+}
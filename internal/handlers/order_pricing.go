@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/money"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/promotion"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/tax"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type pricedOrder struct {
+	Items         []models.OrderItem
+	Products      []*models.Product
+	Subtotal      models.Money
+	DiscountTotal models.Money
+	TaxTotal      models.Money
+	Total         models.Money
+	Discounts     []models.AppliedPromotion
+}
+
+// add รวมยอดของบรรทัดเข้ายอดรวม ค่าส่งไม่นับเข้า Subtotal เพราะเก็บแยกใน ShippingTotal
+func (p *pricedOrder) add(line tax.Line, subtotal bool) error {
+	var err error
+	if subtotal {
+		if p.Subtotal, err = p.Subtotal.Add(line.Net); err != nil {
+			return err
+		}
+	}
+	if p.TaxTotal, err = p.TaxTotal.Add(line.Tax); err != nil {
+		return err
+	}
+	p.Total, err = p.Total.Add(line.Gross)
+	return err
+}
+
+// สินค้าใน onOrder อยู่ในออเดอร์แล้ว จึงอ่านได้แม้ถูกปิดขายไปแล้ว
+func (h *OrderHandle) loadOrderItems(ctx context.Context, input []OrderItemRequest, onOrder map[primitive.ObjectID]bool) ([]models.OrderItem, []*models.Product, error) {
+	var items []models.OrderItem
+	var products []*models.Product
+
+	for _, item := range input {
+		productID, err := primitive.ObjectIDFromHex(item.ProductID)
+		if err != nil {
+			return nil, nil, &handlerError{http.StatusBadRequest, "Invalid product ID: " + item.ProductID}
+		}
+
+		product, err := h.ProductRep.FindByID(ctx, productID, true)
+		if err == mongo.ErrNoDocuments && onOrder[productID] {
+			product, err = h.ProductRep.FindByID(ctx, productID, false)
+		}
+		if err != nil {
+			return nil, nil, &handlerError{http.StatusNotFound, "Product not found: " + item.ProductID}
+		}
+
+		if len(products) > 0 && product.Price.Currency != products[0].Price.Currency {
+			return nil, nil, &handlerError{http.StatusBadRequest, "All items in an order must use the same currency"}
+		}
+
+		items = append(items, models.OrderItem{
+			ProductID: productID,
+			Quantity:  item.Quantity,
+			UnitPrice: product.Price,
+			TaxClass:  product.TaxClass,
+		})
+		products = append(products, product)
+	}
+	return items, products, nil
+}
+
+// priceOrder คำนวณส่วนลด ภาษี และยอดรวมจาก UnitPrice ที่อยู่ในรายการ ส่วนลดถูกหักก่อนคิดภาษี
+func (h *OrderHandle) priceOrder(ctx context.Context, items []models.OrderItem, products []*models.Product, couponCode string) (*pricedOrder, error) {
+	now := time.Now()
+	promos, err := h.PromotionRep.FindAutomatic(ctx, now)
+	if err != nil {
+		return nil, &handlerError{http.StatusInternalServerError, "Failed to load promotions"}
+	}
+	if couponCode != "" {
+		coupon, err := h.PromotionRep.FindByCode(ctx, couponCode)
+		if err == mongo.ErrNoDocuments {
+			return nil, &handlerError{http.StatusBadRequest, "Invalid coupon code"}
+		} else if err != nil {
+			return nil, &handlerError{http.StatusInternalServerError, "Failed to load coupon"}
+		}
+		promos = append(promos, *coupon)
+	}
+	return h.applyPricing(items, products, promos, nil, now)
+}
+
+// โปรโมชันที่ออเดอร์ใช้อยู่แล้วนับการใช้ไปตอนสร้างออเดอร์ จึงใช้ต่อได้แม้หมดอายุหรือใช้ครบจำนวนแล้ว
+func (h *OrderHandle) priceEditedOrder(ctx context.Context, items []models.OrderItem, products []*models.Product, applied []models.AppliedPromotion) (*pricedOrder, error) {
+	now := time.Now()
+	promos, err := h.PromotionRep.FindAutomatic(ctx, now)
+	if err != nil {
+		return nil, &handlerError{http.StatusInternalServerError, "Failed to load promotions"}
+	}
+	if len(applied) == 0 {
+		return h.applyPricing(items, products, promos, nil, now)
+	}
+
+	locked := map[primitive.ObjectID]bool{}
+	var ids []primitive.ObjectID
+	for _, promo := range applied {
+		locked[promo.PromotionID] = true
+		ids = append(ids, promo.PromotionID)
+	}
+	existing, err := h.PromotionRep.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, &handlerError{http.StatusInternalServerError, "Failed to load promotions"}
+	}
+	loaded := map[primitive.ObjectID]bool{}
+	for _, promo := range promos {
+		loaded[promo.ID] = true
+	}
+	// คงลำดับเดียวกับตอนสร้างออเดอร์ โปรโมชันอัตโนมัติเรียงตามเวลาที่สร้าง แล้วจึงเป็นคูปอง
+	var coupons []models.Promotion
+	for _, promo := range existing {
+		if promo.Code != "" {
+			coupons = append(coupons, promo)
+		} else if !loaded[promo.ID] {
+			promos = append(promos, promo)
+		}
+	}
+	sort.SliceStable(promos, func(i, j int) bool {
+		return promos[i].CreatedAt.Before(promos[j].CreatedAt)
+	})
+	return h.applyPricing(items, products, append(promos, coupons...), locked, now)
+}
+
+func (h *OrderHandle) applyPricing(items []models.OrderItem, products []*models.Product, promos []models.Promotion, locked map[primitive.ObjectID]bool, now time.Time) (*pricedOrder, error) {
+	if len(items) == 0 {
+		return nil, &handlerError{http.StatusBadRequest, "Order must contain at least one item"}
+	}
+	currency := items[0].UnitPrice.Currency
+
+	lines := make([]promotion.Line, len(items))
+	for i, item := range items {
+		lines[i] = promotion.Line{ProductID: item.ProductID, Quantity: item.Quantity, UnitPrice: item.UnitPrice}
+	}
+	discounts, err := promotion.Apply(promos, lines, currency, now, locked)
+	if err != nil {
+		return nil, &handlerError{http.StatusBadRequest, err.Error()}
+	}
+
+	priced := &pricedOrder{
+		Products:      products,
+		Subtotal:      money.Zero(currency),
+		DiscountTotal: discounts.Total,
+		TaxTotal:      money.Zero(currency),
+		Total:         money.Zero(currency),
+		Discounts:     discounts.Applied,
+	}
+
+	for i, item := range items {
+		item.Discount = models.Money{Amount: discounts.LineDiscounts[i], Currency: currency}
+		amount, err := item.UnitPrice.Mul(item.Quantity).Sub(item.Discount)
+		if err != nil {
+			return nil, &handlerError{http.StatusBadRequest, err.Error()}
+		}
+		line, err := h.TaxCalc.CalculateAmount(amount, item.TaxClass)
+		if err != nil {
+			return nil, &handlerError{http.StatusInternalServerError, fmt.Sprintf("Cannot calculate tax for %s: %v", products[i].Name, err)}
+		}
+
+		item.TaxClass = line.TaxClass
+		item.TaxRate = line.Rate
+		item.NetAmount = line.Net
+		item.TaxAmount = line.Tax
+		item.LineTotal = line.Gross
+		priced.Items = append(priced.Items, item)
+
+		if err := priced.add(line, true); err != nil {
+			return nil, &handlerError{http.StatusBadRequest, err.Error()}
+		}
+	}
+
+	return priced, nil
+}
+
+// addShipping คิดภาษีค่าส่งด้วย ShippingClass ตามโหมดราคารวม/ไม่รวมภาษีเดียวกับสินค้า แล้วรวมเข้ายอดภาษีและยอดรวม
+// คืนค่าส่งก่อนภาษีสำหรับ ShippingTotal
+func (h *OrderHandle) addShipping(priced *pricedOrder, method *models.ShippingMethod) (models.Money, error) {
+	if method == nil {
+		return money.Zero(priced.Total.Currency), nil
+	}
+	line, err := h.TaxCalc.CalculateAmount(method.Fee, h.TaxCalc.ShippingClass)
+	if err != nil {
+		return models.Money{}, &handlerError{http.StatusInternalServerError, fmt.Sprintf("Cannot calculate tax for shipping: %v", err)}
+	}
+	if err := priced.add(line, false); err != nil {
+		return models.Money{}, &handlerError{http.StatusBadRequest, "Shipping fee currency does not match the order"}
+	}
+	return line.Net, nil
+}
+
+func (h *OrderHandle) reservePromotions(ctx context.Context, applied []models.AppliedPromotion) error {
+	var reserved []models.AppliedPromotion
+	for _, promo := range applied {
+		if err := h.PromotionRep.IncrementUsage(ctx, promo.PromotionID); err != nil {
+			h.releasePromotions(ctx, reserved)
+			return &handlerError{http.StatusConflict, fmt.Sprintf("Promotion %s is no longer available", promo.Name)}
+		}
+		reserved = append(reserved, promo)
+	}
+	return nil
+}
+
+func (h *OrderHandle) releasePromotions(ctx context.Context, applied []models.AppliedPromotion) {
+	for _, promo := range applied {
+		if err := h.PromotionRep.DecrementUsage(ctx, promo.PromotionID); err != nil {
+			log.Printf("failed to release usage of promotion %s: %v", promo.PromotionID.Hex(), err)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/stream"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OrderStreamHandle struct {
+	Broker    stream.Broker
+	Tickets   *stream.Tickets
+	Heartbeat time.Duration
+	Upgrader  websocket.Upgrader
+}
+
+func NewOrderStreamHandle(broker stream.Broker, tickets *stream.Tickets, allowedOrigins []string) *OrderStreamHandle {
+	return &OrderStreamHandle{
+		Broker:    broker,
+		Tickets:   tickets,
+		Heartbeat: 25 * time.Second,
+		Upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 4096,
+			CheckOrigin:     checkStreamOrigin(allowedOrigins),
+		},
+	}
+}
+
+// checkStreamOrigin รับ WebSocket จากหน้าเว็บโดเมนเดียวกับ API หรือที่อยู่ใน allowed เท่านั้น
+// client ที่ไม่ใช่เบราว์เซอร์ไม่ส่ง Origin มาจึงผ่านได้
+func checkStreamOrigin(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		for _, o := range allowed {
+			if strings.EqualFold(strings.TrimRight(o, "/"), origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// IssueStreamTicket ออกตั๋วใช้ครั้งเดียวให้ผู้ใช้ที่ login ด้วย Authorization header นำไปเปิด stream ทาง query ticket
+func (h *OrderStreamHandle) IssueStreamTicket(c *gin.Context) {
+	userID, role, ok := streamViewer(c)
+	if !ok {
+		return
+	}
+	token, ticket, err := h.Tickets.Issue(userID.Hex(), role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue stream ticket"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"ticket": token, "expires_at": ticket.ExpiresAt})
+}
+
+// StreamOrders ส่ง event ของออเดอร์แบบ Server-Sent Events
+// ถ้าส่ง Last-Event-ID มาจะได้ event ที่พลาดไประหว่างหลุดก่อน (เท่าที่ยังอยู่ในประวัติ)
+func (h *OrderStreamHandle) StreamOrders(c *gin.Context) {
+	userID, role, ok := streamViewer(c)
+	if !ok {
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	backlog, sub := h.Broker.Subscribe(lastEventID)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	if _, err := fmt.Fprint(w, "retry: 3000\n\n"); err != nil {
+		return
+	}
+	w.Flush()
+
+	send := func(event stream.Event) bool {
+		if !stream.Visible(event, userID, role) {
+			return true
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return true
+		}
+		if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+			return false
+		}
+		w.Flush()
+		return true
+	}
+
+	for _, event := range backlog {
+		if !send(event) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(h.Heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok || !send(event) {
+				return
+			}
+		case <-ticker.C:
+			// comment ของ SSE กันไม่ให้ proxy ตัดการเชื่อมต่อที่เงียบนานเกินไป
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			w.Flush()
+		}
+	}
+}
+
+// StreamOrdersWebSocket ส่ง event ชุดเดียวกับ StreamOrders ผ่าน WebSocket เป็นข้อความ JSON ทีละ event
+func (h *OrderStreamHandle) StreamOrdersWebSocket(c *gin.Context) {
+	userID, role, ok := streamViewer(c)
+	if !ok {
+		return
+	}
+
+	conn, err := h.Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade ตอบ error กลับไปให้ client แล้ว
+		return
+	}
+	defer conn.Close()
+
+	backlog, sub := h.Broker.Subscribe(c.Query("last_event_id"))
+	defer sub.Close()
+
+	// client ไม่ต้องส่งอะไรมา แต่ต้องอ่านไว้เพื่อรับ pong และรู้ว่า client ปิดการเชื่อมต่อแล้ว
+	closed := make(chan struct{})
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(2 * h.Heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.Heartbeat))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(event stream.Event) bool {
+		if !stream.Visible(event, userID, role) {
+			return true
+		}
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return conn.WriteJSON(event) == nil
+	}
+
+	for _, event := range backlog {
+		if !send(event) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(h.Heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client too slow"),
+					time.Now().Add(time.Second))
+				return
+			}
+			if !send(event) {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func streamViewer(c *gin.Context) (primitive.ObjectID, string, bool) {
+	roleVar, _ := c.Get("role")
+	role, _ := roleVar.(string)
+	if role != "Admin" && role != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can watch orders"})
+		return primitive.NilObjectID, "", false
+	}
+
+	userIdVar, _ := c.Get("userId")
+	userIdStr, _ := userIdVar.(string)
+	userID, err := primitive.ObjectIDFromHex(userIdStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return primitive.NilObjectID, "", false
+	}
+	return userID, role, true
+}
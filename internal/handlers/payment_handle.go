@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/money"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/outbox"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/promptpay"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type PaymentRequest struct {
+	Amount    money.Decimal `json:"amount" form:"amount" binding:"required"`
+	Currency  string        `json:"currency" form:"currency" binding:"omitempty,len=3"`
+	Method    string        `json:"method" form:"method" binding:"required,oneof=cash bank_transfer promptpay card"`
+	Reference string        `json:"reference" form:"reference"`
+	PaidAt    string        `json:"paid_at" form:"paid_at"` // RFC3339 ถ้าไม่ระบุใช้เวลาปัจจุบัน
+}
+
+type PaymentHandle struct {
+	OrderRepo   repositories.OrderRepositoryInterface
+	PaymentRepo repositories.PaymentRepositoryInterface
+	PromptPayID string
+	Events      outbox.Recorder
+}
+
+func NewPaymentHandle(orderRepo repositories.OrderRepositoryInterface, paymentRepo repositories.PaymentRepositoryInterface, promptPayID string, events outbox.Recorder) *PaymentHandle {
+	return &PaymentHandle{OrderRepo: orderRepo, PaymentRepo: paymentRepo, PromptPayID: promptPayID, Events: events}
+}
+
+func (h *PaymentHandle) GetPayments(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can view payments"})
+		return
+	}
+
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	order, err := h.OrderRepo.FindByID(ctx, orderID, roleVar.(string))
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	payments, err := h.PaymentRepo.FindByOrder(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":        len(payments),
+		"payments":     payments,
+		"total_amount": order.TotalAmount,
+		"paid_amount":  order.PaidAmount,
+		"outstanding":  order.Outstanding(),
+		"status":       order.Status,
+	})
+}
+
+func (h *PaymentHandle) RecordPayment(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can record payments"})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	userID, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var input PaymentRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	paidAt := time.Now()
+	if input.PaidAt != "" {
+		paidAt, err = time.Parse(time.RFC3339, input.PaidAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "paid_at must be RFC3339"})
+			return
+		}
+	}
+
+	order, err := h.OrderRepo.FindByID(ctx, orderID, roleVar.(string))
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	currency := order.TotalAmount.Currency
+	if input.Currency != "" && !strings.EqualFold(input.Currency, currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payment currency must match order currency " + currency})
+		return
+	}
+	amount, err := input.Amount.Money(currency)
+	if err != nil || amount.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount"})
+		return
+	}
+
+	payment, updated, err := recordPayment(ctx, h.OrderRepo, h.PaymentRepo, h.Events, order.Status, models.Payment{
+		OrderID:    orderID,
+		Amount:     amount,
+		Method:     input.Method,
+		Reference:  strings.TrimSpace(input.Reference),
+		PaidAt:     paidAt,
+		RecordedBy: userID,
+	})
+	if err == repositories.ErrPaymentRejected {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "Payment exceeds outstanding balance or order is cancelled",
+			"outstanding": order.Outstanding(),
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Payment recorded successfully",
+		"payment":     payment,
+		"paid_amount": updated.PaidAmount,
+		"outstanding": updated.Outstanding(),
+		"status":      updated.Status,
+	})
+}
+
+// GetPromptPayQR สร้าง QR สำหรับยอดค้างชำระของออเดอร์ ค่าเริ่มต้นส่งเป็นรูป PNG
+// ส่ง format=json เพื่อรับ payload และรูปแบบ base64 ไปแสดงเอง
+func (h *PaymentHandle) GetPromptPayQR(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can create payment QR codes"})
+		return
+	}
+
+	if h.PromptPayID == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PromptPay ID is not configured"})
+		return
+	}
+
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	size := 320
+	if sizeStr := c.Query("size"); sizeStr != "" {
+		size, err = strconv.Atoi(sizeStr)
+		if err != nil || size < 128 || size > 1024 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Size must be between 128 and 1024"})
+			return
+		}
+	}
+
+	order, err := h.OrderRepo.FindByID(ctx, orderID, roleVar.(string))
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if strings.EqualFold(order.Status, "Cancelled") {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order is cancelled"})
+		return
+	}
+	outstanding := order.Outstanding()
+	if outstanding.Amount <= 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order is already fully paid"})
+		return
+	}
+
+	payload, err := promptpay.Payload(h.PromptPayID, outstanding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	image, err := promptpay.PNG(payload, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create QR code"})
+		return
+	}
+
+	if c.Query("format") == "json" {
+		c.JSON(http.StatusOK, gin.H{
+			"payload": payload,
+			"amount":  outstanding,
+			"image":   "data:image/png;base64," + base64.StdEncoding.EncodeToString(image),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", image)
+}
+
+// recordPayment บันทึกรายการชำระและเพิ่มยอดในออเดอร์ใน transaction เดียวกัน ถ้าออเดอร์ปฏิเสธยอดนี้รายการชำระจะไม่ถูกบันทึก
+// from คือสถานะของออเดอร์ก่อนชำระ ใช้บันทึก event เมื่อสถานะเปลี่ยน
+func recordPayment(ctx context.Context, orderRepo repositories.OrderRepositoryInterface, paymentRepo repositories.PaymentRepositoryInterface, events outbox.Recorder, from string, payment models.Payment) (*models.Payment, *models.Order, error) {
+	var order *models.Order
+	err := events.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		order, err = applyPayment(ctx, orderRepo, paymentRepo, events, from, &payment)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &payment, order, nil
+}
+
+// applyPayment ต้องเรียกภายใน transaction บันทึกรายการชำระ เพิ่มยอดในออเดอร์ และบันทึก event เมื่อสถานะเปลี่ยน
+func applyPayment(ctx context.Context, orderRepo repositories.OrderRepositoryInterface, paymentRepo repositories.PaymentRepositoryInterface, events outbox.Recorder, from string, payment *models.Payment) (*models.Order, error) {
+	payment.ID = primitive.NilObjectID
+	payment.CreatedAt = time.Now()
+	if err := paymentRepo.Insert(ctx, payment); err != nil {
+		return nil, err
+	}
+	order, err := orderRepo.ApplyPayment(ctx, payment.OrderID, payment.Amount.Amount, payment.PaidAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordStatusChanged(ctx, events, from, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
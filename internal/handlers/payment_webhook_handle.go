@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/gateway"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/outbox"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxWebhookBody = 1 << 20
+
+// errEventRejected ยกเลิก transaction เมื่อ event ใช้ไม่ได้ เพื่อไม่ให้รายการชำระที่เขียนไปแล้วค้างอยู่
+var errEventRejected = fmt.Errorf("payment event rejected")
+
+// webhook ไม่มีผู้ใช้ที่ login อยู่ จึงอ่านออเดอร์ด้วยสิทธิ์ Admin
+const systemRole = "Admin"
+
+var paymentMethods = map[string]bool{
+	models.PaymentCash:         true,
+	models.PaymentBankTransfer: true,
+	models.PaymentPromptPay:    true,
+	models.PaymentCard:         true,
+}
+
+type PaymentWebhookHandle struct {
+	OrderRepo   repositories.OrderRepositoryInterface
+	PaymentRepo repositories.PaymentRepositoryInterface
+	EventRepo   repositories.PaymentEventRepositoryInterface
+	Providers   *gateway.Registry
+	Events      outbox.Recorder
+}
+
+func NewPaymentWebhookHandle(orderRepo repositories.OrderRepositoryInterface, paymentRepo repositories.PaymentRepositoryInterface, eventRepo repositories.PaymentEventRepositoryInterface, providers *gateway.Registry, events outbox.Recorder) *PaymentWebhookHandle {
+	return &PaymentWebhookHandle{OrderRepo: orderRepo, PaymentRepo: paymentRepo, EventRepo: eventRepo, Providers: providers, Events: events}
+}
+
+// HandleWebhook รับ event จาก gateway ตอบ 2xx เมื่อบันทึกผลแล้ว (รวมถึงกรณีปฏิเสธยอด) เพื่อไม่ให้ gateway ส่งซ้ำ
+// ตอบ 5xx เฉพาะเมื่อฐานข้อมูลมีปัญหา ซึ่ง gateway จะส่ง event เดิมมาใหม่ได้
+func (h *PaymentWebhookHandle) HandleWebhook(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	provider, ok := h.Providers.Get(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown payment provider"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read body"})
+		return
+	}
+
+	event, err := provider.ParseWebhook(c.Request.Header, body)
+	if err == gateway.ErrInvalidSignature {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	record := models.PaymentEvent{
+		Provider:   provider.Name(),
+		EventID:    event.ID,
+		Type:       event.Type,
+		OrderID:    event.OrderID,
+		Payload:    string(body),
+		ReceivedAt: time.Now(),
+	}
+
+	if event.Type != gateway.EventPaymentSucceeded {
+		record.Status = models.PaymentEventIgnored
+		h.saveEvent(c, ctx, &record, "Event ignored")
+		return
+	}
+
+	// บันทึก event พร้อมรายการชำระใน transaction เดียว ถ้าล้มเหลวกลางทางจะไม่เหลือ event ค้างที่ทำให้ gateway ส่งซ้ำไม่ได้
+	var reason string
+	err = h.Events.Transaction(ctx, func(ctx context.Context) error {
+		record.ID = primitive.NilObjectID
+		record.Status = models.PaymentEventProcessed
+		if err := h.EventRepo.Insert(ctx, &record); err != nil {
+			return err
+		}
+		var payment *models.Payment
+		var err error
+		payment, reason, err = h.applyEvent(ctx, provider.Name(), event)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			return errEventRejected
+		}
+		return h.EventRepo.UpdateStatus(ctx, record.ID, bson.M{"payment_id": payment.ID})
+	})
+	switch {
+	case err == repositories.ErrDuplicateEvent:
+		c.JSON(http.StatusOK, gin.H{"message": "Event already received"})
+	case err == errEventRejected:
+		log.Printf("payment event %s/%s rejected: %s", record.Provider, record.EventID, reason)
+		record.ID = primitive.NilObjectID
+		record.Status = models.PaymentEventRejected
+		record.Error = reason
+		h.saveEvent(c, ctx, &record, "Event rejected")
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "Payment recorded"})
+	}
+}
+
+// saveEvent บันทึก event ที่ไม่ได้สร้างรายการชำระ ตอบ 5xx ถ้าบันทึกไม่ได้เพื่อให้ gateway ส่งมาใหม่
+func (h *PaymentWebhookHandle) saveEvent(c *gin.Context, ctx context.Context, record *models.PaymentEvent, message string) {
+	if err := h.EventRepo.Insert(ctx, record); err == repositories.ErrDuplicateEvent {
+		c.JSON(http.StatusOK, gin.H{"message": "Event already received"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	response := gin.H{"message": message}
+	if record.Error != "" {
+		response["reason"] = record.Error
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// applyEvent ต้องเรียกภายใน transaction คืน reason เมื่อข้อมูลใน event ใช้ไม่ได้ และคืน error เมื่อเป็นปัญหาชั่วคราวที่ควรลองใหม่
+func (h *PaymentWebhookHandle) applyEvent(ctx context.Context, provider string, event *gateway.Event) (*models.Payment, string, error) {
+	orderID, err := primitive.ObjectIDFromHex(event.OrderID)
+	if err != nil {
+		return nil, "invalid order id", nil
+	}
+	order, err := h.OrderRepo.FindByID(ctx, orderID, systemRole)
+	if err == mongo.ErrNoDocuments {
+		return nil, "order not found", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	if event.Amount.Amount <= 0 {
+		return nil, "amount must be positive", nil
+	}
+	if !strings.EqualFold(event.Amount.Currency, order.TotalAmount.Currency) {
+		return nil, "currency does not match order", nil
+	}
+	method := strings.ToLower(event.Method)
+	if !paymentMethods[method] {
+		return nil, "unsupported payment method: " + event.Method, nil
+	}
+
+	payment := models.Payment{
+		OrderID:   orderID,
+		Amount:    models.Money{Amount: event.Amount.Amount, Currency: order.TotalAmount.Currency},
+		Method:    method,
+		Reference: event.Reference,
+		Provider:  provider,
+		PaidAt:    event.PaidAt,
+	}
+	_, err = applyPayment(ctx, h.OrderRepo, h.PaymentRepo, h.Events, order.Status, &payment)
+	if err == repositories.ErrPaymentRejected {
+		return nil, "payment exceeds outstanding balance or order is cancelled", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	return &payment, "", nil
+}
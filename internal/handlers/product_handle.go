@@ -2,36 +2,65 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/money"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/outbox"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/tax"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/webhook"
 	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type ProductRequest struct {
-	ProductName string  `json:"product_name" form:"product_name" binding:"required"`
-	SKU         string  `json:"sku" form:"sku" binding:"required"`
-	Price       float64 `json:"price" form:"price" binding:"required"`
-	Stock       int     `json:"stock" form:"stock" binding:"required"`
+	ProductName     string        `json:"product_name" form:"product_name" binding:"required"`
+	SKU             string        `json:"sku" form:"sku" binding:"required"`
+	Description     string        `json:"description" form:"description"`
+	Tags            []string      `json:"tags" form:"tags"`
+	Price           money.Decimal `json:"price" form:"price" binding:"required"`
+	Currency        string        `json:"currency" form:"currency" binding:"omitempty,len=3"`
+	TaxClass        string        `json:"tax_class" form:"tax_class"`
+	Stock           int           `json:"stock" form:"stock" binding:"required"`
+	LocationID      string        `json:"location_id" form:"location_id"`
+	ReorderPoint    int           `json:"reorder_point" form:"reorder_point" binding:"min=0"`
+	ReorderQuantity int           `json:"reorder_quantity" form:"reorder_quantity" binding:"min=0"`
+	WeightGrams     int           `json:"weight_grams" form:"weight_grams" binding:"min=0"`
+	AllowBackorder  bool          `json:"allow_backorder" form:"allow_backorder"`
 }
 
 type UpdateProductRequest struct {
-	ProductName string  `json:"product_name" form:"product_name"`
-	SKU         string  `json:"sku" form:"sku"`
-	Price       float64 `json:"price" form:"price"`
-	Stock       int     `json:"stock" form:"stock"`
-	IsActive    bool    `json:"is_active" form:"is_active"`
+	ProductName     string        `json:"product_name" form:"product_name"`
+	SKU             string        `json:"sku" form:"sku"`
+	Description     string        `json:"description" form:"description"`
+	Tags            []string      `json:"tags" form:"tags"`
+	Price           money.Decimal `json:"price" form:"price"`
+	Currency        string        `json:"currency" form:"currency" binding:"omitempty,len=3"`
+	TaxClass        string        `json:"tax_class" form:"tax_class"`
+	Stock           int           `json:"stock" form:"stock"`
+	LocationID      string        `json:"location_id" form:"location_id"`
+	ReorderPoint    int           `json:"reorder_point" form:"reorder_point" binding:"min=0"`
+	ReorderQuantity int           `json:"reorder_quantity" form:"reorder_quantity" binding:"min=0"`
+	WeightGrams     int           `json:"weight_grams" form:"weight_grams" binding:"min=0"`
+	AllowBackorder  bool          `json:"allow_backorder" form:"allow_backorder"`
+	IsActive        bool          `json:"is_active" form:"is_active"`
 }
 type ProductHandle struct {
-	ProductRepo repositories.ProductRepositoryInterface
+	ProductRepo  repositories.ProductRepositoryInterface
+	LocationRepo repositories.LocationRepositoryInterface
+	TaxCalc      *tax.Calculator
+	Events       outbox.Recorder
 }
 
-func NewProductHandle(repo repositories.ProductRepositoryInterface) *ProductHandle {
-	return &ProductHandle{ProductRepo: repo}
+func NewProductHandle(repo repositories.ProductRepositoryInterface, locationRepo repositories.LocationRepositoryInterface, taxCalc *tax.Calculator, events outbox.Recorder) *ProductHandle {
+	return &ProductHandle{ProductRepo: repo, LocationRepo: locationRepo, TaxCalc: taxCalc, Events: events}
 }
 
 func (h *ProductHandle) GetProducts(c *gin.Context) {
@@ -46,11 +75,50 @@ func (h *ProductHandle) GetProducts(c *gin.Context) {
 		return
 	}
 
+	locations, err := h.LocationRepo.FindAll(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":     len(products),
+		"products":  products,
+		"locations": locations,
+	})
+
+}
+
+func (h *ProductHandle) SearchProducts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing search query"})
+		return
+	}
+
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 || n > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Limit must be between 1 and 100"})
+			return
+		}
+		limit = n
+	}
+
+	products, err := h.ProductRepo.Search(ctx, query, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"total":    len(products),
 		"products": products,
 	})
-
 }
 
 func (h *ProductHandle) CreateProduct(c *gin.Context) {
@@ -81,14 +149,43 @@ func (h *ProductHandle) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	price, err := input.Price.Money(input.Currency)
+	if err != nil || price.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price"})
+		return
+	}
+	if !h.TaxCalc.ValidClass(input.TaxClass) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown tax class: " + input.TaxClass})
+		return
+	}
+
 	product := models.Product{
-		Name:      input.ProductName,
-		CreatedBy: createBy,
-		SKU:       input.SKU,
-		Price:     input.Price,
-		Stock:     input.Stock,
-		IsActive:  true,
-		CreatedAt: time.Now(),
+		Name:            input.ProductName,
+		CreatedBy:       createBy,
+		SKU:             input.SKU,
+		Description:     input.Description,
+		Tags:            input.Tags,
+		Price:           price,
+		TaxClass:        tax.Class(input.TaxClass),
+		Stock:           input.Stock,
+		ReorderPoint:    input.ReorderPoint,
+		ReorderQuantity: input.ReorderQuantity,
+		WeightGrams:     input.WeightGrams,
+		AllowBackorder:  input.AllowBackorder,
+		IsActive:        true,
+		CreatedAt:       time.Now(),
+	}
+	if input.LocationID != "" {
+		locationID, err := primitive.ObjectIDFromHex(input.LocationID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+			return
+		}
+		if location, err := h.LocationRepo.FindByID(ctx, locationID); err != nil || !location.IsActive {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Location not found or inactive"})
+			return
+		}
+		product.Locations = []models.LocationStock{{LocationID: locationID, Quantity: input.Stock}}
 	}
 
 	exist, _ := h.ProductRepo.ExistsBySKU(ctx, input.SKU)
@@ -147,22 +244,70 @@ func (h *ProductHandle) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	updateFields := bson.M{
-		"name":      input.ProductName,
-		"sku":       input.SKU,
-		"price":     input.Price,
-		"stock":     input.Stock,
-		"is_active": input.IsActive,
+	if !h.TaxCalc.ValidClass(input.TaxClass) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown tax class: " + input.TaxClass})
+		return
 	}
 
-	result, err := h.ProductRepo.Update(ctx, productID, updateFields, roleVar.(string), userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	locationID := h.ProductRepo.DefaultLocation()
+	if input.LocationID != "" {
+		locationID, err = primitive.ObjectIDFromHex(input.LocationID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+			return
+		}
+		if location, err := h.LocationRepo.FindByID(ctx, locationID); err != nil || !location.IsActive {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Location not found or inactive"})
+			return
+		}
+	}
+
+	updateFields := bson.M{
+		"name":             input.ProductName,
+		"sku":              input.SKU,
+		"description":      input.Description,
+		"tags":             input.Tags,
+		"tax_class":        tax.Class(input.TaxClass),
+		"reorder_point":    input.ReorderPoint,
+		"reorder_quantity": input.ReorderQuantity,
+		"weight_grams":     input.WeightGrams,
+		"allow_backorder":  input.AllowBackorder,
+		"is_active":        input.IsActive,
 	}
-	if result.MatchedCount == 0 {
+	if input.Price != "" {
+		price, err := input.Price.Money(input.Currency)
+		if err != nil || price.Amount < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price"})
+			return
+		}
+		updateFields["price"] = price
+	}
+
+	err = h.Events.Transaction(ctx, func(ctx context.Context) error {
+		result, err := h.ProductRepo.Update(ctx, productID, updateFields, roleVar.(string), userID)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return mongo.ErrNoDocuments
+		}
+
+		// stock ในคำขอคือจำนวนคงเหลือของคลังที่ระบุ (ค่าเริ่มต้นคือหน้าร้าน) ไม่ใช่ยอดรวมทุกคลัง
+		if err := h.ProductRepo.SetLocationStock(ctx, productID, locationID, input.Stock); err != nil {
+			return fmt.Errorf("failed to update stock: %w", err)
+		}
+		product, err := h.ProductRepo.FindByID(ctx, productID, input.IsActive)
+		if err != nil {
+			return err
+		}
+		return h.Events.Record(ctx, productID.Hex(), webhook.EventProductUpdated, product)
+	})
+	if err == mongo.ErrNoDocuments {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Product updated successfully"})
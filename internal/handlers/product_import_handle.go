@@ -0,0 +1,522 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/money"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/spreadsheet"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/tax"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ImportModeInsert = "insert"
+	ImportModeUpsert = "upsert"
+)
+
+const importLookupBatch = 500
+
+var productColumns = []string{"name", "sku", "description", "tags", "price", "currency", "tax_class", "stock", "reorder_point", "reorder_quantity", "weight_grams", "allow_backorder", "is_active"}
+
+type ProductImportRequest struct {
+	Format  string `form:"format"`
+	Mode    string `form:"mode"`
+	DryRun  bool   `form:"dry_run"`
+	Mapping string `form:"mapping"`
+}
+
+type ImportRowError struct {
+	Row    int      `json:"row"`
+	SKU    string   `json:"sku"`
+	Errors []string `json:"errors"`
+}
+
+type importRow struct {
+	line     int
+	product  models.Product
+	hasCols  map[string]bool
+	existing *models.Product
+}
+
+func (h *ProductHandle) ImportProducts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can import products"})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	userIDStr, ok := userIdVar.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	var input ProductImportRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Mode == "" {
+		input.Mode = ImportModeInsert
+	}
+	if input.Mode != ImportModeInsert && input.Mode != ImportModeUpsert {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Mode must be insert or upsert"})
+		return
+	}
+
+	mapping := map[string]string{}
+	if input.Mapping != "" {
+		if err := json.Unmarshal([]byte(input.Mapping), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid column mapping"})
+			return
+		}
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing import file"})
+		return
+	}
+	format, err := spreadsheet.DetectFormat(fileHeader.Filename, input.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot open import file"})
+		return
+	}
+	defer file.Close()
+
+	rows, err := spreadsheet.ReadRows(file, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot read import file: " + err.Error()})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Import file is empty"})
+		return
+	}
+
+	columns, err := resolveColumns(rows[0], mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rowErrors []ImportRowError
+	var parsed []importRow
+	var parseErrors [][]string
+	var skus []string
+	seenSKU := map[string]int{}
+	totalRows, created, updated := 0, 0, 0
+
+	for i, record := range rows[1:] {
+		line := i + 2
+		if isBlankRecord(record) {
+			continue
+		}
+		totalRows++
+
+		row, errs := parseProductRow(record, columns)
+		row.line = line
+		if row.hasCols["tax_class"] && !h.TaxCalc.ValidClass(row.product.TaxClass) {
+			errs = append(errs, fmt.Sprintf("Unknown tax class: %s", row.product.TaxClass))
+		}
+		if row.product.SKU != "" {
+			if first, dup := seenSKU[row.product.SKU]; dup {
+				errs = append(errs, fmt.Sprintf("Duplicate SKU in file (first seen on row %d)", first))
+			} else {
+				seenSKU[row.product.SKU] = line
+				skus = append(skus, row.product.SKU)
+			}
+		}
+		parsed = append(parsed, row)
+		parseErrors = append(parseErrors, errs)
+	}
+
+	existing := map[string]*models.Product{}
+	for start := 0; start < len(skus); start += importLookupBatch {
+		found, err := h.ProductRepo.FindBySKUs(ctx, skus[start:min(start+importLookupBatch, len(skus))])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		for sku, product := range found {
+			existing[sku] = product
+		}
+	}
+
+	var validRows []importRow
+	for i, row := range parsed {
+		errs := parseErrors[i]
+		if row.product.SKU != "" {
+			row.existing = existing[row.product.SKU]
+			switch {
+			case row.existing == nil:
+				errs = append(errs, validateNewProduct(row)...)
+			case input.Mode == ImportModeInsert:
+				errs = append(errs, "SKU already exists")
+			case !canUpdateProduct(row.existing, roleVar.(string), userID):
+				// ตรวจสิทธิ์เหมือน Update เพื่อให้ dry run รายงานแถวที่จะถูกปฏิเสธตอน import จริง
+				errs = append(errs, "Permission denied for existing product")
+			}
+		}
+
+		if len(errs) > 0 {
+			rowErrors = append(rowErrors, ImportRowError{Row: row.line, SKU: row.product.SKU, Errors: errs})
+			continue
+		}
+		validRows = append(validRows, row)
+	}
+
+	if !input.DryRun {
+		for _, row := range validRows {
+			isUpdate, err := h.saveImportedProduct(ctx, row, roleVar.(string), userID)
+			if err != nil {
+				rowErrors = append(rowErrors, ImportRowError{Row: row.line, SKU: row.product.SKU, Errors: []string{err.Error()}})
+				continue
+			}
+			if isUpdate {
+				updated++
+			} else {
+				created++
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run":    input.DryRun,
+		"mode":       input.Mode,
+		"total_rows": totalRows,
+		"valid_rows": len(validRows),
+		"created":    created,
+		"updated":    updated,
+		"failed":     len(rowErrors),
+		"errors":     rowErrors,
+	})
+}
+
+func (h *ProductHandle) saveImportedProduct(ctx context.Context, row importRow, role string, userID primitive.ObjectID) (bool, error) {
+	existing := row.existing
+	if existing == nil {
+		product := row.product
+		product.CreatedBy = userID
+		product.CreatedAt = time.Now()
+		product.TaxClass = tax.Class(product.TaxClass)
+		if !row.hasCols["is_active"] {
+			product.IsActive = true
+		}
+		if err := h.ProductRepo.Insert(ctx, &product); err != nil {
+			return false, fmt.Errorf("Database error")
+		}
+		return false, nil
+	}
+
+	fields := bson.M{}
+	if row.hasCols["name"] {
+		fields["name"] = row.product.Name
+	}
+	if row.hasCols["description"] {
+		fields["description"] = row.product.Description
+	}
+	if row.hasCols["tags"] {
+		fields["tags"] = row.product.Tags
+	}
+	if row.hasCols["price"] {
+		fields["price"] = row.product.Price
+	}
+	if row.hasCols["tax_class"] {
+		fields["tax_class"] = row.product.TaxClass
+	}
+	if row.hasCols["reorder_point"] {
+		fields["reorder_point"] = row.product.ReorderPoint
+	}
+	if row.hasCols["reorder_quantity"] {
+		fields["reorder_quantity"] = row.product.ReorderQuantity
+	}
+	if row.hasCols["weight_grams"] {
+		fields["weight_grams"] = row.product.WeightGrams
+	}
+	if row.hasCols["allow_backorder"] {
+		fields["allow_backorder"] = row.product.AllowBackorder
+	}
+	if row.hasCols["is_active"] {
+		fields["is_active"] = row.product.IsActive
+	}
+
+	result, err := h.ProductRepo.Update(ctx, existing.ID, fields, role, userID)
+	if err != nil {
+		return true, fmt.Errorf("Database error")
+	}
+	if result.MatchedCount == 0 {
+		return true, fmt.Errorf("Permission denied for existing product")
+	}
+	if row.hasCols["stock"] {
+		if err := h.ProductRepo.SetLocationStock(ctx, existing.ID, h.ProductRepo.DefaultLocation(), row.product.Stock); err != nil {
+			return true, fmt.Errorf("Failed to update stock")
+		}
+	}
+	return true, nil
+}
+
+func (h *ProductHandle) ExportProducts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	format, err := spreadsheet.DetectFormat("", c.DefaultQuery("format", spreadsheet.FormatCSV))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	writer, err := spreadsheet.NewWriter(c.Writer, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("products-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Type", spreadsheet.ContentType(format))
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Status(http.StatusOK)
+
+	if err := writer.WriteRow(productColumns); err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.ProductRepo.ForEach(ctx, func(product *models.Product) error {
+		return writer.WriteRow([]string{
+			product.Name,
+			product.SKU,
+			product.Description,
+			strings.Join(product.Tags, "|"),
+			money.Format(product.Price),
+			product.Price.Currency,
+			tax.Class(product.TaxClass),
+			strconv.Itoa(product.Stock),
+			strconv.Itoa(product.ReorderPoint),
+			strconv.Itoa(product.ReorderQuantity),
+			strconv.Itoa(product.WeightGrams),
+			strconv.FormatBool(product.AllowBackorder),
+			strconv.FormatBool(product.IsActive),
+		})
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := writer.Close(); err != nil {
+		c.Error(err)
+	}
+}
+
+func resolveColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	aliases := map[string][]string{
+		"name": {"name", "product_name"},
+	}
+
+	columns := map[string]int{}
+	for _, field := range productColumns {
+		if source, ok := mapping[field]; ok {
+			i, found := index[strings.ToLower(strings.TrimSpace(source))]
+			if !found {
+				return nil, fmt.Errorf("Mapped column %q for %s not found in header", source, field)
+			}
+			columns[field] = i
+			continue
+		}
+		candidates := aliases[field]
+		if candidates == nil {
+			candidates = []string{field}
+		}
+		for _, candidate := range candidates {
+			if i, found := index[candidate]; found {
+				columns[field] = i
+				break
+			}
+		}
+	}
+
+	for field := range mapping {
+		if !contains(productColumns, field) {
+			return nil, fmt.Errorf("Unknown product field in mapping: %s", field)
+		}
+	}
+	if _, ok := columns["sku"]; !ok {
+		return nil, fmt.Errorf("Missing required column: sku")
+	}
+	return columns, nil
+}
+
+func parseProductRow(record []string, columns map[string]int) (importRow, []string) {
+	row := importRow{hasCols: map[string]bool{}}
+	var errs []string
+
+	cell := func(field string) (string, bool) {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			// แถวที่สั้นกว่า header ถือว่าไม่มีคอลัมน์นั้น เพื่อไม่ให้ upsert เขียนทับด้วยค่าว่าง
+			return "", false
+		}
+		return strings.TrimSpace(record[i]), true
+	}
+
+	if v, ok := cell("name"); ok && v != "" {
+		row.hasCols["name"] = true
+		row.product.Name = v
+	}
+	if v, _ := cell("sku"); v != "" {
+		row.product.SKU = v
+	} else {
+		errs = append(errs, "SKU is required")
+	}
+	if v, ok := cell("description"); ok {
+		row.hasCols["description"] = true
+		row.product.Description = v
+	}
+	if v, ok := cell("tags"); ok {
+		row.hasCols["tags"] = true
+		row.product.Tags = splitTags(v)
+	}
+	currency, _ := cell("currency")
+	if v, ok := cell("price"); ok && v != "" {
+		price, err := money.Parse(v, currency)
+		if err != nil || price.Amount < 0 {
+			errs = append(errs, fmt.Sprintf("Invalid price: %s", v))
+		} else {
+			row.hasCols["price"] = true
+			row.product.Price = price
+		}
+	}
+	if v, ok := cell("stock"); ok && v != "" {
+		stock, err := strconv.Atoi(v)
+		if err != nil || stock < 0 {
+			errs = append(errs, fmt.Sprintf("Invalid stock: %s", v))
+		} else {
+			row.hasCols["stock"] = true
+			row.product.Stock = stock
+		}
+	}
+	if v, ok := cell("tax_class"); ok && v != "" {
+		row.hasCols["tax_class"] = true
+		row.product.TaxClass = tax.Class(v)
+	}
+	if v, ok := cell("reorder_point"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			errs = append(errs, fmt.Sprintf("Invalid reorder_point: %s", v))
+		} else {
+			row.hasCols["reorder_point"] = true
+			row.product.ReorderPoint = n
+		}
+	}
+	if v, ok := cell("reorder_quantity"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			errs = append(errs, fmt.Sprintf("Invalid reorder_quantity: %s", v))
+		} else {
+			row.hasCols["reorder_quantity"] = true
+			row.product.ReorderQuantity = n
+		}
+	}
+	if v, ok := cell("weight_grams"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			errs = append(errs, fmt.Sprintf("Invalid weight_grams: %s", v))
+		} else {
+			row.hasCols["weight_grams"] = true
+			row.product.WeightGrams = n
+		}
+	}
+	if v, ok := cell("allow_backorder"); ok && v != "" {
+		allow, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Invalid allow_backorder: %s", v))
+		} else {
+			row.hasCols["allow_backorder"] = true
+			row.product.AllowBackorder = allow
+		}
+	}
+	if v, ok := cell("is_active"); ok && v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Invalid is_active: %s", v))
+		} else {
+			row.hasCols["is_active"] = true
+			row.product.IsActive = active
+		}
+	}
+
+	return row, errs
+}
+
+// canUpdateProduct ใช้เงื่อนไขเดียวกับ ProductRepository.Update: Staff แก้ได้เฉพาะสินค้าที่ตัวเองสร้าง
+func canUpdateProduct(product *models.Product, role string, userID primitive.ObjectID) bool {
+	return role == "Admin" || (role == "Staff" && product.CreatedBy == userID)
+}
+
+func validateNewProduct(row importRow) []string {
+	var errs []string
+	if row.product.Name == "" {
+		errs = append(errs, "Name is required")
+	}
+	if !row.hasCols["price"] || row.product.Price.Amount <= 0 {
+		errs = append(errs, "Price is required")
+	}
+	return errs
+}
+
+func splitTags(v string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(v, func(r rune) bool { return r == '|' || r == ',' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func isBlankRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
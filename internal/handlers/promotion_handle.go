@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/money"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type PromotionRequest struct {
+	Name        string        `json:"name" binding:"required"`
+	Code        string        `json:"code"`
+	Type        string        `json:"type" binding:"required,oneof=percentage fixed buy_x_get_y"`
+	Percent     money.Decimal `json:"percent"`
+	Amount      money.Decimal `json:"amount"`
+	Currency    string        `json:"currency" binding:"omitempty,len=3"`
+	BuyQuantity int           `json:"buy_quantity" binding:"min=0"`
+	GetQuantity int           `json:"get_quantity" binding:"min=0"`
+	ProductIDs  []string      `json:"product_ids"`
+	MinSpend    money.Decimal `json:"min_spend"`
+	UsageLimit  int           `json:"usage_limit" binding:"min=0"`
+	StartsAt    *time.Time    `json:"starts_at"`
+	ExpiresAt   *time.Time    `json:"expires_at"`
+	IsActive    *bool         `json:"is_active"`
+}
+
+type PromotionHandle struct {
+	PromotionRepo repositories.PromotionRepositoryInterface
+}
+
+func NewPromotionHandle(repo repositories.PromotionRepositoryInterface) *PromotionHandle {
+	return &PromotionHandle{PromotionRepo: repo}
+}
+
+func (h *PromotionHandle) GetPromotions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can view promotions"})
+		return
+	}
+
+	promos, err := h.PromotionRepo.FindAll(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":      len(promos),
+		"promotions": promos,
+	})
+}
+
+func (h *PromotionHandle) CreatePromotion(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can create promotions"})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	createBy, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var input PromotionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promo, msg := buildPromotion(input)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	promo.UsageCount = 0
+	promo.CreatedBy = createBy
+	promo.CreatedAt = time.Now()
+
+	if err := h.PromotionRepo.Insert(ctx, promo, roleVar.(string)); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Coupon code already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create promotion"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Promotion created successfully", "promotion": promo})
+}
+
+func (h *PromotionHandle) UpdatePromotion(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can update promotions"})
+		return
+	}
+
+	promoID, err := primitive.ObjectIDFromHex(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion ID"})
+		return
+	}
+
+	var input PromotionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promo, msg := buildPromotion(input)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	fields := bson.M{
+		"name":         promo.Name,
+		"type":         promo.Type,
+		"percent":      promo.Percent,
+		"amount":       promo.Amount,
+		"buy_quantity": promo.BuyQuantity,
+		"get_quantity": promo.GetQuantity,
+		"product_ids":  promo.ProductIDs,
+		"min_spend":    promo.MinSpend,
+		"usage_limit":  promo.UsageLimit,
+		"starts_at":    promo.StartsAt,
+		"expires_at":   promo.ExpiresAt,
+		"is_active":    promo.IsActive,
+	}
+
+	result, err := h.PromotionRepo.Update(ctx, promoID, fields, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update promotion"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion updated successfully"})
+}
+
+func buildPromotion(input PromotionRequest) (*models.Promotion, string) {
+	currency := money.NormalizeCurrency(input.Currency)
+	promo := &models.Promotion{
+		Name:        input.Name,
+		Code:        strings.ToUpper(strings.TrimSpace(input.Code)),
+		Type:        input.Type,
+		BuyQuantity: input.BuyQuantity,
+		GetQuantity: input.GetQuantity,
+		UsageLimit:  input.UsageLimit,
+		StartsAt:    input.StartsAt,
+		ExpiresAt:   input.ExpiresAt,
+		IsActive:    input.IsActive == nil || *input.IsActive,
+		MinSpend:    money.Zero(currency),
+		Amount:      money.Zero(currency),
+	}
+
+	switch input.Type {
+	case models.PromotionPercentage:
+		percent, err := money.ParseScaled(string(input.Percent), 2)
+		if err != nil || percent <= 0 || percent > 10000 {
+			return nil, "Percent must be between 0 and 100"
+		}
+		promo.Percent = percent
+	case models.PromotionFixed:
+		amount, err := input.Amount.Money(currency)
+		if err != nil || amount.Amount <= 0 {
+			return nil, "Invalid discount amount"
+		}
+		promo.Amount = amount
+	case models.PromotionBuyXGetY:
+		if input.BuyQuantity < 1 || input.GetQuantity < 1 {
+			return nil, "buy_quantity and get_quantity must be at least 1"
+		}
+	}
+
+	if input.MinSpend != "" {
+		minSpend, err := input.MinSpend.Money(currency)
+		if err != nil || minSpend.Amount < 0 {
+			return nil, "Invalid minimum spend"
+		}
+		promo.MinSpend = minSpend
+	}
+
+	if input.StartsAt != nil && input.ExpiresAt != nil && !input.ExpiresAt.After(*input.StartsAt) {
+		return nil, "expires_at must be after starts_at"
+	}
+
+	for _, idStr := range input.ProductIDs {
+		id, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			return nil, "Invalid product ID: " + idStr
+		}
+		promo.ProductIDs = append(promo.ProductIDs, id)
+	}
+
+	return promo, ""
+}
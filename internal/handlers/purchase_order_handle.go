@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/money"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/outbox"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type PurchaseOrderItemRequest struct {
+	ProductID string        `json:"product_id" form:"product_id" binding:"required"`
+	Quantity  int           `json:"quantity" form:"quantity" binding:"required,min=1"`
+	UnitCost  money.Decimal `json:"unit_cost" form:"unit_cost" binding:"required"`
+}
+
+type PurchaseOrderRequest struct {
+	SupplierID string                     `json:"supplier_id" form:"supplier_id" binding:"required"`
+	Currency   string                     `json:"currency" form:"currency" binding:"omitempty,len=3"`
+	Items      []PurchaseOrderItemRequest `json:"items" form:"items" binding:"required,min=1,dive"`
+	Note       string                     `json:"note" form:"note"`
+}
+
+type ReceiveItemRequest struct {
+	ProductID string        `json:"product_id" form:"product_id" binding:"required"`
+	Quantity  int           `json:"quantity" form:"quantity" binding:"required,min=1"`
+	UnitCost  money.Decimal `json:"unit_cost" form:"unit_cost"`
+}
+
+type ReceivePurchaseOrderRequest struct {
+	Items      []ReceiveItemRequest `json:"items" form:"items" binding:"required,min=1,dive"`
+	LocationID string               `json:"location_id" form:"location_id"`
+	Note       string               `json:"note" form:"note"`
+}
+
+type PurchaseOrderHandle struct {
+	PurchaseOrderRepo repositories.PurchaseOrderRepositoryInterface
+	SupplierRepo      repositories.SupplierRepositoryInterface
+	ProductRepo       repositories.ProductRepositoryInterface
+	CounterRepo       repositories.CounterRepositoryInterface
+	LocationRepo      repositories.LocationRepositoryInterface
+	Events            outbox.Recorder
+}
+
+func NewPurchaseOrderHandle(poRepo repositories.PurchaseOrderRepositoryInterface, supplierRepo repositories.SupplierRepositoryInterface, productRepo repositories.ProductRepositoryInterface, counterRepo repositories.CounterRepositoryInterface, locationRepo repositories.LocationRepositoryInterface, events outbox.Recorder) *PurchaseOrderHandle {
+	return &PurchaseOrderHandle{PurchaseOrderRepo: poRepo, SupplierRepo: supplierRepo, ProductRepo: productRepo, CounterRepo: counterRepo, LocationRepo: locationRepo, Events: events}
+}
+
+func (h *PurchaseOrderHandle) GetPurchaseOrders(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	role, _ := roleVar.(string)
+
+	filter := bson.M{}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	if supplierIDStr := c.Query("supplier_id"); supplierIDStr != "" {
+		supplierID, err := primitive.ObjectIDFromHex(supplierIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid supplier ID"})
+			return
+		}
+		filter["supplier_id"] = supplierID
+	}
+
+	purchaseOrders, err := h.PurchaseOrderRepo.FindAll(ctx, filter, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":           len(purchaseOrders),
+		"purchase_orders": purchaseOrders,
+	})
+}
+
+func (h *PurchaseOrderHandle) GetPurchaseOrder(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	role, _ := roleVar.(string)
+
+	poID, err := primitive.ObjectIDFromHex(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purchase order ID"})
+		return
+	}
+
+	po, err := h.PurchaseOrderRepo.FindByID(ctx, poID, role)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase order not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	supplier, err := h.SupplierRepo.FindByID(ctx, po.SupplierID, role)
+	if err != nil && err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"purchase_order": po,
+		"supplier":       supplier,
+	})
+}
+
+func (h *PurchaseOrderHandle) CreatePurchaseOrder(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can create purchase orders"})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	createBy, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var input PurchaseOrderRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	supplierID, items, totalCost, status, msg := h.buildPurchaseOrderItems(ctx, input, roleVar.(string))
+	if status != 0 {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	seq, err := h.CounterRepo.Next(ctx, "purchase_order")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to allocate purchase order number"})
+		return
+	}
+
+	po := models.PurchaseOrder{
+		Number:     fmt.Sprintf("PO%s-%05d", time.Now().Format("200601"), seq),
+		SupplierID: supplierID,
+		Status:     models.PurchaseOrderDraft,
+		Items:      items,
+		Receipts:   []models.GoodsReceipt{},
+		TotalCost:  totalCost,
+		Note:       input.Note,
+		CreatedBy:  createBy,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := h.PurchaseOrderRepo.Insert(ctx, &po, roleVar.(string)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create purchase order"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Purchase order created successfully", "purchase_order": po})
+}
+
+func (h *PurchaseOrderHandle) UpdatePurchaseOrder(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can update purchase orders"})
+		return
+	}
+
+	po, ok := h.loadPurchaseOrder(ctx, c, roleVar.(string))
+	if !ok {
+		return
+	}
+	if po.Status != models.PurchaseOrderDraft {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only draft purchase orders can be edited"})
+		return
+	}
+
+	var input PurchaseOrderRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	supplierID, items, totalCost, status, msg := h.buildPurchaseOrderItems(ctx, input, roleVar.(string))
+	if status != 0 {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	result, err := h.PurchaseOrderRepo.UpdateIfUnchanged(ctx, po, bson.M{"$set": bson.M{
+		"supplier_id": supplierID,
+		"items":       items,
+		"total_cost":  totalCost,
+		"note":        input.Note,
+	}}, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update purchase order"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Purchase order was modified, please retry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Purchase order updated successfully"})
+}
+
+func (h *PurchaseOrderHandle) SubmitPurchaseOrder(c *gin.Context) {
+	h.transitionPurchaseOrder(c, models.PurchaseOrderDraft, models.PurchaseOrderOrdered)
+}
+
+func (h *PurchaseOrderHandle) CancelPurchaseOrder(c *gin.Context) {
+	h.transitionPurchaseOrder(c, models.PurchaseOrderDraft, models.PurchaseOrderCancelled, models.PurchaseOrderOrdered)
+}
+
+func (h *PurchaseOrderHandle) transitionPurchaseOrder(c *gin.Context, from string, to string, alsoFrom ...string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can update purchase orders"})
+		return
+	}
+
+	po, ok := h.loadPurchaseOrder(ctx, c, roleVar.(string))
+	if !ok {
+		return
+	}
+
+	allowed := po.Status == from
+	for _, s := range alsoFrom {
+		allowed = allowed || po.Status == s
+	}
+	if !allowed {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Cannot change purchase order from %s to %s", po.Status, to)})
+		return
+	}
+
+	set := bson.M{"status": to}
+	if to == models.PurchaseOrderOrdered {
+		set["ordered_at"] = time.Now()
+	}
+
+	result, err := h.PurchaseOrderRepo.UpdateIfUnchanged(ctx, po, bson.M{"$set": set}, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update purchase order"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Purchase order was modified, please retry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Purchase order " + to})
+}
+
+func (h *PurchaseOrderHandle) ReceivePurchaseOrder(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can receive goods"})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	receivedBy, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var input ReceivePurchaseOrderRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	po, ok := h.loadPurchaseOrder(ctx, c, roleVar.(string))
+	if !ok {
+		return
+	}
+	if po.Status != models.PurchaseOrderOrdered && po.Status != models.PurchaseOrderPartiallyReceived {
+		c.JSON(http.StatusConflict, gin.H{"error": "Goods can only be received for ordered purchase orders"})
+		return
+	}
+
+	locationID := h.ProductRepo.DefaultLocation()
+	if input.LocationID != "" {
+		locationID, err = primitive.ObjectIDFromHex(input.LocationID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+			return
+		}
+		if location, err := h.LocationRepo.FindByID(ctx, locationID); err != nil || !location.IsActive {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Location not found or inactive"})
+			return
+		}
+	}
+
+	now := time.Now()
+	items := append([]models.PurchaseOrderItem(nil), po.Items...)
+	var receipts []models.GoodsReceipt
+
+	for _, received := range input.Items {
+		productID, err := primitive.ObjectIDFromHex(received.ProductID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID: " + received.ProductID})
+			return
+		}
+
+		idx := -1
+		for i := range items {
+			if items[i].ProductID == productID {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Product is not on this purchase order: " + received.ProductID})
+			return
+		}
+
+		remaining := items[idx].QuantityOrdered - items[idx].QuantityReceived
+		if received.Quantity > remaining {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Cannot receive %d of %s, only %d outstanding", received.Quantity, items[idx].SKU, remaining)})
+			return
+		}
+
+		unitCost := items[idx].UnitCost
+		if received.UnitCost != "" {
+			unitCost, err = received.UnitCost.Money(po.TotalCost.Currency)
+			if err != nil || unitCost.Amount < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unit cost for " + items[idx].SKU})
+				return
+			}
+		}
+
+		items[idx].QuantityReceived += received.Quantity
+		receipts = append(receipts, models.GoodsReceipt{
+			ProductID:  productID,
+			Quantity:   received.Quantity,
+			UnitCost:   unitCost,
+			LocationID: locationID,
+			ReceivedBy: receivedBy,
+			ReceivedAt: now,
+			Note:       input.Note,
+		})
+	}
+
+	status := models.PurchaseOrderReceived
+	for _, item := range items {
+		if item.QuantityReceived < item.QuantityOrdered {
+			status = models.PurchaseOrderPartiallyReceived
+			break
+		}
+	}
+
+	set := bson.M{"items": items, "status": status}
+	if status == models.PurchaseOrderReceived {
+		set["received_at"] = now
+	}
+
+	// บันทึกการรับของและเพิ่มสต็อกพร้อมกัน ถ้าเพิ่มสต็อกไม่สำเร็จใบสั่งซื้อจะยังรับของรอบนี้ใหม่ได้
+	err = h.Events.Transaction(ctx, func(ctx context.Context) error {
+		result, err := h.PurchaseOrderRepo.UpdateIfUnchanged(ctx, po, bson.M{
+			"$set":  set,
+			"$push": bson.M{"receipts": bson.M{"$each": receipts}},
+		}, roleVar.(string))
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return &handlerError{http.StatusConflict, "Purchase order was modified, please retry"}
+		}
+		for _, receipt := range receipts {
+			if err := h.ProductRepo.UpdateLocationStock(ctx, receipt.ProductID, receipt.LocationID, receipt.Quantity); err != nil {
+				return fmt.Errorf("add %d stock to %s: %w", receipt.Quantity, receipt.ProductID.Hex(), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if _, ok := err.(*handlerError); ok {
+			writeHandlerError(c, err)
+			return
+		}
+		log.Printf("purchase order %s: failed to receive goods: %v", po.Number, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record goods receipt"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Goods received successfully", "status": status})
+}
+
+func (h *PurchaseOrderHandle) loadPurchaseOrder(ctx context.Context, c *gin.Context, role string) (*models.PurchaseOrder, bool) {
+	poID, err := primitive.ObjectIDFromHex(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purchase order ID"})
+		return nil, false
+	}
+
+	po, err := h.PurchaseOrderRepo.FindByID(ctx, poID, role)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase order not found"})
+		return nil, false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return po, true
+}
+
+func (h *PurchaseOrderHandle) buildPurchaseOrderItems(ctx context.Context, input PurchaseOrderRequest, role string) (primitive.ObjectID, []models.PurchaseOrderItem, models.Money, int, string) {
+	totalCost := money.Zero(input.Currency)
+	supplierID, err := primitive.ObjectIDFromHex(input.SupplierID)
+	if err != nil {
+		return supplierID, nil, totalCost, http.StatusBadRequest, "Invalid supplier ID"
+	}
+	supplier, err := h.SupplierRepo.FindByID(ctx, supplierID, role)
+	if err == mongo.ErrNoDocuments {
+		return supplierID, nil, totalCost, http.StatusNotFound, "Supplier not found"
+	} else if err != nil {
+		return supplierID, nil, totalCost, http.StatusInternalServerError, "Database error"
+	}
+	if !supplier.IsActive {
+		return supplierID, nil, totalCost, http.StatusBadRequest, "Supplier is inactive"
+	}
+
+	var items []models.PurchaseOrderItem
+	seen := map[primitive.ObjectID]bool{}
+
+	for _, item := range input.Items {
+		productID, err := primitive.ObjectIDFromHex(item.ProductID)
+		if err != nil {
+			return supplierID, nil, totalCost, http.StatusBadRequest, "Invalid product ID: " + item.ProductID
+		}
+		if seen[productID] {
+			return supplierID, nil, totalCost, http.StatusBadRequest, "Duplicate product on purchase order: " + item.ProductID
+		}
+		seen[productID] = true
+
+		product, err := h.ProductRepo.FindByID(ctx, productID, true)
+		if err != nil {
+			return supplierID, nil, totalCost, http.StatusNotFound, "Product not found: " + item.ProductID
+		}
+
+		unitCost, err := item.UnitCost.Money(totalCost.Currency)
+		if err != nil || unitCost.Amount < 0 {
+			return supplierID, nil, totalCost, http.StatusBadRequest, "Invalid unit cost for " + product.SKU
+		}
+
+		items = append(items, models.PurchaseOrderItem{
+			ProductID:       productID,
+			SKU:             product.SKU,
+			ProductName:     product.Name,
+			QuantityOrdered: item.Quantity,
+			UnitCost:        unitCost,
+		})
+		if totalCost, err = totalCost.Add(unitCost.Mul(item.Quantity)); err != nil {
+			return supplierID, nil, totalCost, http.StatusBadRequest, "Unit cost currency does not match the purchase order"
+		}
+	}
+
+	return supplierID, items, totalCost, 0, ""
+}
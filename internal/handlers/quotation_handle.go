@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/document"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const defaultQuotationValidDays = 30
+
+// QuotationRequest ใช้ข้อมูลลูกค้า ที่อยู่ สินค้า คูปอง และการจัดส่งแบบเดียวกับคำขอสร้างออเดอร์
+type QuotationRequest struct {
+	OrderRequest
+	ValidDays int    `json:"valid_days" form:"valid_days" binding:"omitempty,min=1,max=365"` // ไม่ระบุคือ 30 วัน
+	Note      string `json:"note" form:"note"`
+}
+
+// QuotationHandle ใช้การคิดราคาและการสร้างออเดอร์ของ OrderHandle เพื่อให้ใบเสนอราคาและออเดอร์ตรวจแบบเดียวกัน
+type QuotationHandle struct {
+	QuotationRepo repositories.QuotationRepositoryInterface
+	CounterRepo   repositories.CounterRepositoryInterface
+	Orders        *OrderHandle
+	Renderer      *document.Renderer
+}
+
+func NewQuotationHandle(quotationRepo repositories.QuotationRepositoryInterface, counterRepo repositories.CounterRepositoryInterface, orders *OrderHandle, renderer *document.Renderer) *QuotationHandle {
+	return &QuotationHandle{QuotationRepo: quotationRepo, CounterRepo: counterRepo, Orders: orders, Renderer: renderer}
+}
+
+func (h *QuotationHandle) GetQuotations(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can view quotations"})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	userID, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	quotations, err := h.QuotationRepo.FindAll(ctx, c.Query("status"), userID, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quotations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":      len(quotations),
+		"quotations": quotations,
+	})
+}
+
+func (h *QuotationHandle) GetQuotation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can view quotations"})
+		return
+	}
+
+	quotation, ok := h.loadQuotation(ctx, c, roleVar.(string))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"quotation": quotation,
+		"expired":   quotation.Expired(time.Now()),
+	})
+}
+
+// CreateQuotation คิดราคา ส่วนลด ภาษี และค่าส่งเหมือนสร้างออเดอร์ แต่ไม่ตรวจและไม่ตัดสต็อก
+func (h *QuotationHandle) CreateQuotation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var input QuotationRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	userID, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can create quotations"})
+		return
+	}
+
+	quotation, err := h.buildQuotation(ctx, input, roleVar.(string))
+	if err != nil {
+		writeHandlerError(c, err)
+		return
+	}
+
+	now := time.Now()
+	seq, err := h.CounterRepo.Next(ctx, fmt.Sprintf("%s-%d", models.DocumentQuotation, now.Year()))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to allocate quotation number"})
+		return
+	}
+	quotation.Number = fmt.Sprintf("QT%d-%06d", now.Year(), seq)
+	quotation.Status = models.QuotationDraft
+	quotation.CreatedBy = userID
+	quotation.CreatedAt = now
+
+	if err := h.QuotationRepo.Insert(ctx, quotation, roleVar.(string)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create quotation"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Quotation created successfully",
+		"quotation": quotation,
+	})
+}
+
+// UpdateQuotation แก้ได้เฉพาะใบที่ยังเป็น Draft ราคาทั้งหมดถูกคิดใหม่ตามราคาปัจจุบัน
+func (h *QuotationHandle) UpdateQuotation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can update quotations"})
+		return
+	}
+	role := roleVar.(string)
+
+	existing, ok := h.loadQuotation(ctx, c, role)
+	if !ok {
+		return
+	}
+	if existing.Status != models.QuotationDraft {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only draft quotations can be edited"})
+		return
+	}
+
+	var input QuotationRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quotation, err := h.buildQuotation(ctx, input, role)
+	if err != nil {
+		writeHandlerError(c, err)
+		return
+	}
+
+	result, err := h.QuotationRepo.UpdateIfUnchanged(ctx, existing, bson.M{"$set": bson.M{
+		"customer_id":        quotation.CustomerID,
+		"prices_include_tax": quotation.PricesIncludeTax,
+		"subtotal":           quotation.Subtotal,
+		"discounts":          quotation.Discounts,
+		"discount_total":     quotation.DiscountTotal,
+		"tax_total":          quotation.TaxTotal,
+		"shipping_total":     quotation.ShippingTotal,
+		"total_amount":       quotation.TotalAmount,
+		"items":              quotation.Items,
+		"location_id":        quotation.LocationID,
+		"coupon_code":        quotation.CouponCode,
+		"shipping_address":   quotation.ShippingAddress,
+		"billing_address":    quotation.BillingAddress,
+		"shipping":           quotation.Shipping,
+		"note":               quotation.Note,
+		"expires_at":         quotation.ExpiresAt,
+		"updated_at":         time.Now(),
+	}}, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update quotation"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Quotation was modified, please retry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quotation updated successfully"})
+}
+
+func (h *QuotationHandle) SendQuotation(c *gin.Context) {
+	h.transitionQuotation(c, models.QuotationSent, models.QuotationDraft)
+}
+
+func (h *QuotationHandle) CancelQuotation(c *gin.Context) {
+	h.transitionQuotation(c, models.QuotationCancelled, models.QuotationDraft, models.QuotationSent)
+}
+
+func (h *QuotationHandle) transitionQuotation(c *gin.Context, to string, from ...string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can update quotations"})
+		return
+	}
+
+	quotation, ok := h.loadQuotation(ctx, c, roleVar.(string))
+	if !ok {
+		return
+	}
+	if !contains(from, quotation.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Cannot change quotation from %s to %s", quotation.Status, to)})
+		return
+	}
+	if to == models.QuotationSent && quotation.Expired(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Quotation has expired"})
+		return
+	}
+
+	result, err := h.QuotationRepo.UpdateIfUnchanged(ctx, quotation, bson.M{"$set": bson.M{"status": to, "updated_at": time.Now()}}, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update quotation"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Quotation was modified, please retry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quotation " + to})
+}
+
+// GetQuotationPDF สร้าง PDF ใหม่ทุกครั้ง วันที่ในเอกสารใช้เวลาที่แก้ไขล่าสุดเพื่อให้ใบเดิมได้ไฟล์เดิม
+func (h *QuotationHandle) GetQuotationPDF(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can view quotations"})
+		return
+	}
+	role := roleVar.(string)
+
+	quotation, ok := h.loadQuotation(ctx, c, role)
+	if !ok {
+		return
+	}
+	if h.Renderer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PDF font is not configured"})
+		return
+	}
+
+	var customer models.Customer
+	found, err := h.Orders.CustomerRep.FindByID(ctx, quotation.CustomerID, role)
+	if err != nil && err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load customer"})
+		return
+	}
+	if found != nil {
+		customer = *found
+	}
+
+	issuedAt := quotation.CreatedAt
+	if quotation.UpdatedAt.After(issuedAt) {
+		issuedAt = quotation.UpdatedAt
+	}
+	content, err := h.Renderer.Render(document.Data{
+		Type:     models.DocumentQuotation,
+		Number:   quotation.Number,
+		IssuedAt: issuedAt,
+		Customer: customer,
+		Order: models.Order{
+			Subtotal:       quotation.Subtotal,
+			DiscountTotal:  quotation.DiscountTotal,
+			TaxTotal:       quotation.TaxTotal,
+			ShippingTotal:  quotation.ShippingTotal,
+			TotalAmount:    quotation.TotalAmount,
+			Items:          quotation.Items,
+			BillingAddress: quotation.BillingAddress,
+		},
+		Lines:   documentLines(ctx, h.Orders.ProductRep, quotation.Items),
+		ValidTo: quotation.ExpiresAt,
+	})
+	if err != nil {
+		log.Printf("failed to render quotation %s: %v", quotation.Number, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render document"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, quotation.Number))
+	c.Data(http.StatusOK, "application/pdf", content)
+}
+
+// ConvertQuotation สร้างออเดอร์จากใบเสนอราคาผ่านการตรวจสต็อกและตัดสต็อกเดียวกับ CreateOrders
+// ใบเสนอราคาถูกจองสถานะเป็น Converted ก่อน ถ้าสร้างออเดอร์ไม่สำเร็จจะคืนสถานะเดิม
+func (h *QuotationHandle) ConvertQuotation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	userIdVar, _ := c.Get("userId")
+	userID, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can create orders"})
+		return
+	}
+	role := roleVar.(string)
+
+	quotation, ok := h.loadQuotation(ctx, c, role)
+	if !ok {
+		return
+	}
+	switch quotation.Status {
+	case models.QuotationDraft, models.QuotationSent:
+	case models.QuotationConverted:
+		c.JSON(http.StatusConflict, gin.H{"error": "Quotation has already been converted"})
+		return
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot convert a " + quotation.Status + " quotation"})
+		return
+	}
+	if quotation.Expired(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Quotation has expired"})
+		return
+	}
+
+	customer, err := h.Orders.CustomerRep.FindByID(ctx, quotation.CustomerID, role)
+	if err == nil && customer.MergedInto != nil {
+		customer, err = h.Orders.CustomerRep.FindByID(ctx, *customer.MergedInto, role)
+	}
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load customer"})
+		return
+	}
+
+	now := time.Now()
+	result, err := h.QuotationRepo.UpdateIfUnchanged(ctx, quotation, bson.M{"$set": bson.M{"status": models.QuotationConverted, "converted_at": now}}, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update quotation"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Quotation was modified, please retry"})
+		return
+	}
+	claimed := *quotation
+	claimed.Status = models.QuotationConverted
+
+	input := OrderRequest{CouponCode: quotation.CouponCode}
+	if !quotation.LocationID.IsZero() {
+		input.LocationID = quotation.LocationID.Hex()
+	}
+	for _, item := range quotation.Items {
+		input.Items = append(input.Items, OrderItemRequest{ProductID: item.ProductID.Hex(), Quantity: item.Quantity})
+	}
+
+	order, err := h.Orders.placeOrder(ctx, input, customer, quotation.ShippingAddress, quotation.BillingAddress, quotation, userID, role)
+	if err != nil {
+		revert := bson.M{"$set": bson.M{"status": quotation.Status}, "$unset": bson.M{"converted_at": ""}}
+		if _, rerr := h.QuotationRepo.UpdateIfUnchanged(ctx, &claimed, revert, role); rerr != nil {
+			log.Printf("failed to reopen quotation %s after conversion error: %v", quotation.Number, rerr)
+		}
+		writeHandlerError(c, err)
+		return
+	}
+
+	if _, err := h.QuotationRepo.UpdateIfUnchanged(ctx, &claimed, bson.M{"$set": bson.M{"order_id": order.ID}}, role); err != nil {
+		log.Printf("quotation %s: failed to link order %s: %v", quotation.Number, order.ID.Hex(), err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Quotation converted to order",
+		"order":   order,
+	})
+}
+
+// buildQuotation หาหรือสร้างลูกค้า เลือกที่อยู่ และคิดราคากับค่าส่งด้วยขั้นตอนเดียวกับการสร้างออเดอร์
+func (h *QuotationHandle) buildQuotation(ctx context.Context, input QuotationRequest, role string) (*models.Quotation, error) {
+	customer, err := h.Orders.findOrCreateCustomer(ctx, input.OrderRequest, role)
+	if err != nil {
+		return nil, &handlerError{http.StatusInternalServerError, "Failed to create customer"}
+	}
+
+	shippingAddress, billingAddress, err := h.Orders.orderAddresses(ctx, customer, input.OrderRequest, role)
+	if err != nil {
+		return nil, err
+	}
+
+	var locationID primitive.ObjectID
+	if input.LocationID != "" {
+		locationID, err = primitive.ObjectIDFromHex(input.LocationID)
+		if err != nil {
+			return nil, &handlerError{http.StatusBadRequest, "Invalid location ID"}
+		}
+	}
+
+	items, products, err := h.Orders.loadOrderItems(ctx, input.Items, nil)
+	if err != nil {
+		return nil, err
+	}
+	priced, err := h.Orders.priceOrder(ctx, items, products, input.CouponCode)
+	if err != nil {
+		return nil, err
+	}
+
+	shippingMethod, err := h.Orders.quoteShipping(ctx, input.OrderRequest, shippingAddress, priced.Items, products, priced.Total)
+	if err != nil {
+		return nil, err
+	}
+	shippingTotal, err := h.Orders.addShipping(priced, shippingMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	validDays := input.ValidDays
+	if validDays == 0 {
+		validDays = defaultQuotationValidDays
+	}
+
+	return &models.Quotation{
+		CustomerID:       customer.ID,
+		PricesIncludeTax: h.Orders.TaxCalc.PricesIncludeTax,
+		Subtotal:         priced.Subtotal,
+		Discounts:        priced.Discounts,
+		DiscountTotal:    priced.DiscountTotal,
+		TaxTotal:         priced.TaxTotal,
+		ShippingTotal:    shippingTotal,
+		TotalAmount:      priced.Total,
+		Items:            priced.Items,
+		LocationID:       locationID,
+		CouponCode:       input.CouponCode,
+		ShippingAddress:  shippingAddress,
+		BillingAddress:   billingAddress,
+		Shipping:         shippingMethod,
+		Note:             input.Note,
+		ExpiresAt:        time.Now().AddDate(0, 0, validDays),
+	}, nil
+}
+
+func (h *QuotationHandle) loadQuotation(ctx context.Context, c *gin.Context, role string) (*models.Quotation, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quotation ID"})
+		return nil, false
+	}
+	quotation, err := h.QuotationRepo.FindByID(ctx, id, role)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quotation not found"})
+		return nil, false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return quotation, true
+}
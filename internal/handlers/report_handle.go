@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // ให้ LoadLocation ใช้ได้บนเครื่องที่ไม่มีฐานข้อมูล timezone
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/money"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/spreadsheet"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultReportTimezone = "Asia/Bangkok"
+	defaultReportDays     = 30
+	maxReportPeriods      = 1000
+)
+
+type ReportHandle struct {
+	ReportRepo repositories.ReportRepositoryInterface
+}
+
+func NewReportHandle(reportRepo repositories.ReportRepositoryInterface) *ReportHandle {
+	return &ReportHandle{ReportRepo: reportRepo}
+}
+
+// GetSummary คือข้อมูลสำหรับหน้า dashboard ยอดรวม ค่าเฉลี่ยต่อออเดอร์ และจำนวนออเดอร์แยกตามสถานะ
+func (h *ReportHandle) GetSummary(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	filter, ok := reportFilter(c)
+	if !ok {
+		return
+	}
+
+	totals, err := h.ReportRepo.SalesTotals(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	statuses, err := h.ReportRepo.StatusCounts(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := reportMeta(filter)
+	response["totals"] = totals
+	response["statuses"] = statuses
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSales แบ่งยอดขายตามช่วงเวลา group_by=day|week|month ช่วงที่ไม่มียอดขายจะแสดงเป็นศูนย์
+func (h *ReportHandle) GetSales(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	filter, ok := reportFilter(c)
+	if !ok {
+		return
+	}
+	groupBy := c.DefaultQuery("group_by", repositories.ReportByDay)
+	periods, err := reportPeriods(filter, groupBy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := h.ReportRepo.SalesByPeriod(ctx, filter, groupBy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	found := map[string]models.SalesPeriod{}
+	for _, row := range rows {
+		found[row.Period] = row
+	}
+	sales := make([]models.SalesPeriod, 0, len(periods))
+	for _, period := range periods {
+		row, ok := found[period]
+		if !ok {
+			row = models.SalesPeriod{Period: period}
+		}
+		sales = append(sales, row)
+	}
+
+	if format := c.Query("format"); format != "" {
+		records := make([][]string, 0, len(sales))
+		for _, row := range sales {
+			records = append(records, append([]string{row.Period}, salesTotalsRecord(row.SalesTotals, filter.Currency)...))
+		}
+		writeReport(c, format, reportFilename("sales-"+groupBy, filter), append([]string{"period"}, salesTotalsColumns...), records)
+		return
+	}
+
+	response := reportMeta(filter)
+	response["group_by"] = groupBy
+	response["total"] = len(sales)
+	response["sales"] = sales
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTopProducts จัดอันดับสินค้าขายดี sort=quantity|revenue
+func (h *ReportHandle) GetTopProducts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	filter, ok := reportFilter(c)
+	if !ok {
+		return
+	}
+	sortBy := c.DefaultQuery("sort", "quantity")
+	if sortBy != "quantity" && sortBy != "revenue" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be quantity or revenue"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	products, err := h.ReportRepo.TopProducts(ctx, filter, sortBy, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format := c.Query("format"); format != "" {
+		records := make([][]string, 0, len(products))
+		for _, row := range products {
+			records = append(records, []string{
+				row.ProductID.Hex(),
+				row.SKU,
+				row.Name,
+				strconv.Itoa(row.Quantity),
+				strconv.Itoa(row.Returned),
+				strconv.Itoa(row.Orders),
+				money.Format(models.Money{Amount: row.Revenue, Currency: filter.Currency}),
+			})
+		}
+		writeReport(c, format, reportFilename("top-products", filter), []string{"product_id", "sku", "name", "quantity", "returned", "orders", "revenue"}, records)
+		return
+	}
+
+	response := reportMeta(filter)
+	response["sort"] = sortBy
+	response["total"] = len(products)
+	response["products"] = products
+	c.JSON(http.StatusOK, response)
+}
+
+// GetStaffSales แบ่งยอดขายตามพนักงานที่สร้างออเดอร์ Staff จะเห็นเฉพาะยอดของตัวเอง
+func (h *ReportHandle) GetStaffSales(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	filter, ok := reportFilter(c)
+	if !ok {
+		return
+	}
+
+	staff, err := h.ReportRepo.SalesByStaff(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format := c.Query("format"); format != "" {
+		records := make([][]string, 0, len(staff))
+		for _, row := range staff {
+			records = append(records, append([]string{row.UserID.Hex(), row.Username, row.Email}, salesTotalsRecord(row.SalesTotals, filter.Currency)...))
+		}
+		writeReport(c, format, reportFilename("staff-sales", filter), append([]string{"user_id", "username", "email"}, salesTotalsColumns...), records)
+		return
+	}
+
+	response := reportMeta(filter)
+	response["total"] = len(staff)
+	response["staff"] = staff
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ReportHandle) GetStatusCounts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	filter, ok := reportFilter(c)
+	if !ok {
+		return
+	}
+
+	statuses, err := h.ReportRepo.StatusCounts(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format := c.Query("format"); format != "" {
+		records := make([][]string, 0, len(statuses))
+		for _, row := range statuses {
+			records = append(records, []string{
+				row.Status,
+				strconv.Itoa(row.Orders),
+				money.Format(models.Money{Amount: row.Revenue, Currency: filter.Currency}),
+			})
+		}
+		writeReport(c, format, reportFilename("order-status", filter), []string{"status", "orders", "revenue"}, records)
+		return
+	}
+
+	response := reportMeta(filter)
+	response["total"] = len(statuses)
+	response["statuses"] = statuses
+	c.JSON(http.StatusOK, response)
+}
+
+// GetBackorders แสดงสินค้าที่มีออเดอร์รอสต็อกพร้อมลำดับออเดอร์ที่จะได้รับสต็อกก่อน
+// ถ้าไม่ระบุ from/to จะนับทุกออเดอร์ที่ยังรออยู่ ไม่จำกัดแค่ 30 วันล่าสุด
+func (h *ReportHandle) GetBackorders(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	filter, ok := reportFilter(c)
+	if !ok {
+		return
+	}
+	allTime := c.Query("from") == "" && c.Query("to") == ""
+	if allTime {
+		filter.From, filter.To = time.Time{}, time.Time{}
+	}
+
+	products, err := h.ReportRepo.Backorders(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format := c.Query("format"); format != "" {
+		records := make([][]string, 0, len(products))
+		for _, row := range products {
+			records = append(records, []string{
+				row.ProductID.Hex(),
+				row.SKU,
+				row.Name,
+				strconv.Itoa(row.Backordered),
+				strconv.Itoa(row.Orders),
+				strconv.Itoa(row.Stock),
+				row.OldestOrderAt.In(filter.Location).Format("2006-01-02"),
+			})
+		}
+		filename := reportFilename("backorders", filter)
+		if allTime {
+			filename = "backorders-" + time.Now().In(filter.Location).Format("20060102")
+		}
+		writeReport(c, format, filename, []string{"product_id", "sku", "name", "backordered", "orders", "stock", "oldest_order_at"}, records)
+		return
+	}
+
+	response := gin.H{"timezone": filter.Location.String()}
+	if !allTime {
+		response = reportMeta(filter)
+	}
+	response["total"] = len(products)
+	response["products"] = products
+	c.JSON(http.StatusOK, response)
+}
+
+var salesTotalsColumns = []string{"orders", "revenue", "tax", "discount", "refunded", "net_revenue", "average_order_value"}
+
+func salesTotalsRecord(t models.SalesTotals, currency string) []string {
+	t.Finish()
+	format := func(amount int64) string {
+		return money.Format(models.Money{Amount: amount, Currency: currency})
+	}
+	return []string{
+		strconv.Itoa(t.Orders),
+		format(t.Revenue),
+		format(t.Tax),
+		format(t.Discount),
+		format(t.Refunded),
+		format(t.NetRevenue),
+		format(t.AverageOrderValue),
+	}
+}
+
+// reportFilter อ่าน from/to (YYYY-MM-DD รวมวันสุดท้าย) tz currency และ status จาก query
+// ถ้าไม่ระบุช่วงวันจะใช้ 30 วันล่าสุดตามเวลาท้องถิ่น
+func reportFilter(c *gin.Context) (repositories.ReportFilter, bool) {
+	var filter repositories.ReportFilter
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can view reports"})
+		return filter, false
+	}
+	userIdVar, _ := c.Get("userId")
+	userID, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return filter, false
+	}
+
+	loc, err := time.LoadLocation(c.DefaultQuery("tz", defaultReportTimezone))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
+		return filter, false
+	}
+
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if value := c.Query("to"); value != "" {
+		if to, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return filter, false
+		}
+	}
+	from := to.AddDate(0, 0, -(defaultReportDays - 1))
+	if value := c.Query("from"); value != "" {
+		if from, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return filter, false
+		}
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return filter, false
+	}
+
+	currency := money.NormalizeCurrency(c.DefaultQuery("currency", money.DefaultCurrency()))
+	if !money.ValidCurrency(currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency"})
+		return filter, false
+	}
+
+	var statuses []string
+	if value := c.Query("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				statuses = append(statuses, status)
+			}
+		}
+	}
+
+	filter = repositories.ReportFilter{
+		From:     from,
+		To:       to.AddDate(0, 0, 1),
+		Location: loc,
+		Currency: currency,
+		Statuses: statuses,
+		UserID:   userID,
+		Role:     roleVar.(string),
+	}
+	return filter, true
+}
+
+func reportMeta(filter repositories.ReportFilter) gin.H {
+	return gin.H{
+		"from":     filter.From.Format("2006-01-02"),
+		"to":       filter.To.AddDate(0, 0, -1).Format("2006-01-02"),
+		"timezone": filter.Location.String(),
+		"currency": filter.Currency,
+	}
+}
+
+// reportPeriods คืน key ของทุกช่วงในรายงานเรียงตามเวลา รูปแบบเดียวกับที่ MongoDB สร้าง
+func reportPeriods(filter repositories.ReportFilter, groupBy string) ([]string, error) {
+	start := filter.From
+	var step func(time.Time) time.Time
+	var key func(time.Time) string
+	switch groupBy {
+	case repositories.ReportByDay:
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+		key = func(t time.Time) string { return t.Format("2006-01-02") }
+	case repositories.ReportByWeek:
+		// สัปดาห์แบบ ISO เริ่มวันจันทร์
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+		key = func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%04d-W%02d", year, week)
+		}
+	case repositories.ReportByMonth:
+		start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+		step = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+		key = func(t time.Time) string { return t.Format("2006-01") }
+	default:
+		return nil, fmt.Errorf("group_by must be day, week or month")
+	}
+
+	var periods []string
+	for t := start; t.Before(filter.To); t = step(t) {
+		if len(periods) == maxReportPeriods {
+			return nil, fmt.Errorf("date range is too long for group_by=%s", groupBy)
+		}
+		periods = append(periods, key(t))
+	}
+	return periods, nil
+}
+
+// reportFilename ชื่อไฟล์รายงานพร้อมช่วงวัน ไม่รวมนามสกุล
+func reportFilename(name string, filter repositories.ReportFilter) string {
+	return fmt.Sprintf("%s-%s-%s", name, filter.From.Format("20060102"), filter.To.AddDate(0, 0, -1).Format("20060102"))
+}
+
+func writeReport(c *gin.Context, format string, filename string, header []string, records [][]string) {
+	format, err := spreadsheet.DetectFormat("", format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	writer, err := spreadsheet.NewWriter(c.Writer, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", spreadsheet.ContentType(format))
+	c.Header("Content-Disposition", "attachment; filename="+filename+"."+format)
+	c.Status(http.StatusOK)
+
+	if err := writer.WriteRow(header); err != nil {
+		c.Error(err)
+		return
+	}
+	for _, record := range records {
+		if err := writer.WriteRow(record); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	if err := writer.Close(); err != nil {
+		c.Error(err)
+	}
+}
@@ -0,0 +1,642 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/money"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/outbox"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ReturnItemRequest struct {
+	ProductID string `json:"product_id" form:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" form:"quantity" binding:"required,min=1"`
+	Reason    string `json:"reason" form:"reason"`
+}
+
+type ReturnRequest struct {
+	OrderID string              `json:"order_id" form:"order_id" binding:"required"`
+	Items   []ReturnItemRequest `json:"items" form:"items" binding:"required,min=1,dive"`
+	Reason  string              `json:"reason" form:"reason"`
+}
+
+type ReturnDecisionRequest struct {
+	Note string `json:"note" form:"note"`
+}
+
+type ReceiveReturnItemRequest struct {
+	ProductID  string `json:"product_id" form:"product_id" binding:"required"`
+	Resellable bool   `json:"resellable" form:"resellable"`
+}
+
+type ReceiveReturnRequest struct {
+	Items []ReceiveReturnItemRequest `json:"items" form:"items" binding:"dive"`
+	Note  string                     `json:"note" form:"note"`
+}
+
+type RefundRequest struct {
+	Amount    money.Decimal `json:"amount" form:"amount"` // ไม่ระบุคือคืนยอดที่เหลือทั้งหมด
+	PaymentID string        `json:"payment_id" form:"payment_id"`
+	Reference string        `json:"reference" form:"reference"`
+	Reason    string        `json:"reason" form:"reason"`
+}
+
+type ReturnHandle struct {
+	ReturnRepo  repositories.ReturnRepositoryInterface
+	OrderRepo   repositories.OrderRepositoryInterface
+	ProductRepo repositories.ProductRepositoryInterface
+	PaymentRepo repositories.PaymentRepositoryInterface
+	RefundRepo  repositories.RefundRepositoryInterface
+	CounterRepo repositories.CounterRepositoryInterface
+	Events      outbox.Recorder
+}
+
+func NewReturnHandle(returnRepo repositories.ReturnRepositoryInterface, orderRepo repositories.OrderRepositoryInterface, productRepo repositories.ProductRepositoryInterface, paymentRepo repositories.PaymentRepositoryInterface, refundRepo repositories.RefundRepositoryInterface, counterRepo repositories.CounterRepositoryInterface, events outbox.Recorder) *ReturnHandle {
+	return &ReturnHandle{ReturnRepo: returnRepo, OrderRepo: orderRepo, ProductRepo: productRepo, PaymentRepo: paymentRepo, RefundRepo: refundRepo, CounterRepo: counterRepo, Events: events}
+}
+
+func (h *ReturnHandle) GetReturns(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	role, _ := roleVar.(string)
+
+	filter := bson.M{}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	if orderIDStr := c.Query("order_id"); orderIDStr != "" {
+		orderID, err := primitive.ObjectIDFromHex(orderIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+			return
+		}
+		filter["order_id"] = orderID
+	}
+
+	returns, err := h.ReturnRepo.FindAll(ctx, filter, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":   len(returns),
+		"returns": returns,
+	})
+}
+
+func (h *ReturnHandle) GetReturn(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	role, _ := roleVar.(string)
+
+	ret, ok := h.loadReturn(ctx, c, role)
+	if !ok {
+		return
+	}
+
+	refunds, err := h.RefundRepo.FindByOrder(ctx, ret.OrderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var ownRefunds []models.Refund
+	for _, refund := range refunds {
+		if refund.ReturnID != nil && *refund.ReturnID == ret.ID {
+			ownRefunds = append(ownRefunds, refund)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"return": ret, "refunds": ownRefunds})
+}
+
+func (h *ReturnHandle) CreateReturn(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can request returns"})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	createBy, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var input ReturnRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orderID, err := primitive.ObjectIDFromHex(input.OrderID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+	order, err := h.OrderRepo.FindByID(ctx, orderID, roleVar.(string))
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !isPaidStatus(order.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Returns can only be requested for paid orders"})
+		return
+	}
+
+	// จำนวนที่อยู่ระหว่างคืนในคำขออื่นยังไม่ถูกนับใน OrderItem.Returned จึงต้องนับเพิ่ม
+	openReturns, err := h.ReturnRepo.FindAll(ctx, bson.M{
+		"order_id": orderID,
+		"status":   bson.M{"$in": bson.A{models.ReturnRequested, models.ReturnApproved}},
+	}, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	pending := map[primitive.ObjectID]int{}
+	for _, ret := range openReturns {
+		for _, item := range ret.Items {
+			pending[item.ProductID] += item.Quantity
+		}
+	}
+
+	currency := order.TotalAmount.Currency
+	refundTotal := money.Zero(currency)
+	var items []models.ReturnItem
+	seen := map[primitive.ObjectID]bool{}
+
+	for _, item := range input.Items {
+		productID, err := primitive.ObjectIDFromHex(item.ProductID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID: " + item.ProductID})
+			return
+		}
+		if seen[productID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Duplicate product on return: " + item.ProductID})
+			return
+		}
+		seen[productID] = true
+
+		ordered, returned, lineTotal := orderedProduct(order, productID)
+		if ordered == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Product is not on this order: " + item.ProductID})
+			return
+		}
+		already := returned + pending[productID]
+		if item.Quantity > ordered-already {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Cannot return %d of %s, only %d returnable", item.Quantity, item.ProductID, ordered-already)})
+			return
+		}
+
+		// คิดจากยอดสะสมเพื่อให้คืนครบทุกชิ้นแล้วได้เท่ากับยอดที่ขายพอดี ไม่มีเศษสตางค์ตกหล่น
+		refund := lineTotal*int64(already+item.Quantity)/int64(ordered) - lineTotal*int64(already)/int64(ordered)
+		refundAmount := models.Money{Amount: refund, Currency: currency}
+		if refundTotal, err = refundTotal.Add(refundAmount); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		items = append(items, models.ReturnItem{
+			ProductID:    productID,
+			Quantity:     item.Quantity,
+			Reason:       item.Reason,
+			RefundAmount: refundAmount,
+		})
+	}
+
+	seq, err := h.CounterRepo.Next(ctx, "return")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to allocate return number"})
+		return
+	}
+
+	ret := models.Return{
+		Number:         fmt.Sprintf("RMA%s-%05d", time.Now().Format("200601"), seq),
+		OrderID:        orderID,
+		Status:         models.ReturnRequested,
+		Items:          items,
+		Reason:         input.Reason,
+		RefundTotal:    refundTotal,
+		RefundedAmount: money.Zero(currency),
+		CreatedBy:      createBy,
+		CreatedAt:      time.Now(),
+	}
+	if err := h.ReturnRepo.Insert(ctx, &ret, roleVar.(string)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create return"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Return requested successfully", "return": ret})
+}
+
+func (h *ReturnHandle) ApproveReturn(c *gin.Context) {
+	h.decideReturn(c, models.ReturnApproved)
+}
+
+func (h *ReturnHandle) RejectReturn(c *gin.Context) {
+	h.decideReturn(c, models.ReturnRejected)
+}
+
+func (h *ReturnHandle) decideReturn(c *gin.Context, to string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can approve or reject returns"})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	decidedBy, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var input ReturnDecisionRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ret, ok := h.loadReturn(ctx, c, roleVar.(string))
+	if !ok {
+		return
+	}
+	if ret.Status != models.ReturnRequested {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Cannot change return from %s to %s", ret.Status, to)})
+		return
+	}
+
+	result, err := h.ReturnRepo.UpdateIfUnchanged(ctx, ret, bson.M{"$set": bson.M{
+		"status":     to,
+		"note":       input.Note,
+		"decided_by": decidedBy,
+		"decided_at": time.Now(),
+	}}, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update return"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Return was modified, please retry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Return " + to})
+}
+
+func (h *ReturnHandle) ReceiveReturn(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can receive returns"})
+		return
+	}
+
+	var input ReceiveReturnRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ret, ok := h.loadReturn(ctx, c, roleVar.(string))
+	if !ok {
+		return
+	}
+	if ret.Status != models.ReturnApproved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only approved returns can be received"})
+		return
+	}
+
+	resellable := map[primitive.ObjectID]bool{}
+	for _, item := range input.Items {
+		productID, err := primitive.ObjectIDFromHex(item.ProductID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID: " + item.ProductID})
+			return
+		}
+		resellable[productID] = item.Resellable
+	}
+
+	items := append([]models.ReturnItem(nil), ret.Items...)
+	for i := range items {
+		items[i].Resellable = resellable[items[i].ProductID]
+	}
+
+	order, err := h.OrderRepo.FindByID(ctx, ret.OrderID, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load order"})
+		return
+	}
+
+	orderItems := append([]models.OrderItem(nil), order.Items...)
+	returned := map[int]int{}
+	// ของที่ขายต่อได้คืนเข้าคลังเดียวกับที่ตัดสต็อกตอนขาย
+	var restock []models.OrderItem
+	for _, item := range items {
+		remaining := item.Quantity
+		for i := range orderItems {
+			if orderItems[i].ProductID != item.ProductID || remaining == 0 {
+				continue
+			}
+			take := min(remaining, orderItems[i].Quantity-orderItems[i].Returned)
+			if take == 0 {
+				continue
+			}
+			orderItems[i].Returned += take
+			returned[i] += take
+			remaining -= take
+			if item.Resellable {
+				restock = append(restock, models.OrderItem{ProductID: item.ProductID, LocationID: orderItems[i].LocationID, Quantity: take})
+			}
+		}
+	}
+	orderUpdate := bson.M{}
+	if fullyReturned(orderItems) && order.Status != "Refunded" {
+		orderUpdate["status"] = "Returned"
+	}
+
+	now := time.Now()
+	set := bson.M{"status": models.ReturnReceived, "items": items, "received_at": now}
+	if input.Note != "" {
+		set["note"] = input.Note
+	}
+	// สถานะคำขอคืน จำนวนที่คืนในออเดอร์ และสต็อกต้องเปลี่ยนพร้อมกัน ถ้าขั้นใดล้มเหลวคำขอยังรับคืนใหม่ได้
+	err = h.Events.Transaction(ctx, func(ctx context.Context) error {
+		result, err := h.ReturnRepo.UpdateIfUnchanged(ctx, ret, bson.M{"$set": set}, roleVar.(string))
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return &handlerError{http.StatusConflict, "Return was modified, please retry"}
+		}
+		result, err = h.OrderRepo.ApplyReturn(ctx, order.ID, order.Items, returned, orderUpdate, roleVar.(string))
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return &handlerError{http.StatusConflict, "Order was modified, please retry"}
+		}
+		for _, item := range restock {
+			if err := h.ProductRepo.UpdateLocationStock(ctx, item.ProductID, item.LocationID, item.Quantity); err != nil {
+				return fmt.Errorf("restock %s: %w", item.ProductID.Hex(), err)
+			}
+		}
+		updated := *order
+		updated.Items = orderItems
+		if status, ok := orderUpdate["status"].(string); ok {
+			updated.Status = status
+		}
+		return recordStatusChanged(ctx, h.Events, order.Status, &updated)
+	})
+	if err != nil {
+		if _, ok := err.(*handlerError); ok {
+			writeHandlerError(c, err)
+			return
+		}
+		log.Printf("return %s: failed to receive: %v", ret.Number, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to receive return"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Return received successfully"})
+}
+
+func (h *ReturnHandle) RefundReturn(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can issue refunds"})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	createBy, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var input RefundRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ret, ok := h.loadReturn(ctx, c, roleVar.(string))
+	if !ok {
+		return
+	}
+	if ret.Status != models.ReturnApproved && ret.Status != models.ReturnReceived {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only approved or received returns can be refunded"})
+		return
+	}
+
+	currency := ret.RefundTotal.Currency
+	remaining, err := ret.RefundTotal.Sub(ret.RefundedAmount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	amount := remaining
+	if input.Amount != "" {
+		amount, err = input.Amount.Money(currency)
+		if err != nil || amount.Amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount"})
+			return
+		}
+	}
+	if amount.Amount <= 0 || amount.Amount > remaining.Amount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Refund exceeds remaining refundable amount " + money.Format(remaining)})
+		return
+	}
+
+	payments, err := h.PaymentRepo.FindByOrder(ctx, ret.OrderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load payments"})
+		return
+	}
+	if input.PaymentID != "" {
+		paymentID, err := primitive.ObjectIDFromHex(input.PaymentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+			return
+		}
+		var selected []models.Payment
+		for _, payment := range payments {
+			if payment.ID == paymentID {
+				selected = append(selected, payment)
+			}
+		}
+		if len(selected) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found on this order"})
+			return
+		}
+		payments = selected
+	}
+
+	refunds := allocateRefund(payments, amount.Amount)
+	var allocated int64
+	for _, refund := range refunds {
+		allocated += refund.Amount.Amount
+	}
+	if allocated < amount.Amount {
+		c.JSON(http.StatusConflict, gin.H{"error": "Refund exceeds amount paid on the selected payments"})
+		return
+	}
+
+	before, err := h.OrderRepo.FindByID(ctx, ret.OrderID, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load order"})
+		return
+	}
+
+	set := bson.M{}
+	now := time.Now()
+	if amount.Amount == remaining.Amount {
+		set["status"] = models.ReturnRefunded
+		set["refunded_at"] = now
+	}
+	update := bson.M{"$inc": bson.M{"refunded_amount.amount": amount.Amount}}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	// ยอดในคำขอคืน รายการชำระ ยอดในออเดอร์ และบันทึกการคืนเงินต้องเปลี่ยนพร้อมกัน ถ้ามีคำขออื่นคืนเงินพร้อมกันจะชนที่การจองยอดในคำขอคืน
+	err = h.Events.Transaction(ctx, func(ctx context.Context) error {
+		result, err := h.ReturnRepo.UpdateIfUnchanged(ctx, ret, update, roleVar.(string))
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return &handlerError{http.StatusConflict, "Return was modified, please retry"}
+		}
+		for _, refund := range refunds {
+			err := h.PaymentRepo.AddRefund(ctx, refund.PaymentID, refund.Amount.Amount)
+			if err == repositories.ErrRefundExceedsPayment {
+				return &handlerError{http.StatusConflict, "Payment was refunded concurrently, please retry"}
+			} else if err != nil {
+				return fmt.Errorf("refund payment %s: %w", refund.PaymentID.Hex(), err)
+			}
+		}
+		updated, err := h.OrderRepo.ApplyRefund(ctx, ret.OrderID, amount.Amount)
+		if err == repositories.ErrRefundRejected {
+			return &handlerError{http.StatusConflict, "Refund exceeds amount paid on the order"}
+		} else if err != nil {
+			return fmt.Errorf("apply refund to order: %w", err)
+		}
+		for i := range refunds {
+			refunds[i].OrderID = ret.OrderID
+			refunds[i].ReturnID = &ret.ID
+			refunds[i].Reference = input.Reference
+			refunds[i].Reason = input.Reason
+			refunds[i].CreatedBy = createBy
+			refunds[i].CreatedAt = now
+			if err := h.RefundRepo.Insert(ctx, &refunds[i]); err != nil {
+				return fmt.Errorf("store refund of payment %s: %w", refunds[i].PaymentID.Hex(), err)
+			}
+		}
+		return recordStatusChanged(ctx, h.Events, before.Status, updated)
+	})
+	if err != nil {
+		if _, ok := err.(*handlerError); ok {
+			writeHandlerError(c, err)
+			return
+		}
+		log.Printf("return %s: failed to refund: %v", ret.Number, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue refund"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Refund issued successfully",
+		"refunds":   refunds,
+		"remaining": models.Money{Amount: remaining.Amount - amount.Amount, Currency: currency},
+	})
+}
+
+// allocateRefund แบ่งยอดคืนเงินไปยังรายการชำระ เริ่มจากรายการล่าสุด
+func allocateRefund(payments []models.Payment, amount int64) []models.Refund {
+	var refunds []models.Refund
+	for i := len(payments) - 1; i >= 0 && amount > 0; i-- {
+		payment := payments[i]
+		refundable := payment.Amount.Amount - payment.Refunded.Amount
+		if refundable <= 0 {
+			continue
+		}
+		take := min(refundable, amount)
+		refunds = append(refunds, models.Refund{
+			PaymentID: payment.ID,
+			Amount:    models.Money{Amount: take, Currency: payment.Amount.Currency},
+			Method:    payment.Method,
+		})
+		amount -= take
+	}
+	return refunds
+}
+
+// สินค้าเดียวกันอาจอยู่หลายบรรทัด จึงรวมจำนวนที่สั่ง จำนวนที่คืนแล้ว และยอดเงินของทุกบรรทัด
+func orderedProduct(order *models.Order, productID primitive.ObjectID) (int, int, int64) {
+	var ordered, returned int
+	var lineTotal int64
+	for _, item := range order.Items {
+		if item.ProductID == productID {
+			ordered += item.Quantity
+			returned += item.Returned
+			lineTotal += item.LineTotal.Amount
+		}
+	}
+	return ordered, returned, lineTotal
+}
+
+func fullyReturned(items []models.OrderItem) bool {
+	for _, item := range items {
+		if item.Returned < item.Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *ReturnHandle) loadReturn(ctx context.Context, c *gin.Context, role string) (*models.Return, bool) {
+	returnID, err := primitive.ObjectIDFromHex(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid return ID"})
+		return nil, false
+	}
+
+	ret, err := h.ReturnRepo.FindByID(ctx, returnID, role)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Return not found"})
+		return nil, false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return ret, true
+}
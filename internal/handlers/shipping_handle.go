@@ -0,0 +1,676 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/money"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/outbox"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/shipping"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ShippingHandle struct {
+	CarrierRepo  repositories.CarrierRepositoryInterface
+	ShipmentRepo repositories.ShipmentRepositoryInterface
+	OrderRepo    repositories.OrderRepositoryInterface
+	ProductRepo  repositories.ProductRepositoryInterface
+	Events       outbox.Recorder
+}
+
+type WeightTierRequest struct {
+	UpToGrams int           `json:"up_to_grams" binding:"required,min=1"`
+	Fee       money.Decimal `json:"fee" binding:"required"`
+}
+
+type ShippingRateRequest struct {
+	Service   string              `json:"service" binding:"required"`
+	Zone      string              `json:"zone"`
+	Provinces []string            `json:"provinces"`
+	Type      string              `json:"type" binding:"required,oneof=flat weight"`
+	Fee       money.Decimal       `json:"fee"`
+	Tiers     []WeightTierRequest `json:"tiers" binding:"dive"`
+	FreeOver  money.Decimal       `json:"free_over"`
+}
+
+type CarrierRequest struct {
+	Code        string                `json:"code" binding:"required"`
+	Name        string                `json:"name" binding:"required"`
+	TrackingURL string                `json:"tracking_url"`
+	Currency    string                `json:"currency" binding:"omitempty,len=3"`
+	Rates       []ShippingRateRequest `json:"rates" binding:"required,min=1,dive"`
+	IsActive    *bool                 `json:"is_active"`
+}
+
+type ShippingQuoteRequest struct {
+	Items    []OrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	Province string             `json:"province" binding:"required"`
+}
+
+type ShipmentRequest struct {
+	CarrierID      string             `json:"carrier_id"`
+	Service        string             `json:"service"`
+	TrackingNumber string             `json:"tracking_number"`
+	Items          []OrderItemRequest `json:"items" binding:"dive"` // ไม่ระบุคือส่งของที่เหลือทั้งหมด
+	WeightGrams    int                `json:"weight_grams" binding:"min=0"`
+	Note           string             `json:"note"`
+	Status         string             `json:"status" binding:"omitempty,oneof=Pending Shipped"`
+}
+
+type UpdateShipmentRequest struct {
+	Status         string `json:"status" binding:"required,oneof=Shipped Delivered Cancelled"`
+	TrackingNumber string `json:"tracking_number"`
+}
+
+func NewShippingHandle(carrierRepo repositories.CarrierRepositoryInterface, shipmentRepo repositories.ShipmentRepositoryInterface, orderRepo repositories.OrderRepositoryInterface, productRepo repositories.ProductRepositoryInterface, events outbox.Recorder) *ShippingHandle {
+	return &ShippingHandle{CarrierRepo: carrierRepo, ShipmentRepo: shipmentRepo, OrderRepo: orderRepo, ProductRepo: productRepo, Events: events}
+}
+
+func (h *ShippingHandle) GetCarriers(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can view carriers"})
+		return
+	}
+
+	carriers, err := h.CarrierRepo.FindAll(ctx, roleVar != "Admin")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":    len(carriers),
+		"carriers": carriers,
+	})
+}
+
+func (h *ShippingHandle) CreateCarrier(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can manage carriers"})
+		return
+	}
+
+	var input CarrierRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rates, err := carrierRates(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	carrier := models.Carrier{
+		Code:        strings.ToLower(strings.TrimSpace(input.Code)),
+		Name:        input.Name,
+		TrackingURL: input.TrackingURL,
+		Rates:       rates,
+		IsActive:    input.IsActive == nil || *input.IsActive,
+		CreatedAt:   time.Now(),
+	}
+	if err := shipping.Validate(&carrier); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.CarrierRepo.Insert(ctx, &carrier, roleVar.(string)); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Carrier code already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create carrier"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Carrier created successfully",
+		"carrier": carrier,
+	})
+}
+
+func (h *ShippingHandle) UpdateCarrier(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can manage carriers"})
+		return
+	}
+
+	carrierID, err := primitive.ObjectIDFromHex(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid carrier ID"})
+		return
+	}
+
+	var input CarrierRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rates, err := carrierRates(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := shipping.Validate(&models.Carrier{Rates: rates}); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields := bson.M{
+		"code":         strings.ToLower(strings.TrimSpace(input.Code)),
+		"name":         input.Name,
+		"tracking_url": input.TrackingURL,
+		"rates":        rates,
+		"updated_at":   time.Now(),
+	}
+	if input.IsActive != nil {
+		fields["is_active"] = *input.IsActive
+	}
+
+	result, err := h.CarrierRepo.Update(ctx, carrierID, fields, roleVar.(string))
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Carrier code already exists"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update carrier"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Carrier not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Carrier updated successfully"})
+}
+
+// DeleteCarrier ลบผู้ให้บริการ ออเดอร์และพัสดุเดิมยังเก็บชื่อและรหัสผู้ให้บริการไว้
+func (h *ShippingHandle) DeleteCarrier(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can manage carriers"})
+		return
+	}
+
+	carrierID, err := primitive.ObjectIDFromHex(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid carrier ID"})
+		return
+	}
+
+	result, err := h.CarrierRepo.Delete(ctx, carrierID, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete carrier"})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Carrier not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Carrier deleted successfully"})
+}
+
+// เกณฑ์ส่งฟรีเทียบกับราคาสินค้าก่อนส่วนลด ค่าส่งจริงคิดใหม่ตอนสร้างออเดอร์
+func (h *ShippingHandle) Quote(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can quote shipping"})
+		return
+	}
+
+	var input ShippingQuoteRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var weight int
+	var total models.Money
+	for i, item := range input.Items {
+		productID, err := primitive.ObjectIDFromHex(item.ProductID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID: " + item.ProductID})
+			return
+		}
+		product, err := h.ProductRepo.FindByID(ctx, productID, true)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found: " + item.ProductID})
+			return
+		}
+		if i == 0 {
+			total.Currency = product.Price.Currency
+		} else if product.Price.Currency != total.Currency {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "All items in an order must use the same currency"})
+			return
+		}
+		weight += product.WeightGrams * item.Quantity
+		total.Amount += product.Price.Amount * int64(item.Quantity)
+	}
+
+	carriers, err := h.CarrierRepo.FindAll(ctx, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	options := shipping.Options(carriers, strings.TrimSpace(input.Province), weight, total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"weight_grams": weight,
+		"order_total":  total,
+		"total":        len(options),
+		"options":      options,
+	})
+}
+
+func (h *ShippingHandle) GetShipments(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	order, role, ok := h.loadOrder(ctx, c)
+	if !ok {
+		return
+	}
+	shipments, err := h.ShipmentRepo.FindByOrder(ctx, order.ID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":     len(shipments),
+		"shipments": shipments,
+		"remaining": shipmentItems(unshipped(order, shipments)),
+	})
+}
+
+func (h *ShippingHandle) CreateShipment(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var input ShipmentRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userIdVar, _ := c.Get("userId")
+	userID, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	order, role, ok := h.loadOrder(ctx, c)
+	if !ok {
+		return
+	}
+	if order.Status == "Cancelled" || order.Status == "Completed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot ship a completed or cancelled order"})
+		return
+	}
+	shipments, err := h.ShipmentRepo.FindByOrder(ctx, order.ID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	remaining := unshipped(order, shipments)
+	var items []models.ShipmentItem
+	if len(input.Items) == 0 {
+		items = shipmentItems(remaining)
+	} else {
+		for _, item := range input.Items {
+			productID, err := primitive.ObjectIDFromHex(item.ProductID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID: " + item.ProductID})
+				return
+			}
+			if item.Quantity > remaining[productID] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Quantity exceeds unshipped quantity for product " + item.ProductID})
+				return
+			}
+			remaining[productID] -= item.Quantity
+			items = append(items, models.ShipmentItem{ProductID: productID, Quantity: item.Quantity})
+		}
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "All items in this order are already in shipments or waiting for stock"})
+		return
+	}
+
+	shipment := models.Shipment{
+		OrderID:        order.ID,
+		Service:        input.Service,
+		TrackingNumber: strings.TrimSpace(input.TrackingNumber),
+		Status:         models.ShipmentPending,
+		Items:          items,
+		WeightGrams:    input.WeightGrams,
+		Note:           input.Note,
+		CreatedBy:      userID,
+		CreatedAt:      time.Now(),
+	}
+	if order.Shipping != nil {
+		shipment.CarrierID = order.Shipping.CarrierID
+		shipment.CarrierCode = order.Shipping.CarrierCode
+		if shipment.Service == "" {
+			shipment.Service = order.Shipping.Service
+		}
+	}
+	if input.CarrierID != "" {
+		if shipment.CarrierID, err = primitive.ObjectIDFromHex(input.CarrierID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid carrier ID"})
+			return
+		}
+	}
+	if !shipment.CarrierID.IsZero() {
+		carrier, err := h.CarrierRepo.FindByID(ctx, shipment.CarrierID)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Carrier not found"})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		shipment.CarrierCode = carrier.Code
+		shipment.TrackingURL = shipping.TrackingURL(carrier, shipment.TrackingNumber)
+	}
+	if shipment.WeightGrams == 0 {
+		shipment.WeightGrams = h.itemsWeight(ctx, items)
+	}
+	if input.Status == models.ShipmentShipped {
+		if shipment.TrackingNumber == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tracking number is required to mark a shipment as shipped"})
+			return
+		}
+		now := time.Now()
+		shipment.Status = models.ShipmentShipped
+		shipment.ShippedAt = &now
+	}
+
+	err = h.Events.Transaction(ctx, func(ctx context.Context) error {
+		if err := h.ShipmentRepo.Insert(ctx, &shipment, role); err != nil {
+			return err
+		}
+		return h.syncOrderStatus(ctx, order, append(shipments, shipment), role)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create shipment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Shipment created successfully",
+		"shipment": shipment,
+	})
+}
+
+// UpdateShipment เปลี่ยนสถานะพัสดุ Pending -> Shipped -> Delivered หรือยกเลิกก่อนส่งถึง
+func (h *ShippingHandle) UpdateShipment(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var input UpdateShipmentRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	shipmentID, err := primitive.ObjectIDFromHex(c.Param("shipmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipment ID"})
+		return
+	}
+
+	order, role, ok := h.loadOrder(ctx, c)
+	if !ok {
+		return
+	}
+	shipments, err := h.ShipmentRepo.FindByOrder(ctx, order.ID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var shipment *models.Shipment
+	for i := range shipments {
+		if shipments[i].ID == shipmentID {
+			shipment = &shipments[i]
+		}
+	}
+	if shipment == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shipment not found"})
+		return
+	}
+
+	allowed := map[string][]string{
+		models.ShipmentPending: {models.ShipmentShipped, models.ShipmentCancelled},
+		models.ShipmentShipped: {models.ShipmentDelivered, models.ShipmentCancelled},
+	}
+	if !contains(allowed[shipment.Status], input.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot change shipment from " + shipment.Status + " to " + input.Status})
+		return
+	}
+
+	now := time.Now()
+	fields := bson.M{"status": input.Status}
+	if tracking := strings.TrimSpace(input.TrackingNumber); tracking != "" {
+		shipment.TrackingNumber = tracking
+		fields["tracking_number"] = tracking
+		if !shipment.CarrierID.IsZero() {
+			if carrier, err := h.CarrierRepo.FindByID(ctx, shipment.CarrierID); err == nil {
+				shipment.TrackingURL = shipping.TrackingURL(carrier, tracking)
+				fields["tracking_url"] = shipment.TrackingURL
+			}
+		}
+	}
+	switch input.Status {
+	case models.ShipmentShipped:
+		if shipment.TrackingNumber == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tracking number is required to mark a shipment as shipped"})
+			return
+		}
+		shipment.ShippedAt = &now
+		fields["shipped_at"] = now
+	case models.ShipmentDelivered:
+		shipment.DeliveredAt = &now
+		fields["delivered_at"] = now
+	}
+	shipment.Status = input.Status
+
+	err = h.Events.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := h.ShipmentRepo.Update(ctx, shipment.ID, fields, role); err != nil {
+			return err
+		}
+		return h.syncOrderStatus(ctx, order, shipments, role)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update shipment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Shipment updated successfully",
+		"shipment": shipment,
+	})
+}
+
+func (h *ShippingHandle) loadOrder(ctx context.Context, c *gin.Context) (*models.Order, string, bool) {
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can manage shipments"})
+		return nil, "", false
+	}
+	role := roleVar.(string)
+
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return nil, "", false
+	}
+	order, err := h.OrderRepo.FindByID(ctx, orderID, role)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return nil, "", false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, "", false
+	}
+	return order, role, true
+}
+
+// syncOrderStatus เปลี่ยนสถานะออเดอร์เป็น Shipped เมื่อทุกชิ้นถูกส่งออกแล้ว และ Delivered เมื่อส่งถึงครบ
+func (h *ShippingHandle) syncOrderStatus(ctx context.Context, order *models.Order, shipments []models.Shipment, role string) error {
+	// เลื่อนสถานะเฉพาะออเดอร์ที่ชำระแล้วหรือกำลังจัดส่ง ออเดอร์ที่ยังไม่ชำระ ส่งถึงแล้ว คืนสินค้าหรือคืนเงินแล้วคงสถานะเดิม
+	switch strings.ToLower(order.Status) {
+	case "paid", "shipped":
+	default:
+		return nil
+	}
+
+	shipped := map[primitive.ObjectID]int{}
+	delivered := map[primitive.ObjectID]int{}
+	for _, shipment := range shipments {
+		for _, item := range shipment.Items {
+			switch shipment.Status {
+			case models.ShipmentDelivered:
+				delivered[item.ProductID] += item.Quantity
+				shipped[item.ProductID] += item.Quantity
+			case models.ShipmentShipped:
+				shipped[item.ProductID] += item.Quantity
+			}
+		}
+	}
+
+	status := "Delivered"
+	for productID, quantity := range orderedQuantities(order) {
+		if delivered[productID] < quantity {
+			status = "Shipped"
+		}
+		if shipped[productID] < quantity {
+			return nil
+		}
+	}
+	if status == order.Status {
+		return nil
+	}
+
+	if _, err := h.OrderRepo.Update(ctx, order.ID, bson.M{"status": status}, role); err != nil {
+		return err
+	}
+	from := order.Status
+	updated := *order
+	updated.Status = status
+	return recordStatusChanged(ctx, h.Events, from, &updated)
+}
+
+func (h *ShippingHandle) itemsWeight(ctx context.Context, items []models.ShipmentItem) int {
+	weight := 0
+	for _, item := range items {
+		if product, err := h.ProductRepo.FindByID(ctx, item.ProductID, true); err == nil {
+			weight += product.WeightGrams * item.Quantity
+		}
+	}
+	return weight
+}
+
+func orderedQuantities(order *models.Order) map[primitive.ObjectID]int {
+	quantities := map[primitive.ObjectID]int{}
+	for _, item := range order.Items {
+		quantities[item.ProductID] += item.Quantity
+	}
+	return quantities
+}
+
+// unshipped จำนวนที่ยังไม่อยู่ในพัสดุใด ไม่นับพัสดุที่ยกเลิก และไม่รวมจำนวนที่ยังรอสต็อก
+func unshipped(order *models.Order, shipments []models.Shipment) map[primitive.ObjectID]int {
+	remaining := orderedQuantities(order)
+	for _, item := range order.Items {
+		remaining[item.ProductID] -= item.Backordered
+	}
+	for _, shipment := range shipments {
+		if shipment.Status == models.ShipmentCancelled {
+			continue
+		}
+		for _, item := range shipment.Items {
+			remaining[item.ProductID] -= item.Quantity
+		}
+	}
+	return remaining
+}
+
+// shipmentItems เรียงตาม ObjectID ของสินค้าเพื่อให้ผลลัพธ์เหมือนเดิมทุกครั้ง
+func shipmentItems(quantities map[primitive.ObjectID]int) []models.ShipmentItem {
+	items := []models.ShipmentItem{}
+	for productID, quantity := range quantities {
+		if quantity > 0 {
+			items = append(items, models.ShipmentItem{ProductID: productID, Quantity: quantity})
+		}
+	}
+	sort.Slice(items, func(a, b int) bool {
+		return items[a].ProductID.Hex() < items[b].ProductID.Hex()
+	})
+	return items
+}
+
+func carrierRates(input CarrierRequest) ([]models.ShippingRate, error) {
+	var rates []models.ShippingRate
+	for _, r := range input.Rates {
+		rate := models.ShippingRate{
+			Service: strings.TrimSpace(r.Service),
+			Zone:    strings.TrimSpace(r.Zone),
+			Type:    r.Type,
+			Tiers:   []models.WeightTier{},
+		}
+		for _, province := range r.Provinces {
+			if province = strings.TrimSpace(province); province != "" {
+				rate.Provinces = append(rate.Provinces, province)
+			}
+		}
+		fee := r.Fee
+		if fee == "" {
+			fee = "0"
+		}
+		var err error
+		if rate.Fee, err = fee.Money(input.Currency); err != nil {
+			return nil, err
+		}
+		for _, t := range r.Tiers {
+			tierFee, err := t.Fee.Money(input.Currency)
+			if err != nil {
+				return nil, err
+			}
+			rate.Tiers = append(rate.Tiers, models.WeightTier{UpToGrams: t.UpToGrams, Fee: tierFee})
+		}
+		if r.FreeOver != "" {
+			freeOver, err := r.FreeOver.Money(input.Currency)
+			if err != nil {
+				return nil, err
+			}
+			rate.FreeOver = &freeOver
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
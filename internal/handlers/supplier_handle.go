@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SupplierRequest struct {
+	Name        string `json:"name" form:"name" binding:"required"`
+	ContactName string `json:"contact_name" form:"contact_name"`
+	Email       string `json:"email" form:"email" binding:"omitempty,email"`
+	Phone       string `json:"phone" form:"phone"`
+	Address     string `json:"address" form:"address"`
+	TaxID       string `json:"tax_id" form:"tax_id"`
+	IsActive    *bool  `json:"is_active" form:"is_active"`
+}
+
+type SupplierHandle struct {
+	SupplierRepo repositories.SupplierRepositoryInterface
+}
+
+func NewSupplierHandle(repo repositories.SupplierRepositoryInterface) *SupplierHandle {
+	return &SupplierHandle{SupplierRepo: repo}
+}
+
+func (h *SupplierHandle) GetSuppliers(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	role, _ := roleVar.(string)
+
+	suppliers, err := h.SupplierRepo.FindAll(ctx, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":     len(suppliers),
+		"suppliers": suppliers,
+	})
+}
+
+func (h *SupplierHandle) CreateSupplier(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can create suppliers"})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	createBy, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var input SupplierRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	supplier := models.Supplier{
+		Name:        input.Name,
+		ContactName: input.ContactName,
+		Email:       input.Email,
+		Phone:       input.Phone,
+		Address:     input.Address,
+		TaxID:       input.TaxID,
+		IsActive:    true,
+		CreatedBy:   createBy,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := h.SupplierRepo.Insert(ctx, &supplier, roleVar.(string)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create supplier"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Supplier created successfully", "supplier_id": supplier.ID.Hex()})
+}
+
+func (h *SupplierHandle) UpdateSupplier(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" && roleVar != "Staff" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff/admin can update suppliers"})
+		return
+	}
+
+	supplierID, err := primitive.ObjectIDFromHex(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid supplier ID"})
+		return
+	}
+
+	var input SupplierRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update := bson.M{
+		"name":         input.Name,
+		"contact_name": input.ContactName,
+		"email":        input.Email,
+		"phone":        input.Phone,
+		"address":      input.Address,
+		"tax_id":       input.TaxID,
+	}
+	if input.IsActive != nil {
+		update["is_active"] = *input.IsActive
+	}
+
+	result, err := h.SupplierRepo.Update(ctx, supplierID, update, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update supplier"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Supplier updated successfully"})
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/outbox"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/webhook"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type WebhookSubscriptionRequest struct {
+	URL         string   `json:"url" form:"url" binding:"required,url"`
+	Events      []string `json:"events" form:"events" binding:"required,min=1"`
+	Description string   `json:"description" form:"description"`
+	IsActive    *bool    `json:"is_active" form:"is_active"`
+}
+
+type WebhookHandle struct {
+	SubscriptionRepo repositories.WebhookSubscriptionRepositoryInterface
+	DeliveryRepo     repositories.WebhookDeliveryRepositoryInterface
+	Dispatcher       *webhook.Dispatcher
+}
+
+func NewWebhookHandle(subscriptionRepo repositories.WebhookSubscriptionRepositoryInterface, deliveryRepo repositories.WebhookDeliveryRepositoryInterface, dispatcher *webhook.Dispatcher) *WebhookHandle {
+	return &WebhookHandle{SubscriptionRepo: subscriptionRepo, DeliveryRepo: deliveryRepo, Dispatcher: dispatcher}
+}
+
+func (h *WebhookHandle) GetSubscriptions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can manage webhooks"})
+		return
+	}
+
+	subs, err := h.SubscriptionRepo.FindAll(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":         len(subs),
+		"subscriptions": subs,
+		"events":        webhook.Events,
+	})
+}
+
+func (h *WebhookHandle) CreateSubscription(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can manage webhooks"})
+		return
+	}
+
+	userIdVar, _ := c.Get("userId")
+	createBy, err := primitive.ObjectIDFromHex(userIdVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var input WebhookSubscriptionRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateSubscription(input); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+
+	sub := models.WebhookSubscription{
+		URL:         input.URL,
+		Events:      input.Events,
+		Secret:      secret,
+		Description: input.Description,
+		IsActive:    input.IsActive == nil || *input.IsActive,
+		CreatedBy:   createBy,
+		CreatedAt:   time.Now(),
+	}
+	if err := h.SubscriptionRepo.Insert(ctx, &sub, roleVar.(string)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+		return
+	}
+
+	// secret แสดงครั้งเดียวตอนสร้าง ผู้รับต้องเก็บไว้ตรวจลายเซ็นเอง
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Webhook subscription created successfully",
+		"subscription": sub,
+		"secret":       secret,
+	})
+}
+
+func (h *WebhookHandle) UpdateSubscription(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can manage webhooks"})
+		return
+	}
+
+	subID, err := primitive.ObjectIDFromHex(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	var input WebhookSubscriptionRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateSubscription(input); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	fields := bson.M{
+		"url":         input.URL,
+		"events":      input.Events,
+		"description": input.Description,
+	}
+	if input.IsActive != nil {
+		fields["is_active"] = *input.IsActive
+	}
+	if c.Query("rotate_secret") == "true" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+			return
+		}
+		fields["secret"] = secret
+	}
+
+	result, err := h.SubscriptionRepo.Update(ctx, subID, fields, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subscription"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return
+	}
+
+	response := gin.H{"message": "Webhook subscription updated successfully"}
+	if secret, ok := fields["secret"]; ok {
+		response["secret"] = secret
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *WebhookHandle) DeleteSubscription(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can manage webhooks"})
+		return
+	}
+
+	subID, err := primitive.ObjectIDFromHex(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	result, err := h.SubscriptionRepo.Delete(ctx, subID, roleVar.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete subscription"})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook subscription deleted successfully"})
+}
+
+func (h *WebhookHandle) TestSubscription(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can manage webhooks"})
+		return
+	}
+
+	subID, err := primitive.ObjectIDFromHex(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	sub, err := h.SubscriptionRepo.FindByID(ctx, subID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if err := h.Dispatcher.Ping(ctx, sub); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue test event"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Test event queued"})
+}
+
+func (h *WebhookHandle) GetDeliveries(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can manage webhooks"})
+		return
+	}
+
+	filter := bson.M{}
+	if subIDStr := c.Query("subscription_id"); subIDStr != "" {
+		subID, err := primitive.ObjectIDFromHex(subIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+			return
+		}
+		filter["subscription_id"] = subID
+	}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	if event := c.Query("event"); event != "" {
+		filter["event"] = event
+	}
+
+	deliveries, err := h.DeliveryRepo.FindAll(ctx, filter, 100)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":      len(deliveries),
+		"deliveries": deliveries,
+	})
+}
+
+// RetryDelivery ส่งรายการที่ล้มเหลวแล้วซ้ำอีกครั้งด้วย payload เดิม
+func (h *WebhookHandle) RetryDelivery(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	roleVar, _ := c.Get("role")
+	if roleVar != "Admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can manage webhooks"})
+		return
+	}
+
+	deliveryID, err := primitive.ObjectIDFromHex(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	delivery, err := h.DeliveryRepo.FindByID(ctx, deliveryID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if delivery.Status == models.WebhookDeliveryPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Delivery is already pending"})
+		return
+	}
+
+	if err := h.DeliveryRepo.Update(ctx, deliveryID, bson.M{
+		"status":          models.WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry delivery"})
+		return
+	}
+	h.Dispatcher.Wake()
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Delivery queued for retry"})
+}
+
+func validateSubscription(input WebhookSubscriptionRequest) string {
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return "URL must be http or https"
+	}
+	for _, event := range input.Events {
+		if !webhook.ValidEvent(event) {
+			return "Unknown event: " + event + " (available: " + strings.Join(webhook.Events, ", ") + ")"
+		}
+	}
+	return ""
+}
+
+func recordStatusChanged(ctx context.Context, events outbox.Recorder, from string, order *models.Order) error {
+	if order == nil || order.Status == from {
+		return nil
+	}
+	return events.Record(ctx, order.ID.Hex(), webhook.EventOrderStatusChanged, gin.H{
+		"order_id": order.ID.Hex(),
+		"from":     from,
+		"to":       order.Status,
+		"order":    order,
+	})
+}
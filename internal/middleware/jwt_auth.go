@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/stream"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
@@ -41,3 +42,24 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// StreamAuthMiddleware ใช้กับ SSE และ WebSocket ซึ่ง EventSource/WebSocket ของเบราว์เซอร์ตั้ง header เองไม่ได้
+// จึงรับตั๋วใช้ครั้งเดียวจาก query ticket ได้ด้วยเมื่อไม่มี Authorization header ไม่รับ JWT ทาง query เพราะ URL ถูกเขียนลง log
+func StreamAuthMiddleware(tickets *stream.Tickets) gin.HandlerFunc {
+	auth := AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.Request.Header.Get("Authorization") != "" {
+			auth(c)
+			return
+		}
+		ticket, ok := tickets.Redeem(c.Query("ticket"))
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Stream ticket missing, expired or already used"})
+			c.Abort()
+			return
+		}
+		c.Set("userId", ticket.UserID)
+		c.Set("role", ticket.Role)
+		c.Next()
+	}
+}
@@ -0,0 +1,88 @@
+package migrations
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrateMoney แปลงฟิลด์จำนวนเงินแบบ float เดิมเป็น {amount: หน่วยย่อย, currency}
+// รันซ้ำได้ เพราะเลือกเฉพาะเอกสารที่ยังเป็นตัวเลขอยู่
+func MigrateMoney(ctx context.Context, db *mongo.Database, currency string, exponent int) error {
+	scale := 1
+	for i := 0; i < exponent; i++ {
+		scale *= 10
+	}
+
+	toMoney := func(field any) bson.M {
+		return bson.M{
+			"amount":   bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{field, scale}}, 0}}},
+			"currency": currency,
+		}
+	}
+	convertItems := func(items string, field string) bson.M {
+		return bson.M{"$map": bson.M{
+			"input": bson.M{"$ifNull": bson.A{"$" + items, bson.A{}}},
+			"in": bson.M{"$mergeObjects": bson.A{"$$this", bson.M{
+				field: bson.M{"$cond": bson.A{
+					bson.M{"$isNumber": "$$this." + field},
+					toMoney("$$this." + field),
+					"$$this." + field,
+				}},
+			}}},
+		}}
+	}
+
+	steps := []struct {
+		collection string
+		filter     bson.M
+		set        bson.M
+	}{
+		{"products", bson.M{"price": bson.M{"$type": "number"}}, bson.M{"price": toMoney("$price")}},
+		{"orders", bson.M{"total_amount": bson.M{"$type": "number"}}, bson.M{
+			"total_amount": toMoney("$total_amount"),
+			"items":        convertItems("items", "unit_price"),
+		}},
+		// ออเดอร์เดิมไม่มีภาษีหรือส่วนลด ยอดก่อนภาษีจึงเท่ากับยอดรวม และออเดอร์ที่ผ่านสถานะรอชำระไปแล้วถือว่าชำระครบ
+		{"orders", bson.M{"paid_amount": bson.M{"$exists": false}, "total_amount.amount": bson.M{"$exists": true}}, bson.M{
+			"subtotal":  "$total_amount",
+			"tax_total": bson.M{"amount": int64(0), "currency": "$total_amount.currency"},
+			"paid_amount": bson.M{
+				"amount": bson.M{"$cond": bson.A{
+					bson.M{"$in": bson.A{"$status", bson.A{"pending", "Pending", "cancelled", "Cancelled"}}},
+					int64(0),
+					"$total_amount.amount",
+				}},
+				"currency": "$total_amount.currency",
+			},
+			"items": bson.M{"$map": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$items", bson.A{}}},
+				"in": bson.M{"$mergeObjects": bson.A{
+					bson.M{"line_total": bson.M{
+						"amount":   bson.M{"$multiply": bson.A{"$$this.unit_price.amount", "$$this.quantity"}},
+						"currency": "$$this.unit_price.currency",
+					}},
+					"$$this",
+				}},
+			}},
+		}},
+		{"purchase_orders", bson.M{"total_cost": bson.M{"$type": "number"}}, bson.M{
+			"total_cost": toMoney("$total_cost"),
+			"items":      convertItems("items", "unit_cost"),
+			"receipts":   convertItems("receipts", "unit_cost"),
+		}},
+	}
+
+	for _, step := range steps {
+		result, err := db.Collection(step.collection).UpdateMany(ctx, step.filter, mongo.Pipeline{{{Key: "$set", Value: step.set}}})
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount > 0 {
+			log.Printf("migrated money fields on %d %s", result.ModifiedCount, step.collection)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Address ที่อยู่แบบมีโครงสร้างตามรูปแบบไปรษณีย์ไทย ชื่อตำบล อำเภอ จังหวัด ตรวจกับชุดข้อมูลรหัสไปรษณีย์แล้ว
+type Address struct {
+	ID                primitive.ObjectID `bson:"_id" json:"id"`
+	Label             string             `bson:"label" json:"label"` // เช่น "บ้าน" "ที่ทำงาน"
+	Recipient         string             `bson:"recipient" json:"recipient"`
+	Phone             string             `bson:"phone" json:"phone"`
+	HouseNo           string             `bson:"house_no" json:"house_no"` // บ้านเลขที่ หมู่ อาคาร ซอย ถนน
+	Subdistrict       string             `bson:"subdistrict" json:"subdistrict"`
+	District          string             `bson:"district" json:"district"`
+	Province          string             `bson:"province" json:"province"`
+	Postcode          string             `bson:"postcode" json:"postcode"`
+	IsDefaultShipping bool               `bson:"is_default_shipping,omitempty" json:"is_default_shipping"`
+	IsDefaultBilling  bool               `bson:"is_default_billing,omitempty" json:"is_default_billing"`
+	Unverified        bool               `bson:"unverified,omitempty" json:"unverified,omitempty"` // รหัสไปรษณีย์ไม่อยู่ในชุดข้อมูล ใช้ตามที่กรอก
+}
+
+// String จัดรูปแบบที่อยู่บรรทัดเดียว กรุงเทพฯ ใช้ แขวง/เขต จังหวัดอื่นใช้ ต./อ./จ.
+func (a Address) String() string {
+	parts := []string{a.HouseNo}
+	if a.Province == "กรุงเทพมหานคร" {
+		parts = append(parts, "แขวง"+a.Subdistrict, "เขต"+a.District, a.Province)
+	} else {
+		parts = append(parts, "ต."+a.Subdistrict, "อ."+a.District, "จ."+a.Province)
+	}
+	parts = append(parts, a.Postcode)
+	return strings.Join(parts, " ")
+}
+
+// Snapshot คือสำเนาที่อยู่สำหรับเก็บไว้กับออเดอร์ ไม่เปลี่ยนตามสมุดที่อยู่ของลูกค้า
+func (a Address) Snapshot() *Address {
+	a.IsDefaultShipping = false
+	a.IsDefaultBilling = false
+	return &a
+}
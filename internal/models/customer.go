@@ -7,10 +7,44 @@ import (
 )
 
 type Customer struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	FullName  string             `bson:"full_name"`
-	Email     string             `bson:"email"`
-	Phone     string             `bson:"phone"`
-	Address   string             `bson:"address"`
-	CreatedAt time.Time          `bson:"created_at"`
+	ID         primitive.ObjectID  `bson:"_id,omitempty"`
+	FullName   string              `bson:"full_name"`
+	Email      string              `bson:"email"`
+	Phone      string              `bson:"phone"`
+	Address    string              `bson:"address"` // ที่อยู่แบบข้อความเดิม ใช้เมื่อยังไม่มีสมุดที่อยู่
+	Addresses  []Address           `bson:"addresses,omitempty"`
+	EmailKey   string              `bson:"email_key" json:"-"` // อีเมลที่ normalise แล้ว ใช้จับคู่ลูกค้าซ้ำ
+	PhoneKey   string              `bson:"phone_key" json:"-"`
+	MergedInto *primitive.ObjectID `bson:"merged_into,omitempty"` // ลูกค้าหลักที่รายนี้ถูกรวมเข้าไปแล้ว
+	MergedAt   *time.Time          `bson:"merged_at,omitempty"`
+	CreatedAt  time.Time           `bson:"created_at"`
+	UpdatedAt  time.Time           `bson:"updated_at,omitempty"`
+}
+
+// DefaultShippingAddress คืนที่อยู่จัดส่งหลัก หรือ nil ถ้าไม่ได้ตั้งไว้
+func (c Customer) DefaultShippingAddress() *Address {
+	for i := range c.Addresses {
+		if c.Addresses[i].IsDefaultShipping {
+			return &c.Addresses[i]
+		}
+	}
+	return nil
+}
+
+func (c Customer) DefaultBillingAddress() *Address {
+	for i := range c.Addresses {
+		if c.Addresses[i].IsDefaultBilling {
+			return &c.Addresses[i]
+		}
+	}
+	return nil
+}
+
+func (c Customer) FindAddress(id primitive.ObjectID) *Address {
+	for i := range c.Addresses {
+		if c.Addresses[i].ID == id {
+			return &c.Addresses[i]
+		}
+	}
+	return nil
 }
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DocumentTaxInvoice = "tax_invoice"
+	DocumentReceipt    = "receipt"
+	DocumentQuotation  = "quotation"
+)
+
+// Document เก็บไฟล์ PDF ที่ออกไปแล้ว เพื่อให้ดาวน์โหลดซ้ำได้ไฟล์เดิมทุกครั้ง
+type Document struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	OrderID   primitive.ObjectID `bson:"order_id"`
+	Type      string             `bson:"type"`
+	Number    string             `bson:"number"`
+	Content   []byte             `bson:"content"`
+	Checksum  string             `bson:"checksum"` // sha256 ของ Content
+	CreatedBy primitive.ObjectID `bson:"created_by"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Location struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Code      string             `bson:"code"`
+	Name      string             `bson:"name"`
+	Type      string             `bson:"type"` // "shop", "warehouse"
+	IsDefault bool               `bson:"is_default"`
+	IsActive  bool               `bson:"is_active"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+type LocationStock struct {
+	LocationID primitive.ObjectID `bson:"location_id"`
+	Quantity   int                `bson:"quantity"`
+}
+
+type StockTransfer struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	ProductID      primitive.ObjectID `bson:"product_id"`
+	FromLocationID primitive.ObjectID `bson:"from_location_id"`
+	ToLocationID   primitive.ObjectID `bson:"to_location_id"`
+	Quantity       int                `bson:"quantity"`
+	Note           string             `bson:"note"`
+	CreatedBy      primitive.ObjectID `bson:"created_by"`
+	CreatedAt      time.Time          `bson:"created_at"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Money เก็บจำนวนเงินเป็นหน่วยย่อยที่สุดของสกุลเงิน (เช่น สตางค์) เพื่อไม่ให้เกิดปัดเศษแบบ float
+// ใน JSON จะส่งเป็นทศนิยมแบบข้อความ เช่น {"amount":"123.45","currency":"THB"} ให้ตรงกับรูปแบบที่ request รับ
+type Money struct {
+	Amount   int64  `bson:"amount"`
+	Currency string `bson:"currency"`
+}
+
+// จำนวนหลักทศนิยมของแต่ละสกุลเงินตาม ISO 4217
+var currencyExponents = map[string]int{
+	"THB": 2,
+	"USD": 2,
+	"EUR": 2,
+	"SGD": 2,
+	"MYR": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+}
+
+// CurrencyExponent คืนจำนวนหลักทศนิยมของสกุลเงิน ok เป็น false ถ้าไม่รู้จักสกุลเงินนี้
+func CurrencyExponent(currency string) (exponent int, ok bool) {
+	exponent, ok = currencyExponents[strings.ToUpper(currency)]
+	return exponent, ok
+}
+
+var ErrCurrencyMismatch = fmt.Errorf("cannot combine amounts in different currencies")
+
+// Add และ Sub ถือว่าค่าที่ยังไม่มีสกุลเงินเป็นศูนย์ของสกุลอีกฝั่ง ถ้าสกุลเงินต่างกันจะคืน ErrCurrencyMismatch
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.currencyWith(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	currency, err := m.currencyWith(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}, nil
+}
+
+func (m Money) currencyWith(other Money) (string, error) {
+	switch {
+	case other.Currency == "" || other.Currency == m.Currency:
+		return m.Currency, nil
+	case m.Currency == "":
+		return other.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+}
+
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Decimal จัดรูปแบบจำนวนเงินเป็นทศนิยมตามสกุลเงิน เช่น 12345 THB เป็น "123.45" สกุลที่ไม่รู้จักใช้ 2 หลัก
+func (m Money) Decimal() string {
+	exp, ok := CurrencyExponent(m.Currency)
+	if !ok {
+		exp = 2
+	}
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if exp == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+	scale := int64(1)
+	for i := 0; i < exp; i++ {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, exp, amount%scale)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.Decimal(), m.Currency})
+}
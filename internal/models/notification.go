@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Notification struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Type      string             `bson:"type"`
+	Subject   string             `bson:"subject"`
+	Message   string             `bson:"message"`
+	Data      map[string]any     `bson:"data"`
+	Read      bool               `bson:"read"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
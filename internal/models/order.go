@@ -7,19 +7,70 @@ import (
 )
 
 type OrderItem struct {
-	ProductID primitive.ObjectID `bson:"product_id"`
-	Quantity  int                `bson:"quantity"`
-	UnitPrice float64            `bson:"unit_price"`
+	ProductID   primitive.ObjectID `bson:"product_id"`
+	Quantity    int                `bson:"quantity"`
+	UnitPrice   Money              `bson:"unit_price"`
+	Discount    Money              `bson:"discount"`
+	TaxClass    string             `bson:"tax_class"`
+	TaxRate     int64              `bson:"tax_rate"` // basis points, 700 = 7%
+	NetAmount   Money              `bson:"net_amount"`
+	TaxAmount   Money              `bson:"tax_amount"`
+	LineTotal   Money              `bson:"line_total"`
+	LocationID  primitive.ObjectID `bson:"location_id"`
+	Returned    int                `bson:"returned"`    // จำนวนที่รับคืนแล้ว
+	Backordered int                `bson:"backordered"` // จำนวนที่ยังไม่ได้ตัดสต็อก รอจัดสรรเมื่อสต็อกเข้า
+}
+
+// Reserved คือจำนวนที่ตัดสต็อกจากคลัง LocationID ไปแล้ว
+func (i OrderItem) Reserved() int {
+	return i.Quantity - i.Backordered
 }
 
 type Order struct {
-	ID              primitive.ObjectID `bson:"_id,omitempty"`
-	CustomerID      primitive.ObjectID `bson:"customer_id"`
-	CreatedBy       primitive.ObjectID `bson:"created_by"`
-	Tracking_number string             `bson:"tracking_number"`
-	Note            string             `bson:"note"`
-	Status          string             `bson:"status"` // "pending", "paid", "shipped"
-	TotalAmount     float64            `bson:"total_amount"`
-	Items           []OrderItem        `bson:"items"`
-	CreatedAt       time.Time          `bson:"created_at"`
+	ID               primitive.ObjectID  `bson:"_id,omitempty"`
+	CustomerID       primitive.ObjectID  `bson:"customer_id"`
+	CreatedBy        primitive.ObjectID  `bson:"created_by"`
+	Tracking_number  string              `bson:"tracking_number"`
+	Note             string              `bson:"note"`
+	Status           string              `bson:"status"` // "pending", "paid", "shipped"
+	PricesIncludeTax bool                `bson:"prices_include_tax"`
+	Subtotal         Money               `bson:"subtotal"` // after discounts, before tax
+	Discounts        []AppliedPromotion  `bson:"discounts"`
+	DiscountTotal    Money               `bson:"discount_total"`
+	TaxTotal         Money               `bson:"tax_total"`
+	ShippingTotal    Money               `bson:"shipping_total"` // ก่อนภาษี ภาษีของค่าส่งรวมอยู่ใน TaxTotal
+	TotalAmount      Money               `bson:"total_amount"`   // grand total
+	PaidAmount       Money               `bson:"paid_amount"`
+	PaidAt           *time.Time          `bson:"paid_at,omitempty"` // เวลาที่ชำระครบ
+	RefundedAmount   Money               `bson:"refunded_amount"`
+	Items            []OrderItem         `bson:"items"`
+	ShippingAddress  *Address            `bson:"shipping_address,omitempty"` // สำเนา ณ ตอนสั่งซื้อ
+	BillingAddress   *Address            `bson:"billing_address,omitempty"`
+	Shipping         *ShippingMethod     `bson:"shipping,omitempty"`
+	History          []OrderRevision     `bson:"history,omitempty"` // การแก้ไขรายการสินค้าหลังสร้างออเดอร์
+	QuotationID      *primitive.ObjectID `bson:"quotation_id,omitempty"`
+	CreatedAt        time.Time           `bson:"created_at"`
+}
+
+// OrderItemChange คือจำนวนของสินค้าหนึ่งรายการก่อนและหลังแก้ไข From เป็น 0 คือเพิ่มใหม่ To เป็น 0 คือลบออก
+type OrderItemChange struct {
+	ProductID primitive.ObjectID `bson:"product_id"`
+	From      int                `bson:"from"`
+	To        int                `bson:"to"`
+}
+
+// OrderRevision บันทึกการแก้ไขรายการสินค้าของออเดอร์หนึ่งครั้ง
+type OrderRevision struct {
+	Changes       []OrderItemChange  `bson:"changes"`
+	Repriced      bool               `bson:"repriced"` // คิดราคาใหม่ทั้งออเดอร์จากราคาปัจจุบัน
+	PreviousTotal Money              `bson:"previous_total"`
+	NewTotal      Money              `bson:"new_total"`
+	Reason        string             `bson:"reason"`
+	ChangedBy     primitive.ObjectID `bson:"changed_by"`
+	ChangedAt     time.Time          `bson:"changed_at"`
+}
+
+// Outstanding คือยอดที่ยังค้างชำระ
+func (o Order) Outstanding() Money {
+	return Money{Amount: o.TotalAmount.Amount - o.PaidAmount.Amount, Currency: o.TotalAmount.Currency}
 }
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	OutboxPending   = "Pending"
+	OutboxPublished = "Published"
+	OutboxFailed    = "Failed"
+)
+
+// OutboxEvent คือ event ที่บันทึกพร้อมกับการเปลี่ยนแปลงข้อมูล แล้วค่อยส่งออกไปยัง sink ภายหลัง
+// event ของ aggregate เดียวกัน (เช่นออเดอร์เดียวกัน) จะถูกส่งตามลำดับ _id เสมอ
+type OutboxEvent struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	AggregateType string             `bson:"aggregate_type"` // "order", "product"
+	AggregateID   string             `bson:"aggregate_id"`
+	Event         string             `bson:"event"`
+	Payload       string             `bson:"payload"`   // JSON ของข้อมูล event
+	Status        string             `bson:"status"`    // "Pending", "Published", "Failed"
+	Delivered     []string           `bson:"delivered"` // sink ที่ส่งสำเร็จแล้ว รอบถัดไปจะไม่ส่งซ้ำ
+	Attempts      int                `bson:"attempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at"`
+	LastError     string             `bson:"last_error"`
+	CreatedAt     time.Time          `bson:"created_at"`
+	PublishedAt   *time.Time         `bson:"published_at,omitempty"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PaymentCash         = "cash"
+	PaymentBankTransfer = "bank_transfer"
+	PaymentPromptPay    = "promptpay"
+	PaymentCard         = "card"
+)
+
+type Payment struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	OrderID    primitive.ObjectID `bson:"order_id"`
+	Amount     Money              `bson:"amount"`
+	Refunded   Money              `bson:"refunded"`
+	Method     string             `bson:"method"`
+	Reference  string             `bson:"reference"`
+	Provider   string             `bson:"provider,omitempty"` // gateway ที่แจ้งผลผ่าน webhook
+	PaidAt     time.Time          `bson:"paid_at"`
+	RecordedBy primitive.ObjectID `bson:"recorded_by"`
+	CreatedAt  time.Time          `bson:"created_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PaymentEventProcessing = "Processing"
+	PaymentEventProcessed  = "Processed"
+	PaymentEventIgnored    = "Ignored"
+	PaymentEventRejected   = "Rejected"
+)
+
+// PaymentEvent บันทึก webhook ที่รับมาแล้ว ใช้กันการประมวลผลซ้ำเมื่อ gateway ส่ง event เดิมมาอีก
+type PaymentEvent struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty"`
+	Provider   string              `bson:"provider"`
+	EventID    string              `bson:"event_id"`
+	Type       string              `bson:"type"`
+	OrderID    string              `bson:"order_id"`
+	PaymentID  *primitive.ObjectID `bson:"payment_id,omitempty"`
+	Status     string              `bson:"status"`
+	Error      string              `bson:"error,omitempty"`
+	Payload    string              `bson:"payload"`
+	ReceivedAt time.Time           `bson:"received_at"`
+}
@@ -7,12 +7,40 @@ import (
 )
 
 type Product struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	CreatedBy primitive.ObjectID `bson:"created_by"`
-	Name      string             `bson:"name"`
-	SKU       string             `bson:"sku"`
-	Price     float64            `bson:"price"`
-	Stock     int                `bson:"stock"`
-	IsActive  bool               `bson:"is_active"`
-	CreatedAt time.Time          `bson:"created_at"`
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	CreatedBy       primitive.ObjectID `bson:"created_by"`
+	Name            string             `bson:"name"`
+	SKU             string             `bson:"sku"`
+	Description     string             `bson:"description"`
+	Tags            []string           `bson:"tags"`
+	Price           Money              `bson:"price"`
+	TaxClass        string             `bson:"tax_class"` // "standard", "zero", "exempt"
+	Stock           int                `bson:"stock"`     // total across all locations
+	Locations       []LocationStock    `bson:"locations"`
+	ReorderPoint    int                `bson:"reorder_point"` // 0 = no low-stock alert
+	ReorderQuantity int                `bson:"reorder_quantity"`
+	WeightGrams     int                `bson:"weight_grams"`    // น้ำหนักต่อชิ้นรวมบรรจุภัณฑ์ ใช้คิดค่าส่ง
+	AllowBackorder  bool               `bson:"allow_backorder"` // รับสั่งได้แม้สต็อกไม่พอ ส่วนที่ขาดรอจัดสรรเมื่อสต็อกเข้า
+	IsActive        bool               `bson:"is_active"`
+	CreatedAt       time.Time          `bson:"created_at"`
+}
+
+func (p *Product) StockAt(locationID primitive.ObjectID) int {
+	for _, location := range p.Locations {
+		if location.LocationID == locationID {
+			return location.Quantity
+		}
+	}
+	return 0
+}
+
+// BestLocation คือคลังที่มีสต็อกมากที่สุด คืน NilObjectID ถ้าไม่มีคลังใดมีของ
+func (p *Product) BestLocation() (primitive.ObjectID, int) {
+	best, bestQty := primitive.NilObjectID, 0
+	for _, location := range p.Locations {
+		if location.Quantity > bestQty {
+			best, bestQty = location.LocationID, location.Quantity
+		}
+	}
+	return best, bestQty
 }
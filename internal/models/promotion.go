@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PromotionPercentage = "percentage"
+	PromotionFixed      = "fixed"
+	PromotionBuyXGetY   = "buy_x_get_y"
+)
+
+type Promotion struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty"`
+	Name        string               `bson:"name"`
+	Code        string               `bson:"code,omitempty"` // empty = applied automatically
+	Type        string               `bson:"type"`           // "percentage", "fixed", "buy_x_get_y"
+	Percent     int64                `bson:"percent"`        // basis points, 1000 = 10%
+	Amount      Money                `bson:"amount"`
+	BuyQuantity int                  `bson:"buy_quantity"`
+	GetQuantity int                  `bson:"get_quantity"`
+	ProductIDs  []primitive.ObjectID `bson:"product_ids"` // empty = every product
+	MinSpend    Money                `bson:"min_spend"`
+	UsageLimit  int                  `bson:"usage_limit"` // 0 = unlimited
+	UsageCount  int                  `bson:"usage_count"`
+	StartsAt    *time.Time           `bson:"starts_at,omitempty"`
+	ExpiresAt   *time.Time           `bson:"expires_at,omitempty"`
+	IsActive    bool                 `bson:"is_active"`
+	CreatedBy   primitive.ObjectID   `bson:"created_by"`
+	CreatedAt   time.Time            `bson:"created_at"`
+}
+
+type AppliedPromotion struct {
+	PromotionID primitive.ObjectID `bson:"promotion_id"`
+	Name        string             `bson:"name"`
+	Code        string             `bson:"code,omitempty"`
+	Type        string             `bson:"type"`
+	Amount      Money              `bson:"amount"`
+}
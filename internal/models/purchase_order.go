@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PurchaseOrderDraft             = "Draft"
+	PurchaseOrderOrdered           = "Ordered"
+	PurchaseOrderPartiallyReceived = "PartiallyReceived"
+	PurchaseOrderReceived          = "Received"
+	PurchaseOrderCancelled         = "Cancelled"
+)
+
+type PurchaseOrderItem struct {
+	ProductID        primitive.ObjectID `bson:"product_id"`
+	SKU              string             `bson:"sku"`
+	ProductName      string             `bson:"product_name"`
+	QuantityOrdered  int                `bson:"quantity_ordered"`
+	QuantityReceived int                `bson:"quantity_received"`
+	UnitCost         Money              `bson:"unit_cost"`
+}
+
+type GoodsReceipt struct {
+	ProductID  primitive.ObjectID `bson:"product_id"`
+	Quantity   int                `bson:"quantity"`
+	UnitCost   Money              `bson:"unit_cost"`
+	LocationID primitive.ObjectID `bson:"location_id"`
+	ReceivedBy primitive.ObjectID `bson:"received_by"`
+	ReceivedAt time.Time          `bson:"received_at"`
+	Note       string             `bson:"note"`
+}
+
+type PurchaseOrder struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty"`
+	Number     string              `bson:"number"`
+	SupplierID primitive.ObjectID  `bson:"supplier_id"`
+	Status     string              `bson:"status"` // "Draft", "Ordered", "PartiallyReceived", "Received", "Cancelled"
+	Items      []PurchaseOrderItem `bson:"items"`
+	Receipts   []GoodsReceipt      `bson:"receipts"`
+	TotalCost  Money               `bson:"total_cost"`
+	Note       string              `bson:"note"`
+	CreatedBy  primitive.ObjectID  `bson:"created_by"`
+	CreatedAt  time.Time           `bson:"created_at"`
+	OrderedAt  *time.Time          `bson:"ordered_at,omitempty"`
+	ReceivedAt *time.Time          `bson:"received_at,omitempty"`
+}
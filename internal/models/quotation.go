@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	QuotationDraft     = "Draft"
+	QuotationSent      = "Sent"
+	QuotationConverted = "Converted"
+	QuotationCancelled = "Cancelled"
+)
+
+// Quotation ใบเสนอราคา รายการสินค้าเหมือนออเดอร์แต่ไม่ตัดสต็อก ราคาต่อหน่วยและค่าส่งยืนตามนี้จนหมดอายุ
+type Quotation struct {
+	ID               primitive.ObjectID  `bson:"_id,omitempty"`
+	Number           string              `bson:"number"`
+	CustomerID       primitive.ObjectID  `bson:"customer_id"`
+	CreatedBy        primitive.ObjectID  `bson:"created_by"`
+	Status           string              `bson:"status"` // "Draft", "Sent", "Converted", "Cancelled"
+	PricesIncludeTax bool                `bson:"prices_include_tax"`
+	Subtotal         Money               `bson:"subtotal"`
+	Discounts        []AppliedPromotion  `bson:"discounts"`
+	DiscountTotal    Money               `bson:"discount_total"`
+	TaxTotal         Money               `bson:"tax_total"`
+	ShippingTotal    Money               `bson:"shipping_total"`
+	TotalAmount      Money               `bson:"total_amount"`
+	Items            []OrderItem         `bson:"items"`
+	LocationID       primitive.ObjectID  `bson:"location_id"` // คลังที่ใช้ตัดสต็อกตอนแปลงเป็นออเดอร์ ว่างคือเลือกให้อัตโนมัติ
+	CouponCode       string              `bson:"coupon_code"`
+	ShippingAddress  *Address            `bson:"shipping_address,omitempty"`
+	BillingAddress   *Address            `bson:"billing_address,omitempty"`
+	Shipping         *ShippingMethod     `bson:"shipping,omitempty"`
+	Note             string              `bson:"note"`
+	ExpiresAt        time.Time           `bson:"expires_at"`
+	OrderID          *primitive.ObjectID `bson:"order_id,omitempty"` // ออเดอร์ที่แปลงมาจากใบนี้
+	ConvertedAt      *time.Time          `bson:"converted_at,omitempty"`
+	CreatedAt        time.Time           `bson:"created_at"`
+	UpdatedAt        time.Time           `bson:"updated_at,omitempty"`
+}
+
+func (q Quotation) Expired(now time.Time) bool {
+	return now.After(q.ExpiresAt)
+}
+
+// UnitPrice คืนราคาต่อหน่วยที่เสนอไว้ของสินค้า
+func (q Quotation) UnitPrice(productID primitive.ObjectID) (Money, bool) {
+	for _, item := range q.Items {
+		if item.ProductID == productID {
+			return item.UnitPrice, true
+		}
+	}
+	return Money{}, false
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ยอดในรายงานเป็นหน่วยย่อยของสกุลเงิน (สตางค์) เหมือน Money.Amount ทุกแถวในรายงานหนึ่งเป็นสกุลเงินเดียวกัน
+
+type SalesTotals struct {
+	Orders            int   `bson:"orders" json:"orders"`
+	Revenue           int64 `bson:"revenue" json:"revenue"`
+	Tax               int64 `bson:"tax" json:"tax"`
+	Discount          int64 `bson:"discount" json:"discount"`
+	Refunded          int64 `bson:"refunded" json:"refunded"`
+	NetRevenue        int64 `bson:"-" json:"net_revenue"`
+	AverageOrderValue int64 `bson:"-" json:"average_order_value"`
+}
+
+// Finish คำนวณค่าที่ได้จากยอดรวม หลังจากอ่านผล aggregation มาแล้ว
+func (t *SalesTotals) Finish() {
+	t.NetRevenue = t.Revenue - t.Refunded
+	t.AverageOrderValue = 0
+	if t.Orders > 0 {
+		t.AverageOrderValue = (t.Revenue + int64(t.Orders)/2) / int64(t.Orders)
+	}
+}
+
+type SalesPeriod struct {
+	Period      string `bson:"_id" json:"period"` // "2026-01-31", "2026-W05" หรือ "2026-01"
+	SalesTotals `bson:",inline"`
+}
+
+type ProductSales struct {
+	ProductID primitive.ObjectID `bson:"_id" json:"product_id"`
+	Name      string             `bson:"name" json:"name"`
+	SKU       string             `bson:"sku" json:"sku"`
+	Quantity  int                `bson:"quantity" json:"quantity"`
+	Returned  int                `bson:"returned" json:"returned"`
+	Revenue   int64              `bson:"revenue" json:"revenue"`
+	Orders    int                `bson:"orders" json:"orders"`
+}
+
+type StaffSales struct {
+	UserID      primitive.ObjectID `bson:"_id" json:"user_id"`
+	Username    string             `bson:"username" json:"username"`
+	Email       string             `bson:"email" json:"email"`
+	SalesTotals `bson:",inline"`
+}
+
+type StatusCount struct {
+	Status  string `bson:"_id" json:"status"`
+	Orders  int    `bson:"orders" json:"orders"`
+	Revenue int64  `bson:"revenue" json:"revenue"`
+}
+
+type CustomerStats struct {
+	CustomerID    primitive.ObjectID `bson:"_id" json:"customer_id"`
+	FullName      string             `bson:"full_name" json:"full_name"`
+	Email         string             `bson:"email" json:"email"`
+	Phone         string             `bson:"phone" json:"phone"`
+	Orders        int                `bson:"orders" json:"orders"`
+	Revenue       int64              `bson:"revenue" json:"revenue"`
+	Refunded      int64              `bson:"refunded" json:"refunded"`
+	LifetimeValue int64              `bson:"lifetime_value" json:"lifetime_value"`
+	FirstOrderAt  time.Time          `bson:"first_order_at" json:"first_order_at"`
+	LastOrderAt   time.Time          `bson:"last_order_at" json:"last_order_at"`
+}
+
+// CustomerRFM คะแนน 1-5 ของ recency, frequency และ monetary เทียบกับลูกค้าคนอื่นในรายงานเดียวกัน
+type CustomerRFM struct {
+	CustomerStats
+	DaysSinceLastOrder int    `json:"days_since_last_order"`
+	Recency            int    `json:"recency_score"`
+	Frequency          int    `json:"frequency_score"`
+	Monetary           int    `json:"monetary_score"`
+	Score              string `json:"rfm"` // เช่น "545"
+	Segment            string `json:"segment"`
+}
+
+// BackorderSummary คือจำนวนที่ออเดอร์ยังรอสต็อกของสินค้าหนึ่งรายการ Waiting เรียงตามลำดับที่จะได้รับสต็อก
+type BackorderSummary struct {
+	ProductID     primitive.ObjectID `bson:"_id" json:"product_id"`
+	Name          string             `bson:"name" json:"name"`
+	SKU           string             `bson:"sku" json:"sku"`
+	Backordered   int                `bson:"backordered" json:"backordered"`
+	Orders        int                `bson:"orders" json:"orders"`
+	Stock         int                `bson:"stock" json:"stock"` // สต็อกที่ยังไม่ได้จัดสรร
+	OldestOrderAt time.Time          `bson:"oldest_order_at" json:"oldest_order_at"`
+	Waiting       []BackorderLine    `bson:"waiting" json:"waiting"`
+}
+
+type BackorderLine struct {
+	OrderID     primitive.ObjectID `bson:"order_id" json:"order_id"`
+	CustomerID  primitive.ObjectID `bson:"customer_id" json:"customer_id"`
+	Status      string             `bson:"status" json:"status"`
+	Quantity    int                `bson:"quantity" json:"quantity"`
+	Backordered int                `bson:"backordered" json:"backordered"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
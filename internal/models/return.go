@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ReturnRequested = "Requested"
+	ReturnApproved  = "Approved"
+	ReturnRejected  = "Rejected"
+	ReturnReceived  = "Received"
+	ReturnRefunded  = "Refunded"
+)
+
+type ReturnItem struct {
+	ProductID    primitive.ObjectID `bson:"product_id"`
+	Quantity     int                `bson:"quantity"`
+	Reason       string             `bson:"reason"`
+	Resellable   bool               `bson:"resellable"` // กำหนดตอนรับของคืน เฉพาะของที่ขายต่อได้จึงคืนเข้าสต็อก
+	RefundAmount Money              `bson:"refund_amount"`
+}
+
+// Return คือคำขอคืนสินค้า (RMA) ของออเดอร์หนึ่ง คืนได้บางรายการและบางจำนวน
+type Return struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	Number         string             `bson:"number"`
+	OrderID        primitive.ObjectID `bson:"order_id"`
+	Status         string             `bson:"status"` // "Requested", "Approved", "Rejected", "Received", "Refunded"
+	Items          []ReturnItem       `bson:"items"`
+	Reason         string             `bson:"reason"`
+	Note           string             `bson:"note"`
+	RefundTotal    Money              `bson:"refund_total"` // ยอดที่คืนได้สูงสุดตามราคาที่ขายไป
+	RefundedAmount Money              `bson:"refunded_amount"`
+	CreatedBy      primitive.ObjectID `bson:"created_by"`
+	CreatedAt      time.Time          `bson:"created_at"`
+	DecidedBy      primitive.ObjectID `bson:"decided_by,omitempty"`
+	DecidedAt      *time.Time         `bson:"decided_at,omitempty"`
+	ReceivedAt     *time.Time         `bson:"received_at,omitempty"`
+	RefundedAt     *time.Time         `bson:"refunded_at,omitempty"`
+}
+
+// Refund คือเงินที่คืนจากรายการชำระหนึ่ง คืนจากหลายรายการชำระจะได้หลาย Refund
+type Refund struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty"`
+	OrderID   primitive.ObjectID  `bson:"order_id"`
+	ReturnID  *primitive.ObjectID `bson:"return_id,omitempty"`
+	PaymentID primitive.ObjectID  `bson:"payment_id"`
+	Amount    Money               `bson:"amount"`
+	Method    string              `bson:"method"`
+	Reference string              `bson:"reference"`
+	Reason    string              `bson:"reason"`
+	CreatedBy primitive.ObjectID  `bson:"created_by"`
+	CreatedAt time.Time           `bson:"created_at"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RateFlat   = "flat"
+	RateWeight = "weight"
+)
+
+const (
+	ShipmentPending   = "Pending"
+	ShipmentShipped   = "Shipped"
+	ShipmentDelivered = "Delivered"
+	ShipmentCancelled = "Cancelled"
+)
+
+type Carrier struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Code        string             `bson:"code"` // เช่น "thaipost", "kerry"
+	Name        string             `bson:"name"`
+	TrackingURL string             `bson:"tracking_url"` // {tracking} ถูกแทนด้วยเลขพัสดุ
+	Rates       []ShippingRate     `bson:"rates"`
+	IsActive    bool               `bson:"is_active"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at,omitempty"`
+}
+
+// ShippingRate ค่าส่งของบริการหนึ่งในโซนหนึ่ง Provinces ว่างหมายถึงทุกจังหวัดที่ไม่มีโซนเฉพาะ
+type ShippingRate struct {
+	Service   string       `bson:"service"` // เช่น "standard", "express"
+	Zone      string       `bson:"zone"`
+	Provinces []string     `bson:"provinces"`
+	Type      string       `bson:"type"` // "flat", "weight"
+	Fee       Money        `bson:"fee"`  // ค่าส่งของแบบ flat
+	Tiers     []WeightTier `bson:"tiers"`
+	FreeOver  *Money       `bson:"free_over,omitempty"` // ยอดสั่งซื้อตั้งแต่เท่านี้ส่งฟรี
+}
+
+// WeightTier ค่าส่งสำหรับพัสดุที่หนักไม่เกิน UpToGrams เรียงจากเบาไปหนัก
+type WeightTier struct {
+	UpToGrams int   `bson:"up_to_grams"`
+	Fee       Money `bson:"fee"`
+}
+
+// ShippingMethod คือวิธีส่งที่เลือกและค่าส่งที่คิดไว้ตอนสั่งซื้อ
+type ShippingMethod struct {
+	CarrierID   primitive.ObjectID `bson:"carrier_id"`
+	CarrierCode string             `bson:"carrier_code"`
+	CarrierName string             `bson:"carrier_name"`
+	Service     string             `bson:"service"`
+	Zone        string             `bson:"zone"`
+	WeightGrams int                `bson:"weight_grams"`
+	Fee         Money              `bson:"fee"`
+	Free        bool               `bson:"free"` // ได้ส่งฟรีเพราะยอดถึงเกณฑ์
+}
+
+type ShipmentItem struct {
+	ProductID primitive.ObjectID `bson:"product_id"`
+	Quantity  int                `bson:"quantity"`
+}
+
+// Shipment คือพัสดุหนึ่งกล่อง ออเดอร์หนึ่งแบ่งส่งได้หลายกล่อง
+type Shipment struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	OrderID        primitive.ObjectID `bson:"order_id"`
+	CarrierID      primitive.ObjectID `bson:"carrier_id"`
+	CarrierCode    string             `bson:"carrier_code"`
+	Service        string             `bson:"service"`
+	TrackingNumber string             `bson:"tracking_number"`
+	TrackingURL    string             `bson:"tracking_url"`
+	Status         string             `bson:"status"` // "Pending", "Shipped", "Delivered", "Cancelled"
+	Items          []ShipmentItem     `bson:"items"`
+	WeightGrams    int                `bson:"weight_grams"`
+	Note           string             `bson:"note"`
+	CreatedBy      primitive.ObjectID `bson:"created_by"`
+	CreatedAt      time.Time          `bson:"created_at"`
+	ShippedAt      *time.Time         `bson:"shipped_at,omitempty"`
+	DeliveredAt    *time.Time         `bson:"delivered_at,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type StockAlert struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	ProductID       primitive.ObjectID `bson:"product_id"`
+	SKU             string             `bson:"sku"`
+	ProductName     string             `bson:"product_name"`
+	Stock           int                `bson:"stock"`
+	ReorderPoint    int                `bson:"reorder_point"`
+	ReorderQuantity int                `bson:"reorder_quantity"`
+	Status          string             `bson:"status"` // "Open", "Resolved"
+	CreatedAt       time.Time          `bson:"created_at"`
+	ResolvedAt      *time.Time         `bson:"resolved_at,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Supplier struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Name        string             `bson:"name"`
+	ContactName string             `bson:"contact_name"`
+	Email       string             `bson:"email"`
+	Phone       string             `bson:"phone"`
+	Address     string             `bson:"address"`
+	TaxID       string             `bson:"tax_id"`
+	IsActive    bool               `bson:"is_active"`
+	CreatedBy   primitive.ObjectID `bson:"created_by"`
+	CreatedAt   time.Time          `bson:"created_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	WebhookDeliveryPending   = "Pending"
+	WebhookDeliverySucceeded = "Succeeded"
+	WebhookDeliveryFailed    = "Failed"
+)
+
+type WebhookSubscription struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	URL         string             `bson:"url"`
+	Events      []string           `bson:"events"` // "*" คือทุก event
+	Secret      string             `bson:"secret" json:"-"`
+	Description string             `bson:"description"`
+	IsActive    bool               `bson:"is_active"`
+	CreatedBy   primitive.ObjectID `bson:"created_by"`
+	CreatedAt   time.Time          `bson:"created_at"`
+}
+
+// WebhookDelivery คือการส่ง event หนึ่งไปยัง subscription หนึ่ง เก็บ payload ไว้เพื่อให้ส่งซ้ำได้ข้อมูลเดิม
+type WebhookDelivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	SubscriptionID primitive.ObjectID `bson:"subscription_id"`
+	EventID        string             `bson:"event_id"`
+	Event          string             `bson:"event"`
+	AggregateID    string             `bson:"aggregate_id,omitempty"`
+	Payload        string             `bson:"payload"`
+	Status         string             `bson:"status"` // "Pending", "Succeeded", "Failed"
+	Attempts       int                `bson:"attempts"`
+	NextAttemptAt  time.Time          `bson:"next_attempt_at"`
+	ResponseStatus int                `bson:"response_status"`
+	LastError      string             `bson:"last_error"`
+	CreatedAt      time.Time          `bson:"created_at"`
+	DeliveredAt    *time.Time         `bson:"delivered_at,omitempty"`
+}
@@ -0,0 +1,71 @@
+package document
+
+import "strings"
+
+var thaiDigits = []string{"", "หนึ่ง", "สอง", "สาม", "สี่", "ห้า", "หก", "เจ็ด", "แปด", "เก้า"}
+var thaiPlaces = []string{"", "สิบ", "ร้อย", "พัน", "หมื่น", "แสน"}
+
+// BahtText อ่านจำนวนเงินหน่วยสตางค์เป็นคำภาษาไทย เช่น 125050 เป็น "หนึ่งพันสองร้อยห้าสิบบาทห้าสิบสตางค์"
+func BahtText(satang int64) string {
+	prefix := ""
+	if satang < 0 {
+		prefix = "ลบ"
+		satang = -satang
+	}
+	baht, rest := satang/100, satang%100
+	if baht == 0 && rest == 0 {
+		return "ศูนย์บาทถ้วน"
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	if baht > 0 {
+		b.WriteString(readNumber(baht))
+		b.WriteString("บาท")
+	}
+	if rest == 0 {
+		b.WriteString("ถ้วน")
+	} else {
+		b.WriteString(readNumber(rest))
+		b.WriteString("สตางค์")
+	}
+	return b.String()
+}
+
+// readNumber อ่านทีละกลุ่มหกหลัก กลุ่มที่สูงกว่าต่อท้ายด้วย "ล้าน"
+func readNumber(n int64) string {
+	if n >= 1000000 {
+		return readNumber(n/1000000) + "ล้าน" + readGroup(n%1000000, true)
+	}
+	return readGroup(n, false)
+}
+
+func readGroup(n int64, hasHigher bool) string {
+	if n == 0 {
+		return ""
+	}
+	var digits []int
+	for v := n; v > 0; v /= 10 {
+		digits = append(digits, int(v%10))
+	}
+
+	var b strings.Builder
+	for place := len(digits) - 1; place >= 0; place-- {
+		d := digits[place]
+		if d == 0 {
+			continue
+		}
+		switch {
+		case place == 0 && d == 1 && (len(digits) > 1 || hasHigher):
+			b.WriteString("เอ็ด")
+		case place == 1 && d == 1:
+			b.WriteString("สิบ")
+		case place == 1 && d == 2:
+			b.WriteString("ยี่สิบ")
+		default:
+			b.WriteString(thaiDigits[d])
+			b.WriteString(thaiPlaces[place])
+		}
+	}
+	return b.String()
+}
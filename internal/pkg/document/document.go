@@ -0,0 +1,322 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/money"
+)
+
+const fontFamily = "thai"
+
+// เวลาไทยไม่มี daylight saving ใช้ fixed zone ได้โดยไม่ต้องพึ่ง tzdata ของเครื่อง
+var bangkok = time.FixedZone("ICT", 7*60*60)
+
+type Company struct {
+	Name    string
+	Address string
+	TaxID   string
+	Branch  string
+	Phone   string
+}
+
+type Line struct {
+	Name string
+	SKU  string
+	Item models.OrderItem
+}
+
+type Data struct {
+	Type     string
+	Number   string
+	IssuedAt time.Time
+	Customer models.Customer
+	Order    models.Order
+	Lines    []Line
+	ValidTo  time.Time // วันหมดอายุของใบเสนอราคา
+}
+
+type Renderer struct {
+	Company Company
+	regular []byte
+	bold    []byte
+}
+
+// NewRenderer โหลดฟอนต์ TTF ที่มีอักษรไทย ฟอนต์มาตรฐานของ PDF แสดงภาษาไทยไม่ได้
+func NewRenderer(company Company, regularPath string, boldPath string) (*Renderer, error) {
+	regular, err := os.ReadFile(regularPath)
+	if err != nil {
+		return nil, fmt.Errorf("load font %s: %w", regularPath, err)
+	}
+	// ไม่ได้ระบุตัวหนาก็ใช้ตัวปกติแทน
+	bold := regular
+	if boldPath != "" {
+		if bold, err = os.ReadFile(boldPath); err != nil {
+			return nil, fmt.Errorf("load font %s: %w", boldPath, err)
+		}
+	}
+	return &Renderer{Company: company, regular: regular, bold: bold}, nil
+}
+
+func Title(docType string) string {
+	switch docType {
+	case models.DocumentTaxInvoice:
+		return "ใบกำกับภาษี / TAX INVOICE"
+	case models.DocumentReceipt:
+		return "ใบเสร็จรับเงิน / RECEIPT"
+	case models.DocumentQuotation:
+		return "ใบเสนอราคา / QUOTATION"
+	}
+	return docType
+}
+
+// Render สร้าง PDF จากข้อมูลที่ให้มาเท่านั้น วันที่ในไฟล์ใช้ IssuedAt เพื่อให้ข้อมูลเดิมได้ไฟล์เดิม
+func (r *Renderer) Render(data Data) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetCatalogSort(true)
+	pdf.SetCreationDate(data.IssuedAt)
+	pdf.SetModificationDate(data.IssuedAt)
+	pdf.SetTitle(data.Number, true)
+	pdf.SetAuthor(r.Company.Name, true)
+	pdf.AddUTF8FontFromBytes(fontFamily, "", r.regular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", r.bold)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont(fontFamily, "", 9)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%s  หน้า %d/{nb}", data.Number, pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	r.header(pdf, data)
+	r.parties(pdf, data)
+	if err := r.items(pdf, data); err != nil {
+		return nil, err
+	}
+	if err := r.totals(pdf, data); err != nil {
+		return nil, err
+	}
+	r.signatures(pdf, data)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Renderer) header(pdf *fpdf.Fpdf, data Data) {
+	pdf.SetFont(fontFamily, "B", 16)
+	pdf.CellFormat(110, 8, r.Company.Name, "", 0, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "B", 14)
+	pdf.CellFormat(0, 8, Title(data.Type), "", 1, "R", false, 0, "")
+
+	pdf.SetFont(fontFamily, "", 10)
+	if r.Company.Address != "" {
+		pdf.MultiCell(110, 5, r.Company.Address, "", "L", false)
+	}
+	taxLine := "เลขประจำตัวผู้เสียภาษี " + r.Company.TaxID + "  " + branchLabel(r.Company.Branch)
+	pdf.CellFormat(110, 5, taxLine, "", 1, "L", false, 0, "")
+	if r.Company.Phone != "" {
+		pdf.CellFormat(110, 5, "โทร "+r.Company.Phone, "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+}
+
+func (r *Renderer) parties(pdf *fpdf.Fpdf, data Data) {
+	top := pdf.GetY()
+	customer := data.Customer
+
+	pdf.SetFont(fontFamily, "B", 11)
+	pdf.CellFormat(110, 6, "ลูกค้า / Customer", "", 1, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "", 10)
+	pdf.CellFormat(110, 5, orDash(customer.FullName), "", 1, "L", false, 0, "")
+	// ใช้ที่อยู่ใบกำกับภาษีที่เก็บไว้กับออเดอร์ก่อน ออเดอร์เก่าไม่มีจึงใช้ที่อยู่ของลูกค้า
+	address := customer.Address
+	if data.Order.BillingAddress != nil {
+		address = data.Order.BillingAddress.String()
+	}
+	if address != "" {
+		pdf.MultiCell(105, 5, address, "", "L", false)
+	}
+	if customer.Phone != "" {
+		pdf.CellFormat(110, 5, "โทร "+customer.Phone, "", 1, "L", false, 0, "")
+	}
+	if customer.Email != "" {
+		pdf.CellFormat(110, 5, customer.Email, "", 1, "L", false, 0, "")
+	}
+	bottom := pdf.GetY()
+
+	pdf.SetXY(125, top)
+	rows := [][2]string{
+		{"เลขที่ / No.", data.Number},
+		{"วันที่ / Date", FormatDate(data.IssuedAt)},
+		{"อ้างอิง / Ref.", data.Order.Tracking_number},
+	}
+	if data.Type == models.DocumentQuotation {
+		rows[2] = [2]string{"ยืนราคาถึง", FormatDate(data.ValidTo)}
+	}
+	for _, row := range rows {
+		pdf.SetX(125)
+		pdf.SetFont(fontFamily, "B", 10)
+		pdf.CellFormat(25, 6, row[0], "", 0, "L", false, 0, "")
+		pdf.SetFont(fontFamily, "", 10)
+		pdf.CellFormat(0, 6, row[1], "", 1, "L", false, 0, "")
+	}
+	if pdf.GetY() < bottom {
+		pdf.SetY(bottom)
+	}
+	pdf.Ln(4)
+}
+
+var columns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"ลำดับ", 12, "C"},
+	{"รายการ", 76, "L"},
+	{"จำนวน", 18, "R"},
+	{"ราคาต่อหน่วย", 26, "R"},
+	{"ส่วนลด", 22, "R"},
+	{"จำนวนเงิน", 26, "R"},
+}
+
+func (r *Renderer) items(pdf *fpdf.Fpdf, data Data) error {
+	pdf.SetFont(fontFamily, "B", 10)
+	pdf.SetFillColor(235, 235, 235)
+	for _, col := range columns {
+		pdf.CellFormat(col.width, 7, col.title, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont(fontFamily, "", 10)
+	const lineHeight = 6
+	for i, line := range data.Lines {
+		name := line.Name
+		if line.SKU != "" {
+			name += " (" + line.SKU + ")"
+		}
+		nameLines := pdf.SplitText(name, columns[1].width-2)
+		height := float64(len(nameLines)) * lineHeight
+
+		_, pageHeight := pdf.GetPageSize()
+		if pdf.GetY()+height > pageHeight-20 {
+			pdf.AddPage()
+		}
+
+		item := line.Item
+		amount, err := item.UnitPrice.Mul(item.Quantity).Sub(item.Discount)
+		if err != nil {
+			return err
+		}
+		cells := []string{
+			fmt.Sprint(i + 1),
+			"",
+			fmt.Sprint(item.Quantity),
+			money.Format(item.UnitPrice),
+			money.Format(item.Discount),
+			money.Format(amount),
+		}
+
+		x, y := pdf.GetXY()
+		for c, col := range columns {
+			if c == 1 {
+				pdf.MultiCell(col.width, lineHeight, strings.Join(nameLines, "\n"), "1", "L", false)
+				pdf.SetXY(x+col.width, y)
+			} else {
+				pdf.CellFormat(col.width, height, cells[c], "1", 0, col.align, false, 0, "")
+			}
+			x += col.width
+		}
+		pdf.SetXY(15, y+height)
+	}
+	pdf.Ln(2)
+	return nil
+}
+
+func (r *Renderer) totals(pdf *fpdf.Fpdf, data Data) error {
+	order := data.Order
+	rows := [][2]string{}
+	if !order.DiscountTotal.IsZero() {
+		rows = append(rows, [2]string{"ส่วนลดรวม", money.Format(order.DiscountTotal)})
+	}
+	// ค่าจัดส่งเป็นส่วนหนึ่งของมูลค่าที่คิดภาษี จึงแสดงก่อนมูลค่าก่อนภาษี
+	if !order.ShippingTotal.IsZero() {
+		rows = append(rows, [2]string{"ค่าจัดส่ง", money.Format(order.ShippingTotal)})
+	}
+	beforeTax, err := order.Subtotal.Add(order.ShippingTotal)
+	if err != nil {
+		return err
+	}
+	rows = append(rows,
+		[2]string{"มูลค่าก่อนภาษี", money.Format(beforeTax)},
+		[2]string{"ภาษีมูลค่าเพิ่ม", money.Format(order.TaxTotal)},
+	)
+
+	pdf.SetFont(fontFamily, "", 10)
+	for _, row := range rows {
+		pdf.SetX(121)
+		pdf.CellFormat(48, 6, row[0], "", 0, "R", false, 0, "")
+		pdf.CellFormat(26, 6, row[1], "", 1, "R", false, 0, "")
+	}
+
+	pdf.SetFont(fontFamily, "B", 11)
+	pdf.SetX(121)
+	pdf.CellFormat(48, 7, "จำนวนเงินรวมทั้งสิ้น ("+order.TotalAmount.Currency+")", "T", 0, "R", false, 0, "")
+	pdf.CellFormat(26, 7, money.Format(order.TotalAmount), "T", 1, "R", false, 0, "")
+
+	if order.TotalAmount.Currency == "THB" {
+		pdf.SetFont(fontFamily, "", 10)
+		pdf.CellFormat(0, 7, "("+BahtText(order.TotalAmount.Amount)+")", "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(4)
+	return nil
+}
+
+func (r *Renderer) signatures(pdf *fpdf.Fpdf, data Data) {
+	pdf.SetFont(fontFamily, "", 10)
+	if data.Type == models.DocumentReceipt {
+		pdf.CellFormat(0, 6, "ได้รับเงินตามรายการข้างต้นเรียบร้อยแล้ว", "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(16)
+
+	left, right := "ผู้รับสินค้า", "ผู้มีอำนาจลงนาม"
+	switch data.Type {
+	case models.DocumentReceipt:
+		left, right = "ผู้จ่ายเงิน", "ผู้รับเงิน"
+	case models.DocumentQuotation:
+		left, right = "ผู้อนุมัติสั่งซื้อ", "ผู้เสนอราคา"
+	}
+	pdf.CellFormat(90, 6, "....................................", "", 0, "C", false, 0, "")
+	pdf.CellFormat(90, 6, "....................................", "", 1, "C", false, 0, "")
+	pdf.CellFormat(90, 6, left, "", 0, "C", false, 0, "")
+	pdf.CellFormat(90, 6, right, "", 1, "C", false, 0, "")
+}
+
+// FormatDate แสดงวันที่แบบไทย วัน/เดือน/ปี พ.ศ.
+func FormatDate(t time.Time) string {
+	t = t.In(bangkok)
+	return fmt.Sprintf("%02d/%02d/%d", t.Day(), int(t.Month()), t.Year()+543)
+}
+
+func branchLabel(branch string) string {
+	if branch == "" || branch == "00000" {
+		return "สำนักงานใหญ่"
+	}
+	return "สาขาที่ " + branch
+}
+
+func orDash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,280 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/skip2/go-qrcode"
+)
+
+// ขนาดใบปะหน้า 100x150 มม. ตรงกับสติกเกอร์ของเครื่องพิมพ์ความร้อนขนาด 4x6 นิ้ว
+const (
+	labelWidth  = 100
+	labelHeight = 150
+)
+
+// Parcel คือข้อมูลของออเดอร์หนึ่งที่ใช้พิมพ์ใบปะหน้าพัสดุและใบรายการสินค้า
+type Parcel struct {
+	Order          models.Order
+	Recipient      string
+	Phone          string
+	Address        string
+	Postcode       string
+	CarrierName    string
+	Service        string
+	TrackingNumber string
+	Lines          []Line
+}
+
+// Reference คือข้อความใน QR ใช้เลขพัสดุถ้ามี ถ้ายังไม่มีใช้รหัสออเดอร์เพื่อให้สแกนหาออเดอร์ได้
+func (p Parcel) Reference() string {
+	if p.TrackingNumber != "" {
+		return p.TrackingNumber
+	}
+	return p.Order.ID.Hex()
+}
+
+// RenderLabels สร้างใบปะหน้าพัสดุ หนึ่งหน้าต่อหนึ่งออเดอร์
+func (r *Renderer) RenderLabels(parcels []Parcel, printedAt time.Time) ([]byte, error) {
+	pdf := r.newPDF(fpdf.SizeType{Wd: labelWidth, Ht: labelHeight}, "Shipping labels", printedAt)
+	pdf.SetMargins(5, 5, 5)
+	pdf.SetAutoPageBreak(false, 0)
+
+	for i, parcel := range parcels {
+		pdf.AddPage()
+		if err := r.label(pdf, parcel, fmt.Sprintf("qr-%d", i)); err != nil {
+			return nil, err
+		}
+	}
+	return output(pdf)
+}
+
+// RenderPackingSlips สร้างใบรายการสินค้าสำหรับคนแพ็กของ หนึ่งออเดอร์เริ่มหน้าใหม่เสมอ
+func (r *Renderer) RenderPackingSlips(parcels []Parcel, printedAt time.Time) ([]byte, error) {
+	pdf := r.newPDF(fpdf.SizeType{}, "Packing slips", printedAt)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+
+	for _, parcel := range parcels {
+		pdf.AddPage()
+		r.packingSlip(pdf, parcel, printedAt)
+	}
+	return output(pdf)
+}
+
+// newPDF ขนาดศูนย์หมายถึง A4
+func (r *Renderer) newPDF(size fpdf.SizeType, title string, createdAt time.Time) *fpdf.Fpdf {
+	init := &fpdf.InitType{OrientationStr: "P", UnitStr: "mm", SizeStr: "A4"}
+	if size.Wd > 0 {
+		init.SizeStr, init.Size = "", size
+	}
+	pdf := fpdf.NewCustom(init)
+	pdf.SetCatalogSort(true)
+	pdf.SetCreationDate(createdAt)
+	pdf.SetModificationDate(createdAt)
+	pdf.SetTitle(title, true)
+	pdf.SetAuthor(r.Company.Name, true)
+	pdf.AddUTF8FontFromBytes(fontFamily, "", r.regular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", r.bold)
+	return pdf
+}
+
+func (r *Renderer) label(pdf *fpdf.Fpdf, parcel Parcel, imageName string) error {
+	const width = labelWidth - 10
+
+	carrier := strings.TrimSpace(parcel.CarrierName + " " + parcel.Service)
+	pdf.SetFont(fontFamily, "B", 14)
+	pdf.CellFormat(60, 8, orDash(carrier), "", 0, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "", 9)
+	pdf.CellFormat(0, 8, FormatDate(parcel.Order.CreatedAt), "", 1, "R", false, 0, "")
+	separator(pdf)
+
+	pdf.SetFont(fontFamily, "B", 9)
+	pdf.CellFormat(width, 5, "ผู้ส่ง / From", "", 1, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "", 9)
+	pdf.CellFormat(width, 4.5, r.Company.Name, "", 1, "L", false, 0, "")
+	if r.Company.Address != "" {
+		pdf.MultiCell(width, 4.5, r.Company.Address, "", "L", false)
+	}
+	if r.Company.Phone != "" {
+		pdf.CellFormat(width, 4.5, "โทร "+r.Company.Phone, "", 1, "L", false, 0, "")
+	}
+	separator(pdf)
+
+	pdf.SetFont(fontFamily, "B", 10)
+	pdf.CellFormat(width, 6, "ผู้รับ / To", "", 1, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "B", 16)
+	pdf.MultiCell(width, 7, orDash(parcel.Recipient), "", "L", false)
+	pdf.SetFont(fontFamily, "", 12)
+	if parcel.Address != "" {
+		pdf.MultiCell(width, 6, parcel.Address, "", "L", false)
+	}
+	if parcel.Phone != "" {
+		pdf.CellFormat(width, 6, "โทร "+parcel.Phone, "", 1, "L", false, 0, "")
+	}
+	if parcel.Postcode != "" {
+		pdf.SetFont(fontFamily, "B", 24)
+		pdf.CellFormat(width, 11, parcel.Postcode, "1", 1, "C", false, 0, "")
+	}
+	separator(pdf)
+
+	png, err := qrcode.Encode(parcel.Reference(), qrcode.Medium, 256)
+	if err != nil {
+		return fmt.Errorf("encode QR for order %s: %w", parcel.Order.ID.Hex(), err)
+	}
+	options := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader(imageName, options, bytes.NewReader(png))
+
+	// QR อยู่ชิดล่างซ้ายเสมอ ข้อความด้านบนยาวแค่ไหนก็ไม่ทับ QR จนเกินขอบกระดาษ
+	const qrSize = 36
+	top := pdf.GetY()
+	if bottom := float64(labelHeight - 5 - qrSize); top > bottom {
+		top = bottom
+	}
+	pdf.ImageOptions(imageName, 5, top, qrSize, qrSize, false, options, 0, "")
+
+	pieces := 0
+	for _, line := range parcel.Lines {
+		pieces += line.Item.Quantity
+	}
+	pdf.SetXY(5+qrSize+3, top+2)
+	pdf.SetFont(fontFamily, "", 9)
+	pdf.CellFormat(0, 5, "เลขพัสดุ / Tracking", "", 2, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "B", 13)
+	pdf.CellFormat(0, 7, orDash(parcel.TrackingNumber), "", 2, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "", 9)
+	pdf.CellFormat(0, 5, "ออเดอร์ "+parcel.Order.ID.Hex(), "", 2, "L", false, 0, "")
+	pdf.CellFormat(0, 5, fmt.Sprintf("จำนวน %d ชิ้น", pieces), "", 2, "L", false, 0, "")
+	if parcel.Order.Shipping != nil && parcel.Order.Shipping.WeightGrams > 0 {
+		pdf.CellFormat(0, 5, fmt.Sprintf("น้ำหนัก %.2f กก.", float64(parcel.Order.Shipping.WeightGrams)/1000), "", 2, "L", false, 0, "")
+	}
+	return nil
+}
+
+var packingColumns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"ลำดับ", 12, "C"},
+	{"รหัสสินค้า", 38, "L"},
+	{"รายการ", 94, "L"},
+	{"จำนวน", 18, "R"},
+	{"ตรวจ", 18, "C"},
+}
+
+func (r *Renderer) packingSlip(pdf *fpdf.Fpdf, parcel Parcel, printedAt time.Time) {
+	order := parcel.Order
+
+	pdf.SetFont(fontFamily, "B", 16)
+	pdf.CellFormat(110, 8, r.Company.Name, "", 0, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "B", 14)
+	pdf.CellFormat(0, 8, "ใบรายการสินค้า / PACKING SLIP", "", 1, "R", false, 0, "")
+	pdf.Ln(4)
+
+	top := pdf.GetY()
+	pdf.SetFont(fontFamily, "B", 11)
+	pdf.CellFormat(110, 6, "ผู้รับ / Ship to", "", 1, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "", 10)
+	pdf.CellFormat(110, 5, orDash(parcel.Recipient), "", 1, "L", false, 0, "")
+	if parcel.Address != "" {
+		pdf.MultiCell(105, 5, parcel.Address, "", "L", false)
+	}
+	if parcel.Phone != "" {
+		pdf.CellFormat(110, 5, "โทร "+parcel.Phone, "", 1, "L", false, 0, "")
+	}
+	bottom := pdf.GetY()
+
+	pdf.SetXY(125, top)
+	rows := [][2]string{
+		{"ออเดอร์", order.ID.Hex()},
+		{"วันที่สั่ง", FormatDate(order.CreatedAt)},
+		{"ขนส่ง", orDash(strings.TrimSpace(parcel.CarrierName + " " + parcel.Service))},
+		{"เลขพัสดุ", orDash(parcel.TrackingNumber)},
+		{"พิมพ์เมื่อ", FormatDate(printedAt)},
+	}
+	for _, row := range rows {
+		pdf.SetX(125)
+		pdf.SetFont(fontFamily, "B", 10)
+		pdf.CellFormat(20, 6, row[0], "", 0, "L", false, 0, "")
+		pdf.SetFont(fontFamily, "", 10)
+		pdf.CellFormat(0, 6, row[1], "", 1, "L", false, 0, "")
+	}
+	if pdf.GetY() < bottom {
+		pdf.SetY(bottom)
+	}
+	pdf.Ln(4)
+
+	pdf.SetFont(fontFamily, "B", 10)
+	pdf.SetFillColor(235, 235, 235)
+	for _, col := range packingColumns {
+		pdf.CellFormat(col.width, 7, col.title, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont(fontFamily, "", 10)
+	const lineHeight = 6
+	pieces := 0
+	for i, line := range parcel.Lines {
+		nameLines := pdf.SplitText(line.Name, packingColumns[2].width-2)
+		height := float64(len(nameLines)) * lineHeight
+
+		_, pageHeight := pdf.GetPageSize()
+		if pdf.GetY()+height > pageHeight-20 {
+			pdf.AddPage()
+		}
+
+		pieces += line.Item.Quantity
+		cells := []string{fmt.Sprint(i + 1), orDash(line.SKU), "", fmt.Sprint(line.Item.Quantity), ""}
+		x, y := pdf.GetXY()
+		for c, col := range packingColumns {
+			if c == 2 {
+				pdf.MultiCell(col.width, lineHeight, strings.Join(nameLines, "\n"), "1", "L", false)
+				pdf.SetXY(x+col.width, y)
+			} else {
+				pdf.CellFormat(col.width, height, cells[c], "1", 0, col.align, false, 0, "")
+			}
+			x += col.width
+		}
+		pdf.SetXY(15, y+height)
+	}
+
+	pdf.SetFont(fontFamily, "B", 10)
+	pdf.CellFormat(144, 7, "รวม", "", 0, "R", false, 0, "")
+	pdf.CellFormat(18, 7, fmt.Sprint(pieces), "", 1, "R", false, 0, "")
+	pdf.Ln(2)
+
+	if order.Note != "" {
+		pdf.SetFont(fontFamily, "B", 10)
+		pdf.CellFormat(0, 6, "หมายเหตุ", "", 1, "L", false, 0, "")
+		pdf.SetFont(fontFamily, "", 10)
+		pdf.MultiCell(0, 5, order.Note, "", "L", false)
+	}
+	pdf.Ln(16)
+
+	pdf.SetFont(fontFamily, "", 10)
+	pdf.CellFormat(90, 6, "....................................", "", 0, "C", false, 0, "")
+	pdf.CellFormat(90, 6, "....................................", "", 1, "C", false, 0, "")
+	pdf.CellFormat(90, 6, "ผู้จัดสินค้า", "", 0, "C", false, 0, "")
+	pdf.CellFormat(90, 6, "ผู้ตรวจสอบ", "", 1, "C", false, 0, "")
+}
+
+func separator(pdf *fpdf.Fpdf) {
+	left, _, right, _ := pdf.GetMargins()
+	width, _ := pdf.GetPageSize()
+	y := pdf.GetY() + 1
+	pdf.Line(left, y, width-right, y)
+	pdf.SetY(y + 1)
+}
+
+func output(pdf *fpdf.Fpdf) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+)
+
+const EventPaymentSucceeded = "payment.succeeded"
+
+var ErrInvalidSignature = fmt.Errorf("invalid webhook signature")
+
+// Event คือ webhook ที่แปลงเป็นรูปแบบกลางแล้ว ไม่ว่าจะมาจากผู้ให้บริการรายไหน
+type Event struct {
+	ID        string
+	Type      string
+	OrderID   string
+	Amount    models.Money
+	Method    string
+	Reference string
+	PaidAt    time.Time
+}
+
+// Provider ตรวจลายเซ็นและแปลง body ของ webhook จากผู้ให้บริการชำระเงินแต่ละราย
+type Provider interface {
+	Name() string
+	ParseWebhook(header http.Header, body []byte) (*Event, error)
+}
+
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: map[string]Provider{}}
+	for _, p := range providers {
+		r.Register(p)
+	}
+	return r
+}
+
+func (r *Registry) Register(p Provider) {
+	r.providers[p.Name()] = p
+}
+
+func (r *Registry) Get(name string) (Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Sign คืน HMAC-SHA256 ของ message เป็น hex
+func Sign(secret string, message []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyHMAC เทียบลายเซ็นแบบ constant time
+func VerifyHMAC(secret string, message []byte, signature string) bool {
+	expected, err := hex.DecodeString(Sign(secret, message))
+	if err != nil {
+		return false
+	}
+	actual, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(expected, actual)
+}
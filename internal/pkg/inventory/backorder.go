@@ -0,0 +1,229 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/notify"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/outbox"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const NotificationTypeBackorderFulfilled = "order.backorder_fulfilled"
+
+var errOrderChanged = errors.New("order changed while allocating backorder")
+
+// BackorderAllocator จัดสรรสต็อกที่เข้ามาให้ออเดอร์ที่รอสินค้าอยู่ ออเดอร์ที่สั่งก่อนได้ก่อน
+// ทำงานเมื่อสต็อกเพิ่มขึ้น และกวาดทุกสินค้าที่มีคนรอตาม SweepInterval เผื่อคิวเต็มหรือรอบก่อนล้มเหลว
+type BackorderAllocator struct {
+	ProductRepo   repositories.ProductRepositoryInterface
+	OrderRepo     repositories.OrderRepositoryInterface
+	Events        outbox.Recorder
+	Notifier      *notify.Dispatcher
+	SweepInterval time.Duration
+	queue         chan primitive.ObjectID
+}
+
+func NewBackorderAllocator(productRepo repositories.ProductRepositoryInterface, orderRepo repositories.OrderRepositoryInterface, events outbox.Recorder, notifier *notify.Dispatcher) *BackorderAllocator {
+	return &BackorderAllocator{
+		ProductRepo:   productRepo,
+		OrderRepo:     orderRepo,
+		Events:        events,
+		Notifier:      notifier,
+		SweepInterval: 5 * time.Minute,
+		queue:         make(chan primitive.ObjectID, 256),
+	}
+}
+
+func (a *BackorderAllocator) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(a.SweepInterval)
+		defer ticker.Stop()
+		a.Sweep(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.Sweep(ctx)
+			case productID := <-a.queue:
+				a.allocateWithTimeout(ctx, productID)
+			}
+		}
+	}()
+}
+
+// Enqueue ไม่บล็อกผู้เรียก หากคิวเต็มจะข้ามไป ออเดอร์ที่รออยู่จะได้รับการจัดสรรในรอบกวาดถัดไป
+func (a *BackorderAllocator) Enqueue(productID primitive.ObjectID) {
+	select {
+	case a.queue <- productID:
+	default:
+		log.Printf("backorder queue full, skipping %s until the next sweep", productID.Hex())
+	}
+}
+
+// Sweep จัดสรรสต็อกให้ทุกสินค้าที่ยังมีออเดอร์รออยู่
+func (a *BackorderAllocator) Sweep(ctx context.Context) {
+	findCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	waiting, err := a.OrderRepo.BackorderedQuantities(findCtx, nil)
+	cancel()
+	if err != nil {
+		log.Printf("backorder sweep failed: %v", err)
+		return
+	}
+	for productID := range waiting {
+		if ctx.Err() != nil {
+			return
+		}
+		a.allocateWithTimeout(ctx, productID)
+	}
+}
+
+func (a *BackorderAllocator) allocateWithTimeout(ctx context.Context, productID primitive.ObjectID) {
+	allocateCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := a.Allocate(allocateCtx, productID); err != nil {
+		log.Printf("backorder allocation failed for %s: %v", productID.Hex(), err)
+	}
+}
+
+// Allocate ตัดสต็อกที่มีอยู่ให้รายการที่รอสินค้านี้ตามลำดับเวลาสั่งซื้อ
+func (a *BackorderAllocator) Allocate(ctx context.Context, productID primitive.ObjectID) error {
+	orders, err := a.OrderRepo.FindBackordered(ctx, productID)
+	if err != nil || len(orders) == 0 {
+		return err
+	}
+	product, err := a.loadProduct(ctx, productID)
+	if err != nil || product == nil {
+		return err
+	}
+
+	for _, order := range orders {
+		allocated := false
+		for i, item := range order.Items {
+			if item.ProductID != productID || item.Backordered == 0 {
+				continue
+			}
+			location, take := allocation(product, item)
+			if take == 0 {
+				continue
+			}
+
+			err := a.Events.Transaction(ctx, func(ctx context.Context) error {
+				return a.allocate(ctx, order.ID, i, item, location, take)
+			})
+			if errors.Is(err, repositories.ErrInsufficientStock) || errors.Is(err, errOrderChanged) {
+				// สต็อกหรือออเดอร์เปลี่ยนระหว่างนี้ อ่านสต็อกใหม่แล้วไปรายการถัดไป
+				if product, err = a.loadProduct(ctx, productID); err != nil || product == nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			moved := 0
+			if location != item.LocationID {
+				moved = item.Reserved()
+			}
+			for j := range product.Locations {
+				switch product.Locations[j].LocationID {
+				case location:
+					product.Locations[j].Quantity -= take + moved
+				case item.LocationID:
+					product.Locations[j].Quantity += moved
+				}
+			}
+			order.Items[i].LocationID = location
+			order.Items[i].Backordered -= take
+			allocated = true
+		}
+		if allocated && !hasBackorders(order) {
+			a.notifyFulfilled(ctx, &order)
+		}
+	}
+	return nil
+}
+
+// allocation เลือกคลังและจำนวนที่จะตัดให้รายการที่รอสต็อก ปกติตัดจากคลังเดิมของรายการ
+// ถ้าคลังอื่นมีของมากพอให้ได้มากกว่า จะย้ายทั้งรายการไปคลังนั้นและคืนส่วนที่เคยตัดไว้ให้คลังเดิม
+func allocation(product *models.Product, item models.OrderItem) (primitive.ObjectID, int) {
+	location := item.LocationID
+	take := min(item.Backordered, product.StockAt(location))
+	if take == item.Backordered {
+		return location, take
+	}
+	best, bestQty := product.BestLocation()
+	if best != location {
+		if moved := min(bestQty, item.Quantity) - item.Reserved(); moved > take {
+			return best, moved
+		}
+	}
+	return location, take
+}
+
+// allocate ต้องเรียกภายใน transaction ตัดสต็อกจากคลังใหม่ทั้งส่วนที่รอและส่วนที่ย้ายมา ลดจำนวนที่รอของรายการ
+// แล้วคืนส่วนที่เคยตัดไว้ให้คลังเดิมถ้ารายการย้ายคลัง
+func (a *BackorderAllocator) allocate(ctx context.Context, orderID primitive.ObjectID, index int, item models.OrderItem, location primitive.ObjectID, take int) error {
+	moved := 0
+	if location != item.LocationID {
+		moved = item.Reserved()
+	}
+	if err := a.ProductRepo.UpdateLocationStock(ctx, item.ProductID, location, -(take + moved)); err != nil {
+		return err
+	}
+	ok, err := a.OrderRepo.AllocateBackorder(ctx, orderID, index, item.ProductID, item.LocationID, location, take)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errOrderChanged
+	}
+	if moved > 0 {
+		return a.ProductRepo.UpdateLocationStock(ctx, item.ProductID, item.LocationID, moved)
+	}
+	return nil
+}
+
+func (a *BackorderAllocator) loadProduct(ctx context.Context, productID primitive.ObjectID) (*models.Product, error) {
+	product, err := a.ProductRepo.FindByID(ctx, productID, true)
+	if err == mongo.ErrNoDocuments {
+		product, err = a.ProductRepo.FindByID(ctx, productID, false)
+	}
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return product, err
+}
+
+func (a *BackorderAllocator) notifyFulfilled(ctx context.Context, order *models.Order) {
+	if a.Notifier == nil {
+		return
+	}
+	a.Notifier.Send(ctx, &models.Notification{
+		Type:    NotificationTypeBackorderFulfilled,
+		Subject: fmt.Sprintf("ออเดอร์ %s ได้รับสินค้าครบแล้ว", order.ID.Hex()),
+		Message: fmt.Sprintf("สินค้าที่รอสต็อกของออเดอร์ %s ถูกจัดสรรครบแล้ว พร้อมจัดส่ง", order.ID.Hex()),
+		Data: map[string]any{
+			"order_id":    order.ID.Hex(),
+			"customer_id": order.CustomerID.Hex(),
+		},
+		CreatedAt: time.Now(),
+	})
+}
+
+func hasBackorders(order models.Order) bool {
+	for _, item := range order.Items {
+		if item.Backordered > 0 {
+			return true
+		}
+	}
+	return false
+}
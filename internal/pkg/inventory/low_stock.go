@@ -0,0 +1,114 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/notify"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const NotificationTypeLowStock = "product.low_stock"
+
+type LowStockChecker struct {
+	ProductRepo repositories.ProductRepositoryInterface
+	AlertRepo   repositories.StockAlertRepositoryInterface
+	Notifier    *notify.Dispatcher
+	queue       chan primitive.ObjectID
+}
+
+func NewLowStockChecker(productRepo repositories.ProductRepositoryInterface, alertRepo repositories.StockAlertRepositoryInterface, notifier *notify.Dispatcher) *LowStockChecker {
+	return &LowStockChecker{
+		ProductRepo: productRepo,
+		AlertRepo:   alertRepo,
+		Notifier:    notifier,
+		queue:       make(chan primitive.ObjectID, 256),
+	}
+}
+
+func (c *LowStockChecker) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case productID := <-c.queue:
+				checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+				if err := c.Check(checkCtx, productID); err != nil {
+					log.Printf("low-stock check failed for %s: %v", productID.Hex(), err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Enqueue ไม่บล็อกผู้เรียก หากคิวเต็มจะข้ามไป และรายงาน low-stock ยังคำนวณจากข้อมูลจริงได้เสมอ
+func (c *LowStockChecker) Enqueue(productID primitive.ObjectID) {
+	select {
+	case c.queue <- productID:
+	default:
+		log.Printf("low-stock queue full, skipping %s", productID.Hex())
+	}
+}
+
+func (c *LowStockChecker) Check(ctx context.Context, productID primitive.ObjectID) error {
+	product, err := c.ProductRepo.FindByID(ctx, productID, true)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if product.ReorderPoint <= 0 || product.Stock > product.ReorderPoint {
+		_, err := c.AlertRepo.Resolve(ctx, productID)
+		return err
+	}
+
+	_, err = c.AlertRepo.FindOpenByProduct(ctx, productID)
+	if err == nil {
+		return nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	alert := models.StockAlert{
+		ProductID:       product.ID,
+		SKU:             product.SKU,
+		ProductName:     product.Name,
+		Stock:           product.Stock,
+		ReorderPoint:    product.ReorderPoint,
+		ReorderQuantity: product.ReorderQuantity,
+		Status:          "Open",
+		CreatedAt:       time.Now(),
+	}
+	if err := c.AlertRepo.Insert(ctx, &alert); err != nil {
+		return err
+	}
+
+	if c.Notifier != nil {
+		c.Notifier.Send(ctx, &models.Notification{
+			Type:    NotificationTypeLowStock,
+			Subject: fmt.Sprintf("สินค้าใกล้หมด: %s (%s)", product.Name, product.SKU),
+			Message: fmt.Sprintf("%s (%s) เหลือ %d ชิ้น ต่ำกว่าจุดสั่งซื้อ %d ชิ้น แนะนำให้สั่งเพิ่ม %d ชิ้น",
+				product.Name, product.SKU, product.Stock, product.ReorderPoint, product.ReorderQuantity),
+			Data: map[string]any{
+				"alert_id":         alert.ID.Hex(),
+				"product_id":       product.ID.Hex(),
+				"sku":              product.SKU,
+				"stock":            product.Stock,
+				"reorder_point":    product.ReorderPoint,
+				"reorder_quantity": product.ReorderQuantity,
+			},
+			CreatedAt: time.Now(),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+)
+
+func DefaultCurrency() string {
+	if currency := os.Getenv("DEFAULT_CURRENCY"); currency != "" {
+		return strings.ToUpper(currency)
+	}
+	return "THB"
+}
+
+func Exponent(currency string) int {
+	if exp, ok := models.CurrencyExponent(currency); ok {
+		return exp
+	}
+	return 2
+}
+
+func Zero(currency string) models.Money {
+	return models.Money{Currency: NormalizeCurrency(currency)}
+}
+
+func NormalizeCurrency(currency string) string {
+	if currency == "" {
+		return DefaultCurrency()
+	}
+	return strings.ToUpper(currency)
+}
+
+func ValidCurrency(currency string) bool {
+	_, ok := models.CurrencyExponent(currency)
+	return ok
+}
+
+func Parse(value string, currency string) (models.Money, error) {
+	currency = NormalizeCurrency(currency)
+	if !ValidCurrency(currency) {
+		return models.Money{}, fmt.Errorf("unsupported currency: %s", currency)
+	}
+	amount, err := ParseScaled(value, Exponent(currency))
+	if err != nil {
+		return models.Money{}, fmt.Errorf("%w for %s", err, currency)
+	}
+	return models.Money{Amount: amount, Currency: currency}, nil
+}
+
+// ParseScaled แปลงทศนิยมเป็นจำนวนเต็มที่คูณ 10^exponent แล้ว เช่น เปอร์เซ็นต์เป็น basis point ใช้ exponent 2
+func ParseScaled(value string, exponent int) (int64, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok || strings.Contains(value, "/") {
+		return 0, fmt.Errorf("invalid amount: %s", value)
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+	scaled := new(big.Rat).Mul(rat, new(big.Rat).SetInt(scale))
+	if !scaled.IsInt() {
+		return 0, fmt.Errorf("amount %s has too many decimal places", value)
+	}
+	if !scaled.Num().IsInt64() {
+		return 0, fmt.Errorf("amount out of range: %s", value)
+	}
+	return scaled.Num().Int64(), nil
+}
+
+func Format(m models.Money) string {
+	return m.Decimal()
+}
+
+// Decimal รับจำนวนเงินจาก request ได้ทั้งแบบตัวเลขและข้อความ โดยเก็บข้อความเดิมไว้แปลงทีหลังพร้อมสกุลเงิน
+type Decimal string
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*d = Decimal(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid amount: %s", string(data))
+	}
+	*d = Decimal(n.String())
+	return nil
+}
+
+func (d *Decimal) UnmarshalParam(param string) error {
+	*d = Decimal(param)
+	return nil
+}
+
+func (d Decimal) Money(currency string) (models.Money, error) {
+	return Parse(string(d), currency)
+}
@@ -0,0 +1,47 @@
+package money
+
+import "testing"
+
+func TestParseScaled(t *testing.T) {
+	tests := []struct {
+		value    string
+		exponent int
+		want     int64
+		wantErr  bool
+	}{
+		{"0", 2, 0, false},
+		{"12", 2, 1200, false},
+		{"12.5", 2, 1250, false},
+		{"12.50", 2, 1250, false},
+		{" 1,250.75 ", 2, 125075, false},
+		{"-3.01", 2, -301, false},
+		{".5", 2, 50, false},
+		{"7", 0, 7, false},
+		{"1.234", 3, 1234, false},
+		{"7.25", 2, 725, false}, // เปอร์เซ็นต์เป็น basis point
+		{"0.1", 1, 1, false},
+		{"12.345", 2, 0, true},
+		{"1.5", 0, 0, true},
+		{"abc", 2, 0, true},
+		{"", 2, 0, true},
+		{"1/2", 2, 0, true},
+		{"1e2", 2, 10000, false},
+		{"92233720368547758.08", 2, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseScaled(tt.value, tt.exponent)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseScaled(%q, %d) = %d, want error", tt.value, tt.exponent, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseScaled(%q, %d): %v", tt.value, tt.exponent, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseScaled(%q, %d) = %d, want %d", tt.value, tt.exponent, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+)
+
+type EmailSink struct {
+	Addr string
+	From string
+	To   []string
+	Auth smtp.Auth
+}
+
+func NewEmailSink(addr string, from string, to []string, auth smtp.Auth) *EmailSink {
+	return &EmailSink{Addr: addr, From: from, To: to, Auth: auth}
+}
+
+func (s *EmailSink) Name() string {
+	return "email"
+}
+
+func (s *EmailSink) Send(ctx context.Context, notification *models.Notification) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", notification.Subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(notification.Message)
+	msg.WriteString("\r\n")
+
+	return smtp.SendMail(s.Addr, s.Auth, s.From, s.To, []byte(msg.String()))
+}
@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+)
+
+type InAppSink struct {
+	NotificationRepo repositories.NotificationRepositoryInterface
+}
+
+func NewInAppSink(repo repositories.NotificationRepositoryInterface) *InAppSink {
+	return &InAppSink{NotificationRepo: repo}
+}
+
+func (s *InAppSink) Name() string {
+	return "in-app"
+}
+
+func (s *InAppSink) Send(ctx context.Context, notification *models.Notification) error {
+	return s.NotificationRepo.Insert(ctx, notification)
+}
@@ -0,0 +1,33 @@
+package notify
+
+import (
+	"context"
+	"log"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+)
+
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, notification *models.Notification) error
+}
+
+type Dispatcher struct {
+	Sinks []Sink
+}
+
+func NewDispatcher(sinks ...Sink) *Dispatcher {
+	return &Dispatcher{Sinks: sinks}
+}
+
+func (d *Dispatcher) Add(sink Sink) {
+	d.Sinks = append(d.Sinks, sink)
+}
+
+func (d *Dispatcher) Send(ctx context.Context, notification *models.Notification) {
+	for _, sink := range d.Sinks {
+		if err := sink.Send(ctx, notification); err != nil {
+			log.Printf("notify: %s sink failed: %v", sink.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Recorder ให้ handler บันทึก event ไปพร้อมกับการเปลี่ยนแปลงข้อมูลใน transaction เดียวกัน
+type Recorder interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	Record(ctx context.Context, aggregateID string, event string, data any) error
+}
+
+// Sink คือปลายทางที่ event ถูกส่งออกไป ต้องรับ event ซ้ำได้เพราะการส่งเป็นแบบ at-least-once
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+}
+
+type Outbox struct {
+	Repo         repositories.OutboxRepositoryInterface
+	Client       *mongo.Client
+	Sinks        []Sink
+	MaxAttempts  int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	Lease        time.Duration
+	PollInterval time.Duration
+	BatchSize    int64
+	transactions bool
+	wake         chan struct{}
+}
+
+// NewOutbox ตรวจว่า MongoDB รองรับ transaction หรือไม่ (ต้องเป็น replica set หรือ mongos)
+// ถ้าไม่รองรับจะคืน error เว้นแต่ allowStandalone ซึ่งจะบันทึก event แยกจากข้อมูลหลัก
+// และ event อาจตกหล่นได้ถ้า process ตายระหว่างสองขั้นนี้ ใช้สำหรับเครื่องพัฒนาเท่านั้น
+func NewOutbox(ctx context.Context, repo repositories.OutboxRepositoryInterface, client *mongo.Client, allowStandalone bool, sinks ...Sink) (*Outbox, error) {
+	o := &Outbox{
+		Repo:         repo,
+		Client:       client,
+		Sinks:        sinks,
+		MaxAttempts:  10,
+		BaseDelay:    5 * time.Second,
+		MaxDelay:     10 * time.Minute,
+		Lease:        time.Minute,
+		PollInterval: 5 * time.Second,
+		BatchSize:    100,
+		wake:         make(chan struct{}, 1),
+	}
+	supported, err := supportsTransactions(ctx, client)
+	if err != nil || !supported {
+		if err == nil {
+			err = fmt.Errorf("MongoDB is not a replica set or mongos")
+		}
+		if !allowStandalone {
+			return nil, fmt.Errorf("outbox requires transactions: %w", err)
+		}
+		log.Printf("outbox: %v, events are recorded WITHOUT transactions because standalone mode is allowed", err)
+		return o, nil
+	}
+	o.transactions = true
+	return o, nil
+}
+
+func supportsTransactions(ctx context.Context, client *mongo.Client) (bool, error) {
+	if client == nil {
+		return false, fmt.Errorf("no MongoDB client")
+	}
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false, fmt.Errorf("hello: %w", err)
+	}
+	return hello.SetName != "" || hello.Msg == "isdbgrid", nil
+}
+
+func (o *Outbox) Add(sink Sink) {
+	o.Sinks = append(o.Sinks, sink)
+}
+
+// Transaction เรียก fn ภายใน transaction แล้วปลุก worker ให้ส่ง event ที่เพิ่งบันทึก
+// fn อาจถูกเรียกซ้ำเมื่อ MongoDB แจ้งว่า transaction ชนกันชั่วคราว
+// งานที่ลงทะเบียนด้วย repositories.AfterCommit จะทำหลัง commit สำเร็จครั้งเดียวเท่านั้น
+func (o *Outbox) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !o.transactions {
+		txCtx, hooks := repositories.WithCommitHooks(ctx)
+		err := fn(txCtx)
+		// ไม่มี rollback ข้อมูลที่เขียนไปแล้วยังอยู่ จึงต้องแจ้ง listener แม้ fn ล้มเหลว
+		hooks.Run()
+		if err != nil {
+			return err
+		}
+		o.Wake()
+		return nil
+	}
+
+	session, err := o.Client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	var hooks *repositories.CommitHooks
+	if _, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (any, error) {
+		var txCtx context.Context
+		txCtx, hooks = repositories.WithCommitHooks(sc)
+		return nil, fn(txCtx)
+	}); err != nil {
+		return err
+	}
+	hooks.Run()
+	o.Wake()
+	return nil
+}
+
+// Record บันทึก event ของ aggregate ชนิดตามส่วนหน้าของชื่อ event เช่น "order.created" เป็นของ "order"
+func (o *Outbox) Record(ctx context.Context, aggregateID string, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", event, err)
+	}
+	aggregateType, _, _ := strings.Cut(event, ".")
+	now := time.Now()
+	return o.Repo.Insert(ctx, &models.OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Event:         event,
+		Payload:       string(payload),
+		Status:        models.OutboxPending,
+		Delivered:     []string{},
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+}
+
+func (o *Outbox) Wake() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (o *Outbox) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(o.PollInterval)
+		defer ticker.Stop()
+		for {
+			o.publishPending(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-o.wake:
+			}
+		}
+	}()
+}
+
+// publishPending ส่ง event ตามลำดับที่บันทึก ถ้า event ใดของ aggregate หนึ่งยังส่งไม่สำเร็จหรือถูก worker อื่นจองอยู่
+// event ถัดไปของ aggregate เดียวกันจะรอไว้ก่อน เพื่อให้ผู้รับได้ event ของแต่ละออเดอร์ตามลำดับเสมอ
+func (o *Outbox) publishPending(ctx context.Context) {
+	events, err := o.Repo.FindPending(ctx, o.BatchSize)
+	if err != nil {
+		log.Printf("outbox: failed to load pending events: %v", err)
+		return
+	}
+
+	blocked := map[string]bool{}
+	for i := range events {
+		if ctx.Err() != nil {
+			return
+		}
+		event := &events[i]
+		key := event.AggregateType + ":" + event.AggregateID
+		if blocked[key] {
+			continue
+		}
+
+		now := time.Now()
+		if event.NextAttemptAt.After(now) {
+			blocked[key] = true
+			continue
+		}
+		claimed, err := o.Repo.Claim(ctx, event, now.Add(o.Lease))
+		if err != nil {
+			log.Printf("outbox: failed to claim event %s: %v", event.ID.Hex(), err)
+			return
+		}
+		if !claimed {
+			blocked[key] = true
+			continue
+		}
+
+		if !o.publish(ctx, event) {
+			blocked[key] = true
+		}
+	}
+}
+
+// publish ส่ง event ไปยัง sink ที่ยังไม่เคยส่งสำเร็จ คืน true เมื่อ event ไม่ค้างแล้ว (ส่งครบหรือเลิกส่ง)
+func (o *Outbox) publish(ctx context.Context, event *models.OutboxEvent) bool {
+	delivered := map[string]bool{}
+	for _, name := range event.Delivered {
+		delivered[name] = true
+	}
+
+	var failures []string
+	for _, sink := range o.Sinks {
+		if delivered[sink.Name()] {
+			continue
+		}
+		if err := sink.Publish(ctx, event); err != nil {
+			failures = append(failures, sink.Name()+": "+err.Error())
+			continue
+		}
+		if err := o.Repo.MarkDelivered(ctx, event.ID, sink.Name()); err != nil {
+			log.Printf("outbox: failed to mark event %s delivered to %s: %v", event.ID.Hex(), sink.Name(), err)
+		}
+	}
+
+	attempts := event.Attempts + 1
+	fields := bson.M{"attempts": attempts}
+	done := true
+	if len(failures) == 0 {
+		now := time.Now()
+		fields["status"] = models.OutboxPublished
+		fields["last_error"] = ""
+		fields["published_at"] = now
+	} else if attempts >= o.MaxAttempts {
+		log.Printf("outbox: giving up on event %s (%s) after %d attempts", event.ID.Hex(), event.Event, attempts)
+		fields["status"] = models.OutboxFailed
+		fields["last_error"] = strings.Join(failures, "; ")
+	} else {
+		fields["last_error"] = strings.Join(failures, "; ")
+		fields["next_attempt_at"] = time.Now().Add(o.backoff(attempts))
+		done = false
+	}
+	if err := o.Repo.Update(ctx, event.ID, fields); err != nil {
+		log.Printf("outbox: failed to update event %s: %v", event.ID.Hex(), err)
+		return false
+	}
+	return done
+}
+
+func (o *Outbox) backoff(attempts int) time.Duration {
+	delay := o.BaseDelay
+	for i := 1; i < attempts && delay < o.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > o.MaxDelay {
+		delay = o.MaxDelay
+	}
+	return delay
+}
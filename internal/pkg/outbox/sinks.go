@@ -0,0 +1,98 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/webhook"
+)
+
+type LogSink struct{}
+
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	log.Printf("outbox: %s %s/%s %s", event.Event, event.AggregateType, event.AggregateID, event.Payload)
+	return nil
+}
+
+type WebhookSink struct {
+	Dispatcher *webhook.Dispatcher
+}
+
+func NewWebhookSink(dispatcher *webhook.Dispatcher) *WebhookSink {
+	return &WebhookSink{Dispatcher: dispatcher}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	// ใช้ id ของ outbox เป็น event id ผู้รับจึงตัดรายการซ้ำได้แม้ outbox จะส่งซ้ำ
+	return s.Dispatcher.Publish(ctx, event.ID.Hex(), event.Event, event.AggregateID, json.RawMessage(event.Payload), event.CreatedAt)
+}
+
+// MessageBroker คือส่วนที่ต้องมีของ client NATS/Kafka
+// key คือ id ของ aggregate ใช้เลือก partition ให้ event ของออเดอร์เดียวกันอยู่ลำดับเดียวกัน
+type MessageBroker interface {
+	Publish(ctx context.Context, subject string, key string, data []byte) error
+}
+
+type BrokerSink struct {
+	Broker MessageBroker
+	Prefix string
+}
+
+func NewBrokerSink(broker MessageBroker, prefix string) *BrokerSink {
+	return &BrokerSink{Broker: broker, Prefix: prefix}
+}
+
+func (s *BrokerSink) Name() string {
+	return "broker"
+}
+
+func (s *BrokerSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	message, err := json.Marshal(map[string]any{
+		"id":             event.ID.Hex(),
+		"event":          event.Event,
+		"aggregate_type": event.AggregateType,
+		"aggregate_id":   event.AggregateID,
+		"created_at":     event.CreatedAt,
+		"data":           json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return err
+	}
+	return s.Broker.Publish(ctx, s.Prefix+event.Event, event.AggregateID, message)
+}
+
+type NotificationSink struct {
+	Recorder Recorder
+}
+
+func NewNotificationSink(recorder Recorder) *NotificationSink {
+	return &NotificationSink{Recorder: recorder}
+}
+
+func (s *NotificationSink) Name() string {
+	return "outbox"
+}
+
+func (s *NotificationSink) Send(ctx context.Context, notification *models.Notification) error {
+	if !webhook.ValidEvent(notification.Type) {
+		return nil
+	}
+	aggregateID, _ := notification.Data["product_id"].(string)
+	return s.Recorder.Transaction(ctx, func(ctx context.Context) error {
+		return s.Recorder.Record(ctx, aggregateID, notification.Type, notification.Data)
+	})
+}
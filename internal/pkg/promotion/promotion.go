@@ -0,0 +1,181 @@
+package promotion
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Line struct {
+	ProductID primitive.ObjectID
+	Quantity  int
+	UnitPrice models.Money
+}
+
+type Result struct {
+	LineDiscounts []int64
+	Applied       []models.AppliedPromotion
+	Total         models.Money
+}
+
+func Validate(promo *models.Promotion, now time.Time) error {
+	if !promo.IsActive {
+		return fmt.Errorf("promotion %s is not active", promo.Name)
+	}
+	if promo.StartsAt != nil && now.Before(*promo.StartsAt) {
+		return fmt.Errorf("promotion %s has not started", promo.Name)
+	}
+	if promo.ExpiresAt != nil && !now.Before(*promo.ExpiresAt) {
+		return fmt.Errorf("promotion %s has expired", promo.Name)
+	}
+	if promo.UsageLimit > 0 && promo.UsageCount >= promo.UsageLimit {
+		return fmt.Errorf("promotion %s has reached its usage limit", promo.Name)
+	}
+	return nil
+}
+
+// Apply คำนวณส่วนลดตามลำดับโปรโมชัน ส่วนลดของแต่ละบรรทัดจะไม่เกินยอดคงเหลือของบรรทัดนั้น
+// คูปองที่ไม่ผ่านเงื่อนไข (เช่น ยอดซื้อขั้นต่ำ) จะคืน error ส่วนโปรโมชันอัตโนมัติจะถูกข้ามไป
+// โปรโมชันใน locked ผูกกับออเดอร์ไปแล้วและนับการใช้แล้ว จึงไม่ตรวจช่วงเวลาและจำนวนครั้งซ้ำ
+func Apply(promos []models.Promotion, lines []Line, currency string, now time.Time, locked map[primitive.ObjectID]bool) (*Result, error) {
+	result := &Result{
+		LineDiscounts: make([]int64, len(lines)),
+		Total:         models.Money{Currency: currency},
+	}
+
+	var subtotal int64
+	for _, line := range lines {
+		subtotal += line.UnitPrice.Amount * int64(line.Quantity)
+	}
+
+	for i := range promos {
+		promo := &promos[i]
+		isCoupon := promo.Code != ""
+
+		if err := Validate(promo, now); err != nil && !locked[promo.ID] {
+			if isCoupon {
+				return nil, err
+			}
+			continue
+		}
+		if promo.MinSpend.Amount > 0 {
+			if promo.MinSpend.Currency != "" && promo.MinSpend.Currency != currency {
+				if isCoupon {
+					return nil, fmt.Errorf("promotion %s is not valid for %s", promo.Name, currency)
+				}
+				continue
+			}
+			if subtotal < promo.MinSpend.Amount {
+				if isCoupon {
+					return nil, fmt.Errorf("promotion %s requires a minimum spend", promo.Name)
+				}
+				continue
+			}
+		}
+
+		discounts := discountFor(promo, lines, result.LineDiscounts, currency)
+		var amount int64
+		for j, d := range discounts {
+			result.LineDiscounts[j] += d
+			amount += d
+		}
+		if amount == 0 {
+			if isCoupon {
+				return nil, fmt.Errorf("promotion %s does not apply to these items", promo.Name)
+			}
+			continue
+		}
+
+		result.Applied = append(result.Applied, models.AppliedPromotion{
+			PromotionID: promo.ID,
+			Name:        promo.Name,
+			Code:        promo.Code,
+			Type:        promo.Type,
+			Amount:      models.Money{Amount: amount, Currency: currency},
+		})
+		result.Total.Amount += amount
+	}
+
+	return result, nil
+}
+
+func discountFor(promo *models.Promotion, lines []Line, already []int64, currency string) []int64 {
+	discounts := make([]int64, len(lines))
+	remaining := make([]int64, len(lines))
+	var eligibleTotal int64
+	for i, line := range lines {
+		if !eligible(promo, line.ProductID) {
+			continue
+		}
+		remaining[i] = line.UnitPrice.Amount*int64(line.Quantity) - already[i]
+		eligibleTotal += remaining[i]
+	}
+	if eligibleTotal <= 0 {
+		return discounts
+	}
+
+	switch promo.Type {
+	case models.PromotionPercentage:
+		for i := range lines {
+			discounts[i] = (remaining[i]*promo.Percent + 5000) / 10000
+		}
+	case models.PromotionFixed:
+		if promo.Amount.Currency != "" && promo.Amount.Currency != currency {
+			return discounts
+		}
+		allocate(discounts, remaining, min(promo.Amount.Amount, eligibleTotal), eligibleTotal)
+	case models.PromotionBuyXGetY:
+		group := promo.BuyQuantity + promo.GetQuantity
+		if promo.BuyQuantity <= 0 || promo.GetQuantity <= 0 {
+			return discounts
+		}
+		for i, line := range lines {
+			if remaining[i] <= 0 {
+				continue
+			}
+			free := int64(line.Quantity/group) * int64(promo.GetQuantity)
+			discounts[i] = free * line.UnitPrice.Amount
+		}
+	}
+
+	for i := range discounts {
+		if discounts[i] > remaining[i] {
+			discounts[i] = remaining[i]
+		}
+		if discounts[i] < 0 {
+			discounts[i] = 0
+		}
+	}
+	return discounts
+}
+
+// allocate กระจายส่วนลดระดับออเดอร์ไปตามสัดส่วนยอดของแต่ละบรรทัด เศษที่เหลือให้บรรทัดสุดท้ายที่มีสิทธิ์
+func allocate(discounts []int64, remaining []int64, amount int64, total int64) {
+	var given int64
+	last := -1
+	for i := range remaining {
+		if remaining[i] <= 0 {
+			continue
+		}
+		discounts[i] = amount * remaining[i] / total
+		given += discounts[i]
+		last = i
+	}
+	if last >= 0 {
+		discounts[last] += amount - given
+	}
+}
+
+func eligible(promo *models.Promotion, productID primitive.ObjectID) bool {
+	if len(promo.ProductIDs) == 0 {
+		return true
+	}
+	for _, id := range promo.ProductIDs {
+		if id == productID {
+			return true
+		}
+	}
+	return false
+}
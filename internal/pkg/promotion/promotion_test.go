@@ -0,0 +1,160 @@
+package promotion
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining []int64
+		amount    int64
+		want      []int64
+	}{
+		{"even split", []int64{100, 100}, 50, []int64{25, 25}},
+		{"remainder goes to last line", []int64{1, 1, 1}, 100, []int64{33, 33, 34}},
+		{"proportional with remainder", []int64{300, 200, 100}, 100, []int64{50, 33, 17}},
+		{"remainder skips ineligible last line", []int64{2, 0, 1, 0}, 10, []int64{6, 0, 4, 0}},
+		{"whole amount", []int64{999, 1}, 1000, []int64{999, 1}},
+		{"nothing eligible", []int64{0, 0}, 10, []int64{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var total int64
+			for _, r := range tt.remaining {
+				total += max(r, 0)
+			}
+			got := make([]int64, len(tt.remaining))
+			if total > 0 {
+				allocate(got, tt.remaining, tt.amount, total)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allocate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	coupon, expired := primitive.NewObjectID(), now.Add(-time.Hour)
+	thb := func(amount int64) models.Money { return models.Money{Amount: amount, Currency: "THB"} }
+	line := func(id primitive.ObjectID, qty int, price int64) Line {
+		return Line{ProductID: id, Quantity: qty, UnitPrice: thb(price)}
+	}
+
+	tests := []struct {
+		name    string
+		promos  []models.Promotion
+		locked  map[primitive.ObjectID]bool
+		lines   []Line
+		want    []int64
+		total   int64
+		wantErr bool
+	}{
+		{
+			name:   "fixed split with remainder on last line",
+			promos: []models.Promotion{{Type: models.PromotionFixed, Amount: thb(100), IsActive: true}},
+			lines:  []Line{line(a, 1, 100), line(b, 1, 100), line(c, 1, 100)},
+			want:   []int64{33, 33, 34},
+			total:  100,
+		},
+		{
+			name:   "fixed capped at eligible total",
+			promos: []models.Promotion{{Type: models.PromotionFixed, Amount: thb(500), IsActive: true}},
+			lines:  []Line{line(a, 2, 100), line(b, 1, 100)},
+			want:   []int64{200, 100},
+			total:  300,
+		},
+		{
+			name:   "fixed limited to eligible products",
+			promos: []models.Promotion{{Type: models.PromotionFixed, Amount: thb(10), ProductIDs: []primitive.ObjectID{a, c}, IsActive: true}},
+			lines:  []Line{line(a, 1, 200), line(b, 1, 500), line(c, 1, 100)},
+			want:   []int64{6, 0, 4},
+			total:  10,
+		},
+		{
+			name:   "percentage rounds half up per line",
+			promos: []models.Promotion{{Type: models.PromotionPercentage, Percent: 1000, IsActive: true}},
+			lines:  []Line{line(a, 1, 105), line(b, 1, 15), line(c, 1, 14)},
+			want:   []int64{11, 2, 1},
+			total:  14,
+		},
+		{
+			name: "stacked promotions discount what is left",
+			promos: []models.Promotion{
+				{Type: models.PromotionPercentage, Percent: 5000, IsActive: true},
+				{Type: models.PromotionFixed, Amount: thb(100), IsActive: true},
+			},
+			lines: []Line{line(a, 1, 100), line(b, 1, 101)},
+			want:  []int64{50 + 50, 51 + 50},
+			total: 201,
+		},
+		{
+			name:   "buy two get one",
+			promos: []models.Promotion{{Type: models.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1, IsActive: true}},
+			lines:  []Line{line(a, 7, 30), line(b, 2, 50)},
+			want:   []int64{60, 0},
+			total:  60,
+		},
+		{
+			name:   "automatic promotion below minimum spend is skipped",
+			promos: []models.Promotion{{Type: models.PromotionFixed, Amount: thb(10), MinSpend: thb(1000), IsActive: true}},
+			lines:  []Line{line(a, 1, 100)},
+			want:   []int64{0},
+		},
+		{
+			name:    "coupon below minimum spend is rejected",
+			promos:  []models.Promotion{{Code: "SAVE", Type: models.PromotionFixed, Amount: thb(10), MinSpend: thb(1000), IsActive: true}},
+			lines:   []Line{line(a, 1, 100)},
+			wantErr: true,
+		},
+		{
+			name:    "inactive coupon is rejected",
+			promos:  []models.Promotion{{Code: "OLD", Type: models.PromotionFixed, Amount: thb(10)}},
+			lines:   []Line{line(a, 1, 100)},
+			wantErr: true,
+		},
+		{
+			name:   "locked coupon applies after expiry and usage limit",
+			promos: []models.Promotion{{ID: coupon, Code: "OLD", Type: models.PromotionFixed, Amount: thb(10), UsageLimit: 1, UsageCount: 1, ExpiresAt: &expired}},
+			locked: map[primitive.ObjectID]bool{coupon: true},
+			lines:  []Line{line(a, 1, 100)},
+			want:   []int64{10},
+			total:  10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Apply(tt.promos, tt.lines, "THB", now, tt.locked)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !reflect.DeepEqual(result.LineDiscounts, tt.want) {
+				t.Errorf("line discounts = %v, want %v", result.LineDiscounts, tt.want)
+			}
+			if result.Total.Amount != tt.total {
+				t.Errorf("total = %d, want %d", result.Total.Amount, tt.total)
+			}
+			var sum int64
+			for _, d := range result.LineDiscounts {
+				sum += d
+			}
+			if sum != result.Total.Amount {
+				t.Errorf("line discounts sum to %d, total is %d", sum, result.Total.Amount)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package promptpay
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/money"
+	"github.com/skip2/go-qrcode"
+)
+
+// รหัสตาม EMVCo merchant presented QR ที่ธนาคารแห่งประเทศไทยกำหนดสำหรับ PromptPay
+const (
+	idPayloadFormat   = "00"
+	idPointOfInit     = "01"
+	idMerchantAccount = "29"
+	idCurrency        = "53"
+	idAmount          = "54"
+	idCountry         = "58"
+	idCRC             = "63"
+
+	applicationID = "A000000677010111"
+	currencyTHB   = "764"
+	staticQR      = "11"
+	dynamicQR     = "12"
+	subPhone      = "01"
+	subNationalID = "02"
+	subEWalletID  = "03"
+)
+
+// Payload สร้างข้อความสำหรับ QR ถ้า amount เป็นศูนย์จะได้ QR แบบไม่ระบุยอดให้ผู้จ่ายกรอกเอง
+// id รับได้ทั้งเบอร์มือถือ เลขประจำตัวประชาชน/ผู้เสียภาษี 13 หลัก และ e-Wallet ID 15 หลัก
+func Payload(id string, amount models.Money) (string, error) {
+	if amount.Currency != "" && amount.Currency != "THB" {
+		return "", fmt.Errorf("PromptPay only supports THB, got %s", amount.Currency)
+	}
+	if amount.Amount < 0 {
+		return "", fmt.Errorf("amount must not be negative")
+	}
+
+	subID, target, err := normalizeID(id)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(field(idPayloadFormat, "01"))
+	if amount.Amount > 0 {
+		b.WriteString(field(idPointOfInit, dynamicQR))
+	} else {
+		b.WriteString(field(idPointOfInit, staticQR))
+	}
+	b.WriteString(field(idMerchantAccount, field("00", applicationID)+field(subID, target)))
+	b.WriteString(field(idCountry, "TH"))
+	b.WriteString(field(idCurrency, currencyTHB))
+	if amount.Amount > 0 {
+		b.WriteString(field(idAmount, money.Format(models.Money{Amount: amount.Amount, Currency: "THB"})))
+	}
+	b.WriteString(idCRC + "04")
+	b.WriteString(fmt.Sprintf("%04X", crc16(b.String())))
+	return b.String(), nil
+}
+
+// PNG สร้างรูป QR ขนาด size พิกเซล
+func PNG(payload string, size int) ([]byte, error) {
+	return qrcode.Encode(payload, qrcode.Medium, size)
+}
+
+func normalizeID(id string) (string, string, error) {
+	var digits strings.Builder
+	for _, r := range id {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	d := digits.String()
+
+	switch {
+	case len(d) == 10 && d[0] == '0':
+		// เบอร์มือถือ 08x-xxx-xxxx เขียนเป็น 0066 ตามด้วยเบอร์ที่ตัด 0 นำหน้า รวม 13 หลัก
+		return subPhone, "0066" + d[1:], nil
+	case len(d) == 11 && strings.HasPrefix(d, "66"):
+		return subPhone, "00" + d, nil
+	case len(d) == 13:
+		return subNationalID, d, nil
+	case len(d) == 15:
+		return subEWalletID, d, nil
+	}
+	return "", "", fmt.Errorf("invalid PromptPay ID: %s", id)
+}
+
+func field(id string, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// crc16 คือ CRC-16/CCITT-FALSE (poly 0x1021, init 0xFFFF) ตามที่ EMVCo กำหนด
+func crc16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
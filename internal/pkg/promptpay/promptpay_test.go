@@ -0,0 +1,96 @@
+package promptpay
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+)
+
+func TestCRC16(t *testing.T) {
+	tests := []struct {
+		data string
+		want uint16
+	}{
+		// ค่าตรวจสอบมาตรฐานของ CRC-16/CCITT-FALSE
+		{"123456789", 0x29B1},
+		{"", 0xFFFF},
+		{"00020101021229370016A000000677010111011300660000000005802TH530376454044.226304", 0xE469},
+	}
+	for _, tt := range tests {
+		if got := crc16(tt.data); got != tt.want {
+			t.Errorf("crc16(%q) = %04X, want %04X", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestPayload(t *testing.T) {
+	thb := func(amount int64) models.Money { return models.Money{Amount: amount, Currency: "THB"} }
+	tests := []struct {
+		name   string
+		id     string
+		amount models.Money
+		want   string
+	}{
+		{
+			// ตัวอย่างที่เผยแพร่ใน promptpay-qr
+			name:   "phone with amount",
+			id:     "000-000-0000",
+			amount: thb(422),
+			want:   "00020101021229370016A000000677010111011300660000000005802TH530376454044.226304E469",
+		},
+		{
+			name: "static phone",
+			id:   "0801234567",
+			want: "00020101021129370016A000000677010111011300668012345675802TH530376463046197",
+		},
+		{
+			name:   "phone with country code and amount without separators",
+			id:     "+66 80 123 4567",
+			amount: thb(123456),
+			want:   "00020101021229370016A000000677010111011300668012345675802TH530376454071234.566304835F",
+		},
+		{
+			name: "national id",
+			id:   "1-2345-67890-12-1",
+			want: "00020101021129370016A000000677010111021312345678901215802TH530376463041C03",
+		},
+		{
+			name: "e-wallet id",
+			id:   "123456789012345",
+			want: "00020101021129390016A00000067701011103151234567890123455802TH5303764630473AF",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Payload(tt.id, tt.amount)
+			if err != nil {
+				t.Fatalf("Payload: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Payload(%q) =\n%s\nwant\n%s", tt.id, got, tt.want)
+			}
+			if !strings.HasSuffix(got[:len(got)-4], idCRC+"04") {
+				t.Errorf("payload must end with the CRC field: %s", got)
+			}
+		})
+	}
+}
+
+func TestPayloadErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		amount models.Money
+	}{
+		{"non-THB currency", "0801234567", models.Money{Amount: 100, Currency: "USD"}},
+		{"negative amount", "0801234567", models.Money{Amount: -1, Currency: "THB"}},
+		{"short id", "12345", models.Money{}},
+		{"phone without leading zero", "8012345678", models.Money{}},
+	}
+	for _, tt := range tests {
+		if _, err := Payload(tt.id, tt.amount); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package rfm
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+)
+
+const (
+	SegmentChampions         = "Champions"
+	SegmentLoyal             = "Loyal"
+	SegmentPotentialLoyalist = "Potential Loyalist"
+	SegmentNew               = "New Customers"
+	SegmentPromising         = "Promising"
+	SegmentNeedsAttention    = "Needs Attention"
+	SegmentAboutToSleep      = "About To Sleep"
+	SegmentCannotLose        = "Cannot Lose Them"
+	SegmentAtRisk            = "At Risk"
+	SegmentHibernating       = "Hibernating"
+	SegmentLost              = "Lost"
+)
+
+var Segments = []string{
+	SegmentChampions, SegmentLoyal, SegmentPotentialLoyalist, SegmentNew, SegmentPromising, SegmentNeedsAttention,
+	SegmentAboutToSleep, SegmentCannotLose, SegmentAtRisk, SegmentHibernating, SegmentLost,
+}
+
+// Score ให้คะแนนลูกค้าแบบ quintile คือแบ่งลูกค้าเป็น 5 กลุ่มเท่าๆ กันตามแต่ละมิติ
+// ลูกค้าที่ค่าเท่ากันได้คะแนนเท่ากันเสมอ recency ยิ่งซื้อล่าสุดยิ่งได้คะแนนสูง
+func Score(stats []models.CustomerStats, now time.Time) []models.CustomerRFM {
+	rows := make([]models.CustomerRFM, len(stats))
+	for i, s := range stats {
+		rows[i] = models.CustomerRFM{
+			CustomerStats:      s,
+			DaysSinceLastOrder: int(now.Sub(s.LastOrderAt).Hours() / 24),
+		}
+	}
+
+	quintile(rows, func(r *models.CustomerRFM) float64 { return float64(-r.DaysSinceLastOrder) }, func(r *models.CustomerRFM, score int) { r.Recency = score })
+	quintile(rows, func(r *models.CustomerRFM) float64 { return float64(r.Orders) }, func(r *models.CustomerRFM, score int) { r.Frequency = score })
+	quintile(rows, func(r *models.CustomerRFM) float64 { return float64(r.LifetimeValue) }, func(r *models.CustomerRFM, score int) { r.Monetary = score })
+
+	for i := range rows {
+		rows[i].Score = fmt.Sprintf("%d%d%d", rows[i].Recency, rows[i].Frequency, rows[i].Monetary)
+		rows[i].Segment = Segment(rows[i].Recency, rows[i].Frequency)
+	}
+	return rows
+}
+
+func quintile(rows []models.CustomerRFM, value func(*models.CustomerRFM) float64, set func(*models.CustomerRFM, int)) {
+	order := make([]int, len(rows))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return value(&rows[order[a]]) < value(&rows[order[b]])
+	})
+
+	rank := 0
+	for pos, i := range order {
+		if pos == 0 || value(&rows[i]) != value(&rows[order[pos-1]]) {
+			rank = pos
+		}
+		set(&rows[i], rank*5/len(rows)+1)
+	}
+}
+
+// Segment จัดกลุ่มจากคะแนน recency และ frequency ตามตาราง RFM ที่ใช้ทั่วไป
+func Segment(recency int, frequency int) string {
+	switch {
+	case recency >= 4 && frequency >= 4:
+		return SegmentChampions
+	case recency == 3 && frequency >= 4:
+		return SegmentLoyal
+	case recency >= 4 && frequency >= 2:
+		return SegmentPotentialLoyalist
+	case recency == 5 && frequency == 1:
+		return SegmentNew
+	case recency == 4 && frequency == 1:
+		return SegmentPromising
+	case recency == 3 && frequency == 3:
+		return SegmentNeedsAttention
+	case recency == 3:
+		return SegmentAboutToSleep
+	case frequency == 5:
+		return SegmentCannotLose
+	case frequency >= 3:
+		return SegmentAtRisk
+	case recency == 2:
+		return SegmentHibernating
+	}
+	return SegmentLost
+}
@@ -0,0 +1,42 @@
+package rfm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+)
+
+func TestQuintile(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int
+		want   []int
+	}{
+		{"single row", []int{42}, []int{1}},
+		{"ten distinct values", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, []int{1, 1, 2, 2, 3, 3, 4, 4, 5, 5}},
+		{"five distinct values", []int{50, 10, 40, 20, 30}, []int{5, 1, 4, 2, 3}},
+		{"all equal share the lowest score", []int{5, 5, 5, 5, 5}, []int{1, 1, 1, 1, 1}},
+		{"ties take the rank of the first equal value", []int{1, 2, 2, 3}, []int{1, 2, 2, 4}},
+		{"ties at the top", []int{1, 2, 3, 9, 9, 9}, []int{1, 1, 2, 3, 3, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := make([]models.CustomerRFM, len(tt.values))
+			for i, v := range tt.values {
+				rows[i].Orders = v
+			}
+			quintile(rows,
+				func(r *models.CustomerRFM) float64 { return float64(r.Orders) },
+				func(r *models.CustomerRFM, score int) { r.Frequency = score })
+
+			got := make([]int, len(rows))
+			for i := range rows {
+				got[i] = rows[i].Frequency
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("quintile(%v) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package search
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type Field struct {
+	Values []string
+	Weight float64
+}
+
+type Document struct {
+	Key    int
+	Fields []Field
+	Boost  float64
+}
+
+type Result struct {
+	Key   int
+	Score float64
+}
+
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		// เครื่องหมายวรรณยุกต์และทัณฑฆาตมักพิมพ์ผิด/ตกหล่นตอนค้นหา จึงไม่นำมาเทียบ
+		if r >= 0x0E48 && r <= 0x0E4C {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Pattern คืน regex ที่ค้นข้อความเดิมในฐานข้อมูลได้โดยไม่สนวรรณยุกต์ ตรงกับที่ Normalize ตัดออก
+func Pattern(s string) string {
+	var b strings.Builder
+	for _, r := range Normalize(s) {
+		b.WriteString(regexp.QuoteMeta(string(r)))
+		if r >= 0x0E00 && r <= 0x0E7F {
+			b.WriteString(`[\x{0E48}-\x{0E4C}]*`)
+		}
+	}
+	return b.String()
+}
+
+// FuzzyPattern คืน regex สำหรับคัดสินค้าก่อนเทียบคำสะกดผิด คืนค่าว่างถ้าไม่มีคำค้นที่ยาวพอจะยอมให้สะกดผิด
+// คำที่ต่างจากคำค้นไม่เกิน k ตำแหน่งต้องมีอย่างน้อยหนึ่งใน k+1 ส่วนของคำค้นอยู่ครบ จึงใช้ส่วนเหล่านี้คัดได้โดยไม่ตกหล่น
+func FuzzyPattern(query string) string {
+	var parts []string
+	for _, term := range Tokenize(query) {
+		k := typoTolerance(term)
+		if k == 0 {
+			continue
+		}
+		runes := []rune(term)
+		size := len(runes) / (k + 1)
+		for i := 0; i <= k; i++ {
+			end := (i + 1) * size
+			if i == k {
+				end = len(runes)
+			}
+			parts = append(parts, Pattern(string(runes[i*size:end])))
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(Normalize(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r)
+	})
+}
+
+func Rank(query string, docs []Document, limit int) []Result {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []Result
+	for _, doc := range docs {
+		score := scoreDocument(terms, doc)
+		if score > 0 {
+			results = append(results, Result{Key: doc.Key, Score: score + doc.Boost})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func scoreDocument(terms []string, doc Document) float64 {
+	var total float64
+	for _, term := range terms {
+		var best float64
+		for _, field := range doc.Fields {
+			for _, value := range field.Values {
+				if s := scoreTerm(term, value) * field.Weight; s > best {
+					best = s
+				}
+			}
+		}
+		// ทุกคำค้นต้องตรงอย่างน้อยหนึ่งฟิลด์
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total
+}
+
+func scoreTerm(term, value string) float64 {
+	value = Normalize(value)
+	if value == "" {
+		return 0
+	}
+	if value == term {
+		return 10
+	}
+
+	tokens := Tokenize(value)
+	for _, token := range tokens {
+		if token == term {
+			return 8
+		}
+	}
+	for _, token := range tokens {
+		if strings.HasPrefix(token, term) {
+			return 6
+		}
+	}
+	// ภาษาไทยไม่เว้นวรรคระหว่างคำ จึงต้องเทียบแบบ substring ด้วย
+	if strings.Contains(value, term) {
+		return 4
+	}
+
+	maxDist := typoTolerance(term)
+	if maxDist == 0 {
+		return 0
+	}
+	for _, token := range tokens {
+		if levenshtein(term, token) <= maxDist {
+			return 2
+		}
+		tr := []rune(token)
+		if len(tr) > len([]rune(term)) && levenshtein(term, string(tr[:len([]rune(term))])) <= maxDist {
+			return 1
+		}
+	}
+	return 0
+}
+
+func typoTolerance(term string) int {
+	switch n := len([]rune(term)); {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,164 @@
+package shipping
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+)
+
+var (
+	ErrNoRate           = fmt.Errorf("carrier does not deliver to this province or parcel weight")
+	ErrCurrencyMismatch = fmt.Errorf("shipping rate currency does not match the order")
+)
+
+func Validate(carrier *models.Carrier) error {
+	if len(carrier.Rates) == 0 {
+		return fmt.Errorf("at least one rate is required")
+	}
+	for i, rate := range carrier.Rates {
+		if rate.Service == "" {
+			return fmt.Errorf("rate %d: service is required", i+1)
+		}
+		currency := rate.Fee.Currency
+		switch rate.Type {
+		case models.RateFlat:
+			if rate.Fee.Amount < 0 {
+				return fmt.Errorf("rate %d: fee must not be negative", i+1)
+			}
+		case models.RateWeight:
+			if len(rate.Tiers) == 0 {
+				return fmt.Errorf("rate %d: weight rate needs at least one tier", i+1)
+			}
+			currency = rate.Tiers[0].Fee.Currency
+			for j, tier := range rate.Tiers {
+				if tier.UpToGrams <= 0 || tier.Fee.Amount < 0 {
+					return fmt.Errorf("rate %d: tier %d must have positive weight and non-negative fee", i+1, j+1)
+				}
+				if j > 0 && tier.UpToGrams <= rate.Tiers[j-1].UpToGrams {
+					return fmt.Errorf("rate %d: tiers must be sorted by weight", i+1)
+				}
+				if tier.Fee.Currency != currency {
+					return fmt.Errorf("rate %d: all tiers must use the same currency", i+1)
+				}
+			}
+		default:
+			return fmt.Errorf("rate %d: type must be flat or weight", i+1)
+		}
+		if rate.FreeOver != nil && rate.FreeOver.Currency != currency {
+			return fmt.Errorf("rate %d: free_over must use the same currency as the fee", i+1)
+		}
+	}
+	return nil
+}
+
+// Quote คิดค่าส่งของบริการหนึ่งไปยังจังหวัดปลายทาง ใช้โซนที่ระบุจังหวัดไว้ก่อนโซนที่ใช้ได้ทุกจังหวัด
+// orderTotal คือยอดสินค้ารวมภาษีหลังหักส่วนลด ใช้เทียบเกณฑ์ส่งฟรี
+func Quote(carrier *models.Carrier, service string, province string, weightGrams int, orderTotal models.Money) (*models.ShippingMethod, error) {
+	rate := findRate(carrier.Rates, service, province)
+	if rate == nil {
+		return nil, ErrNoRate
+	}
+
+	fee := rate.Fee
+	if rate.Type == models.RateWeight {
+		found := false
+		for _, tier := range rate.Tiers {
+			if weightGrams <= tier.UpToGrams {
+				fee, found = tier.Fee, true
+				break
+			}
+		}
+		if !found {
+			return nil, ErrNoRate
+		}
+	}
+	if fee.Currency != orderTotal.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	method := &models.ShippingMethod{
+		CarrierID:   carrier.ID,
+		CarrierCode: carrier.Code,
+		CarrierName: carrier.Name,
+		Service:     rate.Service,
+		Zone:        rate.Zone,
+		WeightGrams: weightGrams,
+		Fee:         fee,
+	}
+	if rate.FreeOver != nil && orderTotal.Amount >= rate.FreeOver.Amount {
+		method.Fee = models.Money{Amount: 0, Currency: fee.Currency}
+		method.Free = true
+	}
+	return method, nil
+}
+
+// Options คืนทุกบริการที่ส่งไปจังหวัดนี้ได้ เรียงจากค่าส่งถูกไปแพง
+func Options(carriers []models.Carrier, province string, weightGrams int, orderTotal models.Money) []models.ShippingMethod {
+	options := []models.ShippingMethod{}
+	for i := range carriers {
+		if !carriers[i].IsActive {
+			continue
+		}
+		for _, service := range services(carriers[i].Rates) {
+			if method, err := Quote(&carriers[i], service, province, weightGrams, orderTotal); err == nil {
+				options = append(options, *method)
+			}
+		}
+	}
+	sort.SliceStable(options, func(a, b int) bool {
+		return options[a].Fee.Amount < options[b].Fee.Amount
+	})
+	return options
+}
+
+func Cheapest(carrier *models.Carrier, province string, weightGrams int, orderTotal models.Money) (*models.ShippingMethod, error) {
+	options := Options([]models.Carrier{*carrier}, province, weightGrams, orderTotal)
+	if len(options) == 0 {
+		return nil, ErrNoRate
+	}
+	return &options[0], nil
+}
+
+// TrackingURL แทน {tracking} ในลิงก์ติดตามพัสดุของผู้ให้บริการ
+func TrackingURL(carrier *models.Carrier, trackingNumber string) string {
+	if carrier.TrackingURL == "" || trackingNumber == "" {
+		return ""
+	}
+	return strings.ReplaceAll(carrier.TrackingURL, "{tracking}", trackingNumber)
+}
+
+func findRate(rates []models.ShippingRate, service string, province string) *models.ShippingRate {
+	var fallback *models.ShippingRate
+	for i := range rates {
+		rate := &rates[i]
+		if rate.Service != service {
+			continue
+		}
+		if len(rate.Provinces) == 0 {
+			if fallback == nil {
+				fallback = rate
+			}
+			continue
+		}
+		for _, p := range rate.Provinces {
+			if p == province {
+				return rate
+			}
+		}
+	}
+	return fallback
+}
+
+func services(rates []models.ShippingRate) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, rate := range rates {
+		if !seen[rate.Service] {
+			seen[rate.Service] = true
+			names = append(names, rate.Service)
+		}
+	}
+	return names
+}
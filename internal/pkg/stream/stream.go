@@ -0,0 +1,162 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Event คือการเปลี่ยนแปลงของออเดอร์ที่ส่งให้หน้าจอที่เปิด stream ค้างไว้
+// ID คือ id ของ outbox event ใช้เป็น Last-Event-ID ตอนเชื่อมต่อใหม่
+type Event struct {
+	ID        string             `json:"id"`
+	Type      string             `json:"type"`
+	OrderID   string             `json:"order_id"`
+	CreatedBy primitive.ObjectID `json:"-"`
+	Data      json.RawMessage    `json:"data"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// Broker กระจาย event ให้ผู้ฟังทุกคน ตัวที่ใช้อยู่ทำงานในหน่วยความจำของ process เดียว
+// ถ้ารันหลาย instance ให้เปลี่ยนเป็นตัวที่อ่านจาก Mongo change streams โดยคง interface นี้ไว้
+type Broker interface {
+	Publish(event Event)
+	// Subscribe คืน event ที่ตามหลัง lastEventID (ถ้ายังอยู่ในประวัติ) และช่องทางรับ event ใหม่
+	Subscribe(lastEventID string) ([]Event, *Subscription)
+}
+
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	broker *MemoryBroker
+	once   sync.Once
+}
+
+// Close เลิกฟัง event ช่องทาง C จะถูกปิดด้วย ซึ่งเกิดขึ้นเองเช่นกันเมื่อผู้ฟังรับ event ไม่ทันจนถูกตัดออก
+func (s *Subscription) Close() {
+	s.once.Do(func() { s.broker.remove(s) })
+}
+
+type MemoryBroker struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	history     []Event
+	HistorySize int
+	BufferSize  int
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subscribers: map[*Subscription]struct{}{},
+		HistorySize: 256,
+		BufferSize:  64,
+	}
+}
+
+func (b *MemoryBroker) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.history = append(b.history, event)
+	if len(b.history) > b.HistorySize {
+		b.history = b.history[len(b.history)-b.HistorySize:]
+	}
+
+	for sub := range b.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+			// ผู้ฟังที่ค้างจะถูกตัด ช่องทางปิดลงและ client ต่อใหม่พร้อม Last-Event-ID เพื่อรับส่วนที่ขาด
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+func (b *MemoryBroker) Subscribe(lastEventID string) ([]Event, *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, b.BufferSize)
+	sub := &Subscription{C: ch, ch: ch, broker: b}
+	b.subscribers[sub] = struct{}{}
+
+	if lastEventID == "" {
+		return nil, sub
+	}
+	for i, event := range b.history {
+		if event.ID == lastEventID {
+			return append([]Event(nil), b.history[i+1:]...), sub
+		}
+	}
+	return nil, sub
+}
+
+func (b *MemoryBroker) remove(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// OutboxSink ส่ง event ของออเดอร์จาก outbox เข้า broker
+type OutboxSink struct {
+	Broker Broker
+}
+
+func NewOutboxSink(broker Broker) *OutboxSink {
+	return &OutboxSink{Broker: broker}
+}
+
+func (s *OutboxSink) Name() string {
+	return "stream"
+}
+
+func (s *OutboxSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	if event.AggregateType != "order" {
+		return nil
+	}
+
+	// order.created ส่งตัวออเดอร์มาตรงๆ ส่วน order.status_changed ห่อออเดอร์ไว้ใน "order"
+	var payload struct {
+		CreatedBy primitive.ObjectID `json:"CreatedBy"`
+		Order     *struct {
+			CreatedBy primitive.ObjectID `json:"CreatedBy"`
+		} `json:"order"`
+	}
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return err
+	}
+	createdBy := payload.CreatedBy
+	if payload.Order != nil {
+		createdBy = payload.Order.CreatedBy
+	}
+
+	s.Broker.Publish(Event{
+		ID:        event.ID.Hex(),
+		Type:      event.Event,
+		OrderID:   event.AggregateID,
+		CreatedBy: createdBy,
+		Data:      json.RawMessage(event.Payload),
+		CreatedAt: event.CreatedAt,
+	})
+	return nil
+}
+
+// Visible ใช้กติกาเดียวกับ OrderRepository.FindAll คือ Admin เห็นทุกออเดอร์
+// Staff เห็นออเดอร์ที่ตัวเองสร้างและออเดอร์ที่ไม่มีผู้สร้าง
+func Visible(event Event, userID primitive.ObjectID, role string) bool {
+	switch role {
+	case "Admin":
+		return true
+	case "Staff":
+		return event.CreatedBy == userID || event.CreatedBy.IsZero()
+	}
+	return false
+}
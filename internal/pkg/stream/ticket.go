@@ -0,0 +1,62 @@
+package stream
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Ticket ใช้เปิด stream แทน JWT เพราะ EventSource/WebSocket ของเบราว์เซอร์ต้องส่งตัวยืนยันทาง query
+// ซึ่งถูกเขียนลง access log ตั๋วจึงใช้ได้ครั้งเดียวและหมดอายุเร็ว
+type Ticket struct {
+	UserID    string
+	Role      string
+	ExpiresAt time.Time
+}
+
+// Tickets เก็บตั๋วไว้ในหน่วยความจำของ process เดียวเหมือน MemoryBroker
+type Tickets struct {
+	TTL     time.Duration
+	mu      sync.Mutex
+	tickets map[string]Ticket
+}
+
+func NewTickets(ttl time.Duration) *Tickets {
+	return &Tickets{TTL: ttl, tickets: map[string]Ticket{}}
+}
+
+func (t *Tickets) Issue(userID, role string) (string, Ticket, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", Ticket{}, err
+	}
+	token := hex.EncodeToString(buf)
+	now := time.Now()
+	ticket := Ticket{UserID: userID, Role: role, ExpiresAt: now.Add(t.TTL)}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, existing := range t.tickets {
+		if now.After(existing.ExpiresAt) {
+			delete(t.tickets, key)
+		}
+	}
+	t.tickets[token] = ticket
+	return token, ticket, nil
+}
+
+// Redeem คืนตั๋วและลบทิ้งทันที ตั๋วที่หมดอายุหรือถูกใช้ไปแล้วจะคืน false
+func (t *Tickets) Redeem(token string) (Ticket, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ticket, ok := t.tickets[token]
+	if !ok {
+		return Ticket{}, false
+	}
+	delete(t.tickets, token)
+	if time.Now().After(ticket.ExpiresAt) {
+		return Ticket{}, false
+	}
+	return ticket, true
+}
@@ -0,0 +1,124 @@
+package tax
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+)
+
+const (
+	ClassStandard = "standard"
+	ClassZero     = "zero"
+	ClassExempt   = "exempt"
+)
+
+// อัตราภาษีเก็บเป็น basis point (700 = 7%)
+type Calculator struct {
+	Rates            map[string]int64
+	PricesIncludeTax bool
+	ShippingClass    string // tax class ของค่าจัดส่ง ค่าส่งเป็นส่วนหนึ่งของมูลค่าขายจึงคิดภาษีตามปกติ
+}
+
+type Line struct {
+	TaxClass string
+	Rate     int64
+	Net      models.Money
+	Tax      models.Money
+	Gross    models.Money
+}
+
+func NewCalculatorFromEnv() (*Calculator, error) {
+	calc := &Calculator{
+		Rates: map[string]int64{
+			ClassStandard: 700,
+			ClassZero:     0,
+			ClassExempt:   0,
+		},
+		PricesIncludeTax: os.Getenv("PRICES_INCLUDE_TAX") == "true",
+		ShippingClass:    Class(strings.TrimSpace(os.Getenv("SHIPPING_TAX_CLASS"))),
+	}
+
+	if v := os.Getenv("VAT_RATE"); v != "" {
+		rate, err := parseRate(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid VAT_RATE: %w", err)
+		}
+		calc.Rates[ClassStandard] = rate
+	}
+
+	// TAX_RATES=standard=7,reduced=3 ใช้เพิ่มหรือแทนที่ tax class
+	for _, pair := range strings.Split(os.Getenv("TAX_RATES"), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		class, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid TAX_RATES entry: %s", pair)
+		}
+		rate, err := parseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TAX_RATES entry %s: %w", pair, err)
+		}
+		calc.Rates[strings.ToLower(strings.TrimSpace(class))] = rate
+	}
+	if !calc.ValidClass(calc.ShippingClass) {
+		return nil, fmt.Errorf("unknown SHIPPING_TAX_CLASS: %s", calc.ShippingClass)
+	}
+	return calc, nil
+}
+
+func (c *Calculator) ValidClass(class string) bool {
+	_, ok := c.Rates[Class(class)]
+	return ok
+}
+
+func Class(class string) string {
+	if class == "" {
+		return ClassStandard
+	}
+	return strings.ToLower(class)
+}
+
+func (c *Calculator) Calculate(unitPrice models.Money, quantity int, class string) (Line, error) {
+	return c.CalculateAmount(unitPrice.Mul(quantity), class)
+}
+
+func (c *Calculator) CalculateAmount(amount models.Money, class string) (Line, error) {
+	class = Class(class)
+	rate, ok := c.Rates[class]
+	if !ok {
+		return Line{}, fmt.Errorf("unknown tax class: %s", class)
+	}
+
+	var err error
+	line := Line{TaxClass: class, Rate: rate}
+	if c.PricesIncludeTax {
+		line.Gross = amount
+		line.Tax = models.Money{Amount: divRound(amount.Amount*rate, 10000+rate), Currency: amount.Currency}
+		line.Net, err = amount.Sub(line.Tax)
+	} else {
+		line.Net = amount
+		line.Tax = models.Money{Amount: divRound(amount.Amount*rate, 10000), Currency: amount.Currency}
+		line.Gross, err = amount.Add(line.Tax)
+	}
+	return line, err
+}
+
+func parseRate(v string) (int64, error) {
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(v), "%"), 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("rate must be a percentage between 0 and 100")
+	}
+	return int64(percent*100 + 0.5), nil
+}
+
+// divRound หารแล้วปัดครึ่งขึ้น (half away from zero)
+func divRound(n, d int64) int64 {
+	if n < 0 {
+		return -divRound(-n, d)
+	}
+	return (n + d/2) / d
+}
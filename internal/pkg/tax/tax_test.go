@@ -0,0 +1,77 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+)
+
+func TestCalculateAmountRounding(t *testing.T) {
+	rates := map[string]int64{ClassStandard: 700, ClassZero: 0, "reduced": 350}
+	tests := []struct {
+		name            string
+		includeTax      bool
+		amount          int64
+		class           string
+		net, tax, gross int64
+	}{
+		{"exclusive exact", false, 10000, ClassStandard, 10000, 700, 10700},
+		{"exclusive rounds half up", false, 50, ClassStandard, 50, 4, 54}, // 3.5 -> 4
+		{"exclusive rounds down", false, 21, ClassStandard, 21, 1, 22},    // 1.47 -> 1
+		{"exclusive rounds up", false, 22, ClassStandard, 22, 2, 24},      // 1.54 -> 2
+		{"exclusive fractional rate", false, 100, "reduced", 100, 4, 104}, // 3.5 -> 4
+		{"inclusive exact", true, 10700, ClassStandard, 10000, 700, 10700},
+		{"inclusive rounds", true, 100, ClassStandard, 93, 7, 100},          // 6.54 -> 7
+		{"inclusive rounds down", true, 1000, ClassStandard, 935, 65, 1000}, // 65.42 -> 65
+		{"inclusive small exact", true, 535, ClassStandard, 500, 35, 535},
+		{"zero rate", false, 999, ClassZero, 999, 0, 999},
+		{"default class", false, 10000, "", 10000, 700, 10700},
+		{"negative amount rounds away from zero", false, -50, ClassStandard, -50, -4, -54},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := &Calculator{Rates: rates, PricesIncludeTax: tt.includeTax}
+			line, err := calc.CalculateAmount(models.Money{Amount: tt.amount, Currency: "THB"}, tt.class)
+			if err != nil {
+				t.Fatalf("CalculateAmount: %v", err)
+			}
+			if line.Net.Amount != tt.net || line.Tax.Amount != tt.tax || line.Gross.Amount != tt.gross {
+				t.Errorf("got net=%d tax=%d gross=%d, want net=%d tax=%d gross=%d",
+					line.Net.Amount, line.Tax.Amount, line.Gross.Amount, tt.net, tt.tax, tt.gross)
+			}
+			if line.Net.Amount+line.Tax.Amount != line.Gross.Amount {
+				t.Errorf("net + tax must equal gross")
+			}
+		})
+	}
+}
+
+func TestCalculateAmountUnknownClass(t *testing.T) {
+	calc := &Calculator{Rates: map[string]int64{ClassStandard: 700}}
+	if _, err := calc.CalculateAmount(models.Money{Amount: 100, Currency: "THB"}, "luxury"); err == nil {
+		t.Error("expected error for unknown tax class")
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"7", 700, false},
+		{"7%", 700, false},
+		{" 3.5 ", 350, false},
+		{"0", 0, false},
+		{"0.07", 7, false},
+		{"101", 0, true},
+		{"-1", 0, true},
+		{"seven", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseRate(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseRate(%q) = %d, %v; want %d, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
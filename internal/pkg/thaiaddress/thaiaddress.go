@@ -0,0 +1,193 @@
+package thaiaddress
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// postcodes.csv เป็นชุดข้อมูลตัวอย่างที่ฝังมากับโปรแกรม ใช้ไฟล์ชุดเต็มรูปแบบเดียวกันได้ผ่าน Load
+// รหัสไปรษณีย์ที่ไม่อยู่ในชุดข้อมูลจะถูกรับตามที่กรอกผ่าน Unlisted แทนการปฏิเสธ
+//
+//go:embed postcodes.csv
+var bundled []byte
+
+var (
+	ErrUnknownPostcode = fmt.Errorf("unknown postcode")
+	ErrMismatch        = fmt.Errorf("subdistrict, district and province do not match the postcode")
+)
+
+// Entry คือตำบล/แขวงหนึ่งแห่งกับรหัสไปรษณีย์
+type Entry struct {
+	Subdistrict string `json:"subdistrict"`
+	District    string `json:"district"`
+	Province    string `json:"province"`
+	Postcode    string `json:"postcode"`
+}
+
+type Dataset struct {
+	entries    []Entry
+	byPostcode map[string][]int
+}
+
+// Load อ่านชุดข้อมูลจากไฟล์ CSV (province,district,subdistrict,postcode) ถ้า path ว่างใช้ชุดที่ฝังมา
+func Load(path string) (*Dataset, error) {
+	if path == "" {
+		return Parse(bytes.NewReader(bundled))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+func Parse(r io.Reader) (*Dataset, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, name := range []string{"province", "district", "subdistrict", "postcode"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	d := &Dataset{byPostcode: map[string][]int{}}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry := Entry{
+			Subdistrict: strings.TrimSpace(record[columns["subdistrict"]]),
+			District:    strings.TrimSpace(record[columns["district"]]),
+			Province:    strings.TrimSpace(record[columns["province"]]),
+			Postcode:    strings.TrimSpace(record[columns["postcode"]]),
+		}
+		d.byPostcode[entry.Postcode] = append(d.byPostcode[entry.Postcode], len(d.entries))
+		d.entries = append(d.entries, entry)
+	}
+	if len(d.entries) == 0 {
+		return nil, fmt.Errorf("dataset is empty")
+	}
+	return d, nil
+}
+
+func (d *Dataset) Len() int {
+	return len(d.entries)
+}
+
+// Lookup คืนทุกตำบลที่ใช้รหัสไปรษณีย์นี้
+func (d *Dataset) Lookup(postcode string) []Entry {
+	entries := []Entry{}
+	for _, i := range d.byPostcode[strings.TrimSpace(postcode)] {
+		entries = append(entries, d.entries[i])
+	}
+	return entries
+}
+
+// Search หาตำบล อำเภอ หรือจังหวัดที่ชื่อขึ้นต้นด้วยคำค้น ใช้ทำ autocomplete
+func (d *Dataset) Search(query string, limit int) []Entry {
+	entries := []Entry{}
+	q := key(query)
+	if q == "" {
+		return entries
+	}
+	for _, entry := range d.entries {
+		if strings.HasPrefix(entry.Postcode, q) ||
+			strings.HasPrefix(key(entry.Subdistrict), q) ||
+			strings.HasPrefix(key(entry.District), q) ||
+			strings.HasPrefix(key(entry.Province), q) {
+			entries = append(entries, entry)
+			if len(entries) == limit {
+				break
+			}
+		}
+	}
+	return entries
+}
+
+// Resolve ตรวจว่าตำบล อำเภอ จังหวัด และรหัสไปรษณีย์เข้ากันได้ และคืนชื่อตามชุดข้อมูล
+// ชื่อที่ส่งมาจะมีคำนำหน้าอย่าง "ต." "แขวง" "อำเภอ" "จ." หรือไม่ก็ได้
+func (d *Dataset) Resolve(subdistrict string, district string, province string, postcode string) (Entry, error) {
+	candidates := d.byPostcode[strings.TrimSpace(postcode)]
+	if len(candidates) == 0 {
+		return Entry{}, ErrUnknownPostcode
+	}
+	s, a, p := key(subdistrict), key(district), provinceKey(province)
+	if a == "เมือง" {
+		// อำเภอเมืองมักเขียนย่อโดยไม่มีชื่อจังหวัดต่อท้าย
+		a += p
+	}
+	for _, i := range candidates {
+		entry := d.entries[i]
+		if key(entry.Subdistrict) == s && key(entry.District) == a && provinceKey(entry.Province) == p {
+			return entry, nil
+		}
+	}
+	return Entry{}, ErrMismatch
+}
+
+// Unlisted คืนที่อยู่ตามที่กรอกสำหรับรหัสไปรษณีย์ที่ไม่อยู่ในชุดข้อมูล ตัดคำนำหน้าออกให้เก็บรูปแบบเดียวกับชุดข้อมูล
+// ชุดข้อมูลที่ฝังมาไม่ครบทุกจังหวัด จึงไม่ควรปฏิเสธที่อยู่เพียงเพราะไม่พบรหัสไปรษณีย์
+func Unlisted(subdistrict string, district string, province string, postcode string) Entry {
+	return Entry{
+		Subdistrict: trimPrefix(subdistrict),
+		District:    trimPrefix(district),
+		Province:    provinceName(province),
+		Postcode:    strings.TrimSpace(postcode),
+	}
+}
+
+func trimPrefix(name string) string {
+	name = strings.TrimSpace(name)
+	for _, prefix := range prefixes {
+		if rest := strings.TrimSpace(strings.TrimPrefix(name, prefix)); rest != name && rest != "" {
+			return rest
+		}
+	}
+	return name
+}
+
+func provinceName(name string) string {
+	if provinceKey(name) == "กรุงเทพมหานคร" {
+		return "กรุงเทพมหานคร"
+	}
+	return trimPrefix(name)
+}
+
+var prefixes = []string{"ตำบล", "ต.", "แขวง", "อำเภอ", "อ.", "เขต", "จังหวัด", "จ."}
+
+// key ตัดคำนำหน้าและช่องว่างออก ให้ "ต. บางรัก" กับ "บางรัก" เทียบกันได้
+func key(name string) string {
+	name = strings.ToLower(strings.Join(strings.Fields(name), ""))
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			return name[len(prefix):]
+		}
+	}
+	return name
+}
+
+func provinceKey(name string) string {
+	switch k := key(name); k {
+	case "กรุงเทพ", "กรุงเทพฯ", "กทม", "กทม.", "bangkok":
+		return "กรุงเทพมหานคร"
+	default:
+		return k
+	}
+}
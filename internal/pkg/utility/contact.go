@@ -0,0 +1,27 @@
+package utility
+
+import "strings"
+
+// NormalizeEmail ใช้เทียบอีเมลลูกค้า ตัดช่องว่างและไม่สนตัวพิมพ์เล็กใหญ่
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizePhone เก็บเฉพาะตัวเลขและแปลงรหัสประเทศ +66 เป็น 0 นำหน้า
+// เช่น "+66 81-234-5678" และ "081 234 5678" ได้ "0812345678" เหมือนกัน
+func NormalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	normalized := digits.String()
+	if strings.HasPrefix(normalized, "0066") {
+		normalized = normalized[2:]
+	}
+	if strings.HasPrefix(normalized, "66") && len(normalized) == 11 {
+		normalized = "0" + normalized[2:]
+	}
+	return normalized
+}
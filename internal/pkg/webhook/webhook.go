@@ -0,0 +1,287 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	EventOrderCreated       = "order.created"
+	EventOrderStatusChanged = "order.status_changed"
+	EventOrderItemsChanged  = "order.items_changed"
+	EventProductLowStock    = "product.low_stock"
+	EventProductUpdated     = "product.updated"
+	EventPing               = "ping"
+)
+
+var Events = []string{EventOrderCreated, EventOrderStatusChanged, EventOrderItemsChanged, EventProductLowStock, EventProductUpdated}
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+type Dispatcher struct {
+	SubscriptionRepo repositories.WebhookSubscriptionRepositoryInterface
+	DeliveryRepo     repositories.WebhookDeliveryRepositoryInterface
+	Client           *http.Client
+	MaxAttempts      int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	PollInterval     time.Duration
+	wake             chan struct{}
+}
+
+func NewDispatcher(subscriptionRepo repositories.WebhookSubscriptionRepositoryInterface, deliveryRepo repositories.WebhookDeliveryRepositoryInterface) *Dispatcher {
+	return &Dispatcher{
+		SubscriptionRepo: subscriptionRepo,
+		DeliveryRepo:     deliveryRepo,
+		Client:           &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts:      8,
+		BaseDelay:        30 * time.Second,
+		MaxDelay:         time.Hour,
+		PollInterval:     15 * time.Second,
+		wake:             make(chan struct{}, 1),
+	}
+}
+
+func ValidEvent(event string) bool {
+	if event == "*" {
+		return true
+	}
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSecret สร้าง secret สำหรับ subscription ใหม่
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign คืนลายเซ็น HMAC-SHA256 ของ "<timestamp>.<body>" ฝั่งผู้รับคำนวณแบบเดียวกันแล้วเทียบกับ header
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff คือระยะรอก่อนส่งครั้งถัดไปหลังจากล้มเหลวไปแล้ว attempts ครั้ง เพิ่มเป็นเท่าตัวจนถึง MaxDelay
+func (d *Dispatcher) Backoff(attempts int) time.Duration {
+	delay := d.BaseDelay
+	for i := 1; i < attempts && delay < d.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.MaxDelay {
+		delay = d.MaxDelay
+	}
+	return delay
+}
+
+// Publish บันทึกรายการส่งให้ทุก subscription ที่สมัคร event นี้ไว้ แล้วปลุก worker ให้ส่ง
+// eventID เดิมที่ส่งมาซ้ำจะไม่สร้างรายการส่งใหม่ให้ subscription ที่มีรายการอยู่แล้ว
+// รายการที่มี aggregateID เดียวกันจะถูกส่งตามลำดับที่ Publish
+func (d *Dispatcher) Publish(ctx context.Context, eventID string, event string, aggregateID string, data any, createdAt time.Time) error {
+	subs, err := d.SubscriptionRepo.FindActiveForEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"id":         eventID,
+		"event":      event,
+		"created_at": createdAt,
+		"data":       data,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if err := d.enqueue(ctx, sub.ID, eventID, event, aggregateID, payload, time.Now()); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	d.Wake()
+	return nil
+}
+
+// Ping ส่ง event ทดสอบไปยัง subscription เดียวโดยไม่สนใจว่าสมัคร event ใดไว้
+func (d *Dispatcher) Ping(ctx context.Context, sub *models.WebhookSubscription) error {
+	eventID := primitive.NewObjectID().Hex()
+	now := time.Now()
+	payload, err := json.Marshal(map[string]any{
+		"id":         eventID,
+		"event":      EventPing,
+		"created_at": now,
+		"data":       map[string]any{"subscription_id": sub.ID.Hex()},
+	})
+	if err != nil {
+		return err
+	}
+	if err := d.enqueue(ctx, sub.ID, eventID, EventPing, "", payload, now); err != nil {
+		return err
+	}
+	d.Wake()
+	return nil
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, subscriptionID primitive.ObjectID, eventID string, event string, aggregateID string, payload []byte, now time.Time) error {
+	return d.DeliveryRepo.Insert(ctx, &models.WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		Event:          event,
+		AggregateID:    aggregateID,
+		Payload:        string(payload),
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	})
+}
+
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(d.PollInterval)
+		defer ticker.Stop()
+		for {
+			d.deliverDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-d.wake:
+			}
+		}
+	}()
+}
+
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		// lease ต้องนานกว่า timeout ของ client ไม่เช่นนั้นอาจมี worker อื่นหยิบรายการเดียวกันไปส่งซ้ำ
+		delivery, err := d.DeliveryRepo.ClaimDue(ctx, time.Now(), d.Client.Timeout+time.Minute)
+		if err == mongo.ErrNoDocuments {
+			return
+		}
+		if err != nil {
+			log.Printf("webhook: failed to claim deliveries: %v", err)
+			return
+		}
+		d.deliver(ctx, delivery)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	sub, err := d.SubscriptionRepo.FindByID(ctx, delivery.SubscriptionID)
+	if err == mongo.ErrNoDocuments || (err == nil && !sub.IsActive) {
+		d.finish(ctx, delivery, bson.M{"status": models.WebhookDeliveryFailed, "last_error": "subscription removed or inactive"})
+		return
+	}
+	if err != nil {
+		log.Printf("webhook: failed to load subscription %s: %v", delivery.SubscriptionID.Hex(), err)
+		return
+	}
+
+	if delivery.AggregateID != "" {
+		earlier, err := d.DeliveryRepo.FindEarlierPending(ctx, delivery)
+		if err == nil {
+			// พักไว้จนรายการก่อนหน้าเสร็จ ReleaseNext จะปลุกรายการนี้ ถ้าไม่ถูกปลุกจะลองใหม่ตามเวลาของรายการก่อนหน้า
+			d.finish(ctx, delivery, bson.M{"next_attempt_at": earlier.NextAttemptAt.Add(time.Second)})
+			return
+		}
+		if err != mongo.ErrNoDocuments {
+			log.Printf("webhook: failed to check earlier deliveries for %s: %v", delivery.ID.Hex(), err)
+			return
+		}
+	}
+
+	status, err := d.send(ctx, sub, delivery)
+	attempts := delivery.Attempts + 1
+	fields := bson.M{"attempts": attempts, "response_status": status}
+	if err == nil {
+		fields["status"] = models.WebhookDeliverySucceeded
+		fields["last_error"] = ""
+		fields["delivered_at"] = time.Now()
+	} else if attempts >= d.MaxAttempts {
+		fields["status"] = models.WebhookDeliveryFailed
+		fields["last_error"] = err.Error()
+	} else {
+		fields["last_error"] = err.Error()
+		fields["next_attempt_at"] = time.Now().Add(d.Backoff(attempts))
+	}
+	d.finish(ctx, delivery, fields)
+	if _, done := fields["status"]; done && delivery.AggregateID != "" {
+		if err := d.DeliveryRepo.ReleaseNext(ctx, delivery, time.Now()); err != nil {
+			log.Printf("webhook: failed to release deliveries after %s: %v", delivery.ID.Hex(), err)
+		}
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.EventID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, timestamp, body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) finish(ctx context.Context, delivery *models.WebhookDelivery, fields bson.M) {
+	if err := d.DeliveryRepo.Update(ctx, delivery.ID, fields); err != nil {
+		log.Printf("webhook: failed to update delivery %s: %v", delivery.ID.Hex(), err)
+	}
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+)
+
+type commitHooksKey struct{}
+
+// CommitHooks เก็บงานที่ต้องรอให้ transaction commit ก่อน เช่นแจ้ง listener ว่าสต็อกเปลี่ยน
+// งานที่ใช้ key เดียวกันจะถูกเรียกครั้งเดียว
+type CommitHooks struct {
+	mu    sync.Mutex
+	keys  map[string]bool
+	hooks []func()
+}
+
+// WithCommitHooks คืน ctx ที่ AfterCommit จะเก็บงานไว้แทนการเรียกทันที ผู้เปิด transaction ต้องเรียก Run หลัง commit
+func WithCommitHooks(ctx context.Context) (context.Context, *CommitHooks) {
+	hooks := &CommitHooks{keys: map[string]bool{}}
+	return context.WithValue(ctx, commitHooksKey{}, hooks), hooks
+}
+
+// AfterCommit เรียก fn หลัง transaction ของ ctx commit แล้ว ถ้า ctx ไม่อยู่ใน transaction จะเรียกทันที
+func AfterCommit(ctx context.Context, key string, fn func()) {
+	hooks, ok := ctx.Value(commitHooksKey{}).(*CommitHooks)
+	if !ok {
+		fn()
+		return
+	}
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	if hooks.keys[key] {
+		return
+	}
+	hooks.keys[key] = true
+	hooks.hooks = append(hooks.hooks, fn)
+}
+
+func (h *CommitHooks) Run() {
+	h.mu.Lock()
+	hooks := h.hooks
+	h.hooks, h.keys = nil, map[string]bool{}
+	h.mu.Unlock()
+	for _, fn := range hooks {
+		fn()
+	}
+}
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CounterRepositoryInterface interface {
+	Next(ctx context.Context, name string) (int64, error)
+}
+
+type CounterRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewCounterRepository(collection *mongo.Collection) *CounterRepository {
+	return &CounterRepository{Collection: collection}
+}
+
+func (r *CounterRepository) Next(ctx context.Context, name string) (int64, error) {
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.Collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": name},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	return counter.Seq, err
+}
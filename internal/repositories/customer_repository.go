@@ -3,19 +3,29 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/utility"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type CustomerRepositoryInterface interface {
 	FindAll(ctx context.Context, role string) ([]models.Customer, error)
 	Insert(ctx context.Context, customer *models.Customer, role string) (*mongo.InsertOneResult, error)
-	FindByEmail(ctx context.Context, email string, role string) (*models.Customer, error)
-	FindByID(ctx context.Context, id string, role string) (*models.Customer, error)
+	FindMatch(ctx context.Context, email string, phone string, role string) (*models.Customer, error)
+	FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.Customer, error)
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error)
+	MarkMerged(ctx context.Context, ids []primitive.ObjectID, survivorID primitive.ObjectID, role string) (*mongo.UpdateResult, error)
+	EnsureIndexes(ctx context.Context) error
 }
 
+// ลูกค้าที่ถูกรวมไปแล้วจะไม่ถูกจับคู่หรือแสดงในรายการอีก
+var activeCustomer = bson.M{"merged_into": bson.M{"$exists": false}}
+
 type CustomerRepository struct {
 	Collection *mongo.Collection
 }
@@ -24,11 +34,43 @@ func NewCustomerRepository(collection *mongo.Collection) *CustomerRepository {
 	return &CustomerRepository{Collection: collection}
 }
 
+// EnsureIndexes สร้าง index สำหรับจับคู่ลูกค้า และเติม email_key/phone_key ให้ลูกค้าที่สร้างก่อนมีฟิลด์นี้
+func (r *CustomerRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"email_key": 1}},
+		{Keys: bson.M{"phone_key": 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	cursor, err := r.Collection.Find(ctx, bson.M{"email_key": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var customer models.Customer
+		if err := cursor.Decode(&customer); err != nil {
+			return err
+		}
+		_, err := r.Collection.UpdateOne(ctx, bson.M{"_id": customer.ID}, bson.M{"$set": bson.M{
+			"email_key": utility.NormalizeEmail(customer.Email),
+			"phone_key": utility.NormalizePhone(customer.Phone),
+		}})
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
 func (r *CustomerRepository) FindAll(ctx context.Context, role string) ([]models.Customer, error) {
 	if role != "Admin" && role != "Staff" {
 		return nil, fmt.Errorf("unauthorized role")
 	}
-	cursor, err := r.Collection.Find(ctx, bson.M{})
+	cursor, err := r.Collection.Find(ctx, activeCustomer)
 	if err != nil {
 		return nil, err
 	}
@@ -49,22 +91,56 @@ func (r *CustomerRepository) Insert(ctx context.Context, customer *models.Custom
 	if role != "Admin" && role != "Staff" {
 		return nil, fmt.Errorf("unauthorized role")
 	}
+	customer.EmailKey = utility.NormalizeEmail(customer.Email)
+	customer.PhoneKey = utility.NormalizePhone(customer.Phone)
 	result, err := r.Collection.InsertOne(ctx, customer)
 	return result, err
 }
 
-func (r *CustomerRepository) FindByEmail(ctx context.Context, email string, role string) (*models.Customer, error) {
+// FindMatch หาลูกค้าเดิมจากอีเมลหรือเบอร์โทรที่ normalise แล้ว ถ้าพบหลายรายจะเลือกรายที่อีเมลตรงก่อน
+// แล้วจึงเป็นรายที่สร้างก่อน ไม่พบคืน mongo.ErrNoDocuments
+func (r *CustomerRepository) FindMatch(ctx context.Context, email string, phone string, role string) (*models.Customer, error) {
 	if role != "Admin" && role != "Staff" {
 		return nil, fmt.Errorf("unauthorized role")
 	}
-	var customer models.Customer
-	if err := r.Collection.FindOne(ctx, bson.M{"email": email}).Decode(&customer); err != nil {
+	emailKey := utility.NormalizeEmail(email)
+	phoneKey := utility.NormalizePhone(phone)
+
+	var keys []bson.M
+	if emailKey != "" {
+		keys = append(keys, bson.M{"email_key": emailKey})
+	}
+	if phoneKey != "" {
+		keys = append(keys, bson.M{"phone_key": phoneKey})
+	}
+	if len(keys) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	cursor, err := r.Collection.Find(ctx,
+		bson.M{"$and": []bson.M{activeCustomer, {"$or": keys}}},
+		options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
 		return nil, err
 	}
-	return &customer, nil
+	defer cursor.Close(ctx)
+
+	var candidates []models.Customer
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	for i := range candidates {
+		if emailKey != "" && candidates[i].EmailKey == emailKey {
+			return &candidates[i], nil
+		}
+	}
+	return &candidates[0], nil
 }
 
-func (r *CustomerRepository) FindByID(ctx context.Context, id string, role string) (*models.Customer, error) {
+func (r *CustomerRepository) FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.Customer, error) {
 	if role != "Admin" && role != "Staff" {
 		return nil, fmt.Errorf("unauthorized role")
 	}
@@ -74,3 +150,34 @@ func (r *CustomerRepository) FindByID(ctx context.Context, id string, role strin
 	}
 	return &customer, nil
 }
+
+// Update แก้ข้อมูลลูกค้า ถ้าแก้ email หรือ phone จะคำนวณ key สำหรับจับคู่ใหม่ให้ด้วย
+func (r *CustomerRepository) Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	if email, ok := fields["email"].(string); ok {
+		fields["email_key"] = utility.NormalizeEmail(email)
+	}
+	if phone, ok := fields["phone"].(string); ok {
+		fields["phone_key"] = utility.NormalizePhone(phone)
+	}
+	fields["updated_at"] = time.Now()
+	result, err := r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	return result, err
+}
+
+// MarkMerged บันทึกว่าลูกค้าใน ids ถูกรวมเข้ากับ survivorID แล้ว ข้ามรายที่ถูกรวมไปก่อนหน้า
+func (r *CustomerRepository) MarkMerged(ctx context.Context, ids []primitive.ObjectID, survivorID primitive.ObjectID, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	now := time.Now()
+	filter := bson.M{"$and": []bson.M{activeCustomer, {"_id": bson.M{"$in": ids}}}}
+	result, err := r.Collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{
+		"merged_into": survivorID,
+		"merged_at":   now,
+		"updated_at":  now,
+	}})
+	return result, err
+}
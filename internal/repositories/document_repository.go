@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DocumentRepositoryInterface interface {
+	FindByOrder(ctx context.Context, orderID primitive.ObjectID, docType string) (*models.Document, error)
+	Insert(ctx context.Context, doc *models.Document) error
+	EnsureIndexes(ctx context.Context) error
+}
+
+type DocumentRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewDocumentRepository(collection *mongo.Collection) *DocumentRepository {
+	return &DocumentRepository{Collection: collection}
+}
+
+// EnsureIndexes กันไม่ให้ออกเอกสารประเภทเดียวกันซ้ำสองใบต่อหนึ่งออเดอร์ และเลขที่เอกสารต้องไม่ซ้ำ
+func (r *DocumentRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "order_id", Value: 1}, {Key: "type", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.M{"number": 1},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	return err
+}
+
+func (r *DocumentRepository) FindByOrder(ctx context.Context, orderID primitive.ObjectID, docType string) (*models.Document, error) {
+	var doc models.Document
+	if err := r.Collection.FindOne(ctx, bson.M{"order_id": orderID, "type": docType}).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (r *DocumentRepository) Insert(ctx context.Context, doc *models.Document) error {
+	result, err := r.Collection.InsertOne(ctx, doc)
+	if err != nil {
+		return err
+	}
+	doc.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocationRepositoryInterface interface {
+	FindAll(ctx context.Context) ([]models.Location, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Location, error)
+	Insert(ctx context.Context, location *models.Location, role string) error
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error)
+	EnsureDefault(ctx context.Context) (*models.Location, error)
+}
+
+type LocationRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewLocationRepository(collection *mongo.Collection) *LocationRepository {
+	return &LocationRepository{Collection: collection}
+}
+
+func (r *LocationRepository) FindAll(ctx context.Context) ([]models.Location, error) {
+	cursor, err := r.Collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "is_default", Value: -1}, {Key: "code", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var locations []models.Location
+	for cursor.Next(ctx) {
+		var location models.Location
+		if err := cursor.Decode(&location); err != nil {
+			return nil, err
+		}
+		locations = append(locations, location)
+	}
+	return locations, nil
+}
+
+func (r *LocationRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Location, error) {
+	var location models.Location
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&location); err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
+
+func (r *LocationRepository) Insert(ctx context.Context, location *models.Location, role string) error {
+	if role != "Admin" {
+		return fmt.Errorf("unauthorized role")
+	}
+	result, err := r.Collection.InsertOne(ctx, location)
+	if err != nil {
+		return err
+	}
+	location.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *LocationRepository) Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	return r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+}
+
+// EnsureDefault สร้างหน้าร้านเป็นคลังเริ่มต้นหากยังไม่มี สต็อกเดิมก่อนมีหลายคลังจะถูกย้ายมาไว้ที่นี่
+func (r *LocationRepository) EnsureDefault(ctx context.Context) (*models.Location, error) {
+	var location models.Location
+	err := r.Collection.FindOneAndUpdate(ctx,
+		bson.M{"is_default": true},
+		bson.M{"$setOnInsert": bson.M{
+			"code":       "SHOP",
+			"name":       "หน้าร้าน",
+			"type":       "shop",
+			"is_default": true,
+			"is_active":  true,
+			"created_at": time.Now(),
+		}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&location)
+	if err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationRepositoryInterface interface {
+	Insert(ctx context.Context, notification *models.Notification) error
+	FindRecent(ctx context.Context, unreadOnly bool, limit int64) ([]models.Notification, error)
+	MarkRead(ctx context.Context, id primitive.ObjectID) (*mongo.UpdateResult, error)
+}
+
+type NotificationRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewNotificationRepository(collection *mongo.Collection) *NotificationRepository {
+	return &NotificationRepository{Collection: collection}
+}
+
+func (r *NotificationRepository) Insert(ctx context.Context, notification *models.Notification) error {
+	result, err := r.Collection.InsertOne(ctx, notification)
+	if err != nil {
+		return err
+	}
+	notification.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *NotificationRepository) FindRecent(ctx context.Context, unreadOnly bool, limit int64) ([]models.Notification, error) {
+	filter := bson.M{}
+	if unreadOnly {
+		filter["read"] = false
+	}
+
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var notifications []models.Notification
+	for cursor.Next(ctx) {
+		var notification models.Notification
+		if err := cursor.Decode(&notification); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, nil
+}
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, id primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"read": true}})
+}
@@ -3,21 +3,36 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/simple-business-management-api/go-backend-api/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type OrderRepositoryInterface interface {
 	FindAll(ctx context.Context, userID primitive.ObjectID, role string) ([]models.Order, error)
 	Insert(ctx context.Context, order *models.Order, role string) error
 	FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.Order, error)
+	FindByCustomer(ctx context.Context, customerID primitive.ObjectID, userID primitive.ObjectID, role string) ([]models.Order, error)
+	FindReadyToShip(ctx context.Context, userID primitive.ObjectID, role string, skip int, limit int) ([]models.Order, error)
 	Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error)
+	ApplyReturn(ctx context.Context, id primitive.ObjectID, current []models.OrderItem, returned map[int]int, fields bson.M, role string) (*mongo.UpdateResult, error)
+	ApplyRevision(ctx context.Context, id primitive.ObjectID, version int, current []models.OrderItem, fields bson.M, revision models.OrderRevision, role string) (*mongo.UpdateResult, error)
+	FindBackordered(ctx context.Context, productID primitive.ObjectID) ([]models.Order, error)
+	BackorderedQuantities(ctx context.Context, productIDs []primitive.ObjectID) (map[primitive.ObjectID]int, error)
+	AllocateBackorder(ctx context.Context, id primitive.ObjectID, index int, productID primitive.ObjectID, from primitive.ObjectID, to primitive.ObjectID, quantity int) (bool, error)
+	ReassignCustomer(ctx context.Context, from []primitive.ObjectID, to primitive.ObjectID, role string) (*mongo.UpdateResult, error)
 	Delete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, role string) (*mongo.DeleteResult, error)
+	ApplyPayment(ctx context.Context, id primitive.ObjectID, amount int64, paidAt time.Time) (*models.Order, error)
+	ApplyRefund(ctx context.Context, id primitive.ObjectID, amount int64) (*models.Order, error)
 }
 
+var ErrPaymentRejected = fmt.Errorf("payment exceeds outstanding balance or order cannot be paid")
+var ErrRefundRejected = fmt.Errorf("refund exceeds amount paid")
+
 type OrderRepository struct {
 	Collection *mongo.Collection
 }
@@ -64,8 +79,12 @@ func (r *OrderRepository) Insert(ctx context.Context, order *models.Order, role
 	if role != "Admin" && role != "Staff" {
 		return fmt.Errorf("unauthorized role")
 	}
-	_, err := r.Collection.InsertOne(ctx, order)
-	return err
+	result, err := r.Collection.InsertOne(ctx, order)
+	if err != nil {
+		return err
+	}
+	order.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
 }
 
 func (r *OrderRepository) FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.Order, error) {
@@ -79,6 +98,57 @@ func (r *OrderRepository) FindByID(ctx context.Context, id primitive.ObjectID, r
 	return &order, nil
 }
 
+// FindByCustomer คืนออเดอร์ของลูกค้าเรียงจากใหม่ไปเก่า Staff เห็นเฉพาะออเดอร์ตามกติกาเดียวกับ FindAll
+func (r *OrderRepository) FindByCustomer(ctx context.Context, customerID primitive.ObjectID, userID primitive.ObjectID, role string) ([]models.Order, error) {
+	filter := bson.M{"customer_id": customerID}
+	switch role {
+	case "Admin":
+	case "Staff":
+		filter["$or"] = []bson.M{{"created_by": userID}, {"created_by": primitive.NilObjectID}}
+	default:
+		return nil, fmt.Errorf("unauthorized role")
+	}
+
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	orders := []models.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// FindReadyToShip คืนออเดอร์ที่ชำระแล้วและไม่มีรายการรอสต็อก เรียงจากเก่าไปใหม่ ให้ออเดอร์ที่รอนานที่สุดถูกแพ็กก่อน
+func (r *OrderRepository) FindReadyToShip(ctx context.Context, userID primitive.ObjectID, role string, skip int, limit int) ([]models.Order, error) {
+	filter := bson.M{"status": "Paid", "items.backordered": bson.M{"$not": bson.M{"$gt": 0}}}
+	switch role {
+	case "Admin":
+	case "Staff":
+		filter["$or"] = []bson.M{{"created_by": userID}, {"created_by": primitive.NilObjectID}}
+	default:
+		return nil, fmt.Errorf("unauthorized role")
+	}
+
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	orders := []models.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
 func (r *OrderRepository) Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error) {
 	if role != "Admin" && role != "Staff" {
 		return nil, fmt.Errorf("unauthorized role")
@@ -87,6 +157,147 @@ func (r *OrderRepository) Update(ctx context.Context, id primitive.ObjectID, fie
 	return result, err
 }
 
+// ApplyReturn เพิ่มจำนวนที่รับคืนของรายการลำดับ index ตาม returned ด้วย $inc และตั้ง fields เพิ่ม
+// อัปเดตเฉพาะเมื่อรายการยังเป็นสินค้าเดิมและจำนวนที่รับคืนยังเท่ากับใน current ที่อ่านมา
+func (r *OrderRepository) ApplyReturn(ctx context.Context, id primitive.ObjectID, current []models.OrderItem, returned map[int]int, fields bson.M, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	filter := bson.M{"_id": id}
+	inc := bson.M{}
+	for index, quantity := range returned {
+		key := fmt.Sprintf("items.%d", index)
+		filter[key+".product_id"] = current[index].ProductID
+		if current[index].Returned == 0 {
+			filter[key+".returned"] = bson.M{"$in": bson.A{0, nil}}
+		} else {
+			filter[key+".returned"] = current[index].Returned
+		}
+		inc[key+".returned"] = quantity
+	}
+	update := bson.M{}
+	if len(inc) > 0 {
+		update["$inc"] = inc
+	}
+	if len(fields) > 0 {
+		update["$set"] = fields
+	}
+	if len(update) == 0 {
+		matched, err := r.Collection.CountDocuments(ctx, filter)
+		return &mongo.UpdateResult{MatchedCount: matched}, err
+	}
+	return r.Collection.UpdateOne(ctx, filter, update)
+}
+
+// ApplyRevision แก้รายการสินค้าของออเดอร์ที่ยัง Pending และต่อท้ายประวัติการแก้ไข
+// version คือจำนวนประวัติที่อ่านมา ถ้ามีคำขออื่นแก้ไปก่อน MatchedCount จะเป็น 0
+// current คือรายการที่อ่านมา ใช้กันไม่ให้ทับจำนวน backorder ที่งานจัดสรรสต็อกเพิ่งลดไป
+func (r *OrderRepository) ApplyRevision(ctx context.Context, id primitive.ObjectID, version int, current []models.OrderItem, fields bson.M, revision models.OrderRevision, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	filter := bson.M{"_id": id, "status": bson.M{"$in": bson.A{"Pending", "pending"}}}
+	if version == 0 {
+		filter["$or"] = []bson.M{{"history": bson.M{"$exists": false}}, {"history": bson.M{"$size": 0}}}
+	} else {
+		filter["history"] = bson.M{"$size": version}
+	}
+	for i, item := range current {
+		if item.Backordered > 0 {
+			filter[fmt.Sprintf("items.%d.backordered", i)] = item.Backordered
+		}
+	}
+	return r.Collection.UpdateOne(ctx, filter, bson.M{"$set": fields, "$push": bson.M{"history": revision}})
+}
+
+// FindBackordered คืนออเดอร์ที่ยังรอสินค้านี้อยู่ เรียงจากเก่าไปใหม่ให้ออเดอร์ที่สั่งก่อนได้รับสต็อกก่อน
+func (r *OrderRepository) FindBackordered(ctx context.Context, productID primitive.ObjectID) ([]models.Order, error) {
+	filter := bson.M{
+		"items":  bson.M{"$elemMatch": bson.M{"product_id": productID, "backordered": bson.M{"$gt": 0}}},
+		"status": bson.M{"$nin": bson.A{"Cancelled", "cancelled", "Refunded"}},
+	}
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	orders := []models.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// BackorderedQuantities รวมจำนวนที่ออเดอร์ยังรอสต็อกแยกตามสินค้า ถ้า productIDs เป็น nil จะคืนทุกสินค้าที่มีคนรอ
+func (r *OrderRepository) BackorderedQuantities(ctx context.Context, productIDs []primitive.ObjectID) (map[primitive.ObjectID]int, error) {
+	line := bson.M{"backordered": bson.M{"$gt": 0}}
+	if productIDs != nil {
+		line["product_id"] = bson.M{"$in": productIDs}
+	}
+	unwound := bson.M{"items.backordered": bson.M{"$gt": 0}}
+	if productIDs != nil {
+		unwound["items.product_id"] = bson.M{"$in": productIDs}
+	}
+	cursor, err := r.Collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"items":  bson.M{"$elemMatch": line},
+			"status": bson.M{"$nin": bson.A{"Cancelled", "cancelled", "Refunded"}},
+		}}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$match", Value: unwound}},
+		{{Key: "$group", Value: bson.M{"_id": "$items.product_id", "backordered": bson.M{"$sum": "$items.backordered"}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ProductID   primitive.ObjectID `bson:"_id"`
+		Backordered int                `bson:"backordered"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	quantities := map[primitive.ObjectID]int{}
+	for _, row := range rows {
+		quantities[row.ProductID] = row.Backordered
+	}
+	return quantities, nil
+}
+
+// AllocateBackorder ลดจำนวนที่รอสต็อกของรายการลำดับ index ลง quantity และย้ายรายการไปคลัง to
+// อัปเดตเฉพาะเมื่อรายการยังเป็นสินค้าและคลังเดิมและยังรออย่างน้อย quantity ถ้าออเดอร์ถูกแก้หรือยกเลิกไปก่อนจะคืน false
+func (r *OrderRepository) AllocateBackorder(ctx context.Context, id primitive.ObjectID, index int, productID primitive.ObjectID, from primitive.ObjectID, to primitive.ObjectID, quantity int) (bool, error) {
+	key := fmt.Sprintf("items.%d", index)
+	filter := bson.M{
+		"_id":                id,
+		"status":             bson.M{"$nin": bson.A{"Cancelled", "cancelled", "Refunded"}},
+		key + ".product_id":  productID,
+		key + ".location_id": from,
+		key + ".backordered": bson.M{"$gte": quantity},
+	}
+	update := bson.M{
+		"$inc": bson.M{key + ".backordered": -quantity},
+		"$set": bson.M{key + ".location_id": to},
+	}
+	result, err := r.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// ReassignCustomer ย้ายออเดอร์ทั้งหมดของลูกค้าใน from ไปเป็นของลูกค้า to ใช้ตอนรวมลูกค้าซ้ำ
+func (r *OrderRepository) ReassignCustomer(ctx context.Context, from []primitive.ObjectID, to primitive.ObjectID, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	result, err := r.Collection.UpdateMany(ctx, bson.M{"customer_id": bson.M{"$in": from}}, bson.M{"$set": bson.M{"customer_id": to}})
+	return result, err
+}
+
 func (r *OrderRepository) Delete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, role string) (*mongo.DeleteResult, error) {
 
 	filter := bson.M{"_id": id}
@@ -102,3 +313,82 @@ func (r *OrderRepository) Delete(ctx context.Context, id primitive.ObjectID, use
 	result, err := r.Collection.DeleteOne(ctx, filter)
 	return result, err
 }
+
+// ApplyPayment เพิ่มยอดที่ชำระแล้วแบบ atomic โดยไม่ให้เกินยอดรวม และเปลี่ยนสถานะเป็น Paid เมื่อชำระครบ
+// คืน ErrPaymentRejected ถ้าออเดอร์ถูกยกเลิกไปแล้วหรือยอดเกินที่ค้างอยู่
+func (r *OrderRepository) ApplyPayment(ctx context.Context, id primitive.ObjectID, amount int64, paidAt time.Time) (*models.Order, error) {
+	paid := bson.M{"$ifNull": bson.A{"$paid_amount.amount", 0}}
+	filter := bson.M{
+		"_id":    id,
+		"status": bson.M{"$nin": bson.A{"Cancelled", "cancelled"}},
+		"$expr":  bson.M{"$lte": bson.A{bson.M{"$add": bson.A{paid, amount}}, "$total_amount.amount"}},
+	}
+	settled := bson.M{"$gte": bson.A{"$paid_amount.amount", "$total_amount.amount"}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"paid_amount": bson.M{
+				"amount":   bson.M{"$add": bson.A{paid, amount}},
+				"currency": "$total_amount.currency",
+			},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"status": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{settled, bson.M{"$in": bson.A{"$status", bson.A{"Pending", "pending"}}}}},
+				"Paid",
+				"$status",
+			}},
+			"paid_at": bson.M{"$cond": bson.A{
+				settled,
+				bson.M{"$ifNull": bson.A{"$paid_at", paidAt}},
+				"$$REMOVE",
+			}},
+		}}},
+	}
+
+	var order models.Order
+	err := r.Collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrPaymentRejected
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// ApplyRefund เพิ่มยอดคืนเงินของออเดอร์โดยไม่ให้เกินยอดที่ชำระ และเปลี่ยนสถานะเป็น Refunded เมื่อคืนครบ
+func (r *OrderRepository) ApplyRefund(ctx context.Context, id primitive.ObjectID, amount int64) (*models.Order, error) {
+	refunded := bson.M{"$ifNull": bson.A{"$refunded_amount.amount", 0}}
+	filter := bson.M{
+		"_id":   id,
+		"$expr": bson.M{"$lte": bson.A{bson.M{"$add": bson.A{refunded, amount}}, bson.M{"$ifNull": bson.A{"$paid_amount.amount", 0}}}},
+	}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"refunded_amount": bson.M{
+				"amount":   bson.M{"$add": bson.A{refunded, amount}},
+				"currency": "$total_amount.currency",
+			},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"status": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{
+					bson.M{"$gt": bson.A{"$refunded_amount.amount", 0}},
+					bson.M{"$gte": bson.A{"$refunded_amount.amount", "$paid_amount.amount"}},
+				}},
+				"Refunded",
+				"$status",
+			}},
+		}}},
+	}
+
+	var order models.Order
+	err := r.Collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRefundRejected
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OutboxRepositoryInterface interface {
+	Insert(ctx context.Context, event *models.OutboxEvent) error
+	FindPending(ctx context.Context, limit int64) ([]models.OutboxEvent, error)
+	Claim(ctx context.Context, event *models.OutboxEvent, until time.Time) (bool, error)
+	MarkDelivered(ctx context.Context, id primitive.ObjectID, sink string) error
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error
+}
+
+type OutboxRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewOutboxRepository(collection *mongo.Collection) *OutboxRepository {
+	return &OutboxRepository{Collection: collection}
+}
+
+func (r *OutboxRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "aggregate_type", Value: 1}, {Key: "aggregate_id", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	return err
+}
+
+// Insert ใช้ ctx ที่ส่งมา ถ้าเป็น ctx ของ transaction รายการนี้จะ commit หรือยกเลิกไปพร้อมข้อมูลหลัก
+func (r *OutboxRepository) Insert(ctx context.Context, event *models.OutboxEvent) error {
+	result, err := r.Collection.InsertOne(ctx, event)
+	if err != nil {
+		return err
+	}
+	event.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindPending คืนรายการที่ยังไม่ได้ส่งเรียงตามลำดับที่บันทึก รวมถึงรายการที่ยังไม่ถึงเวลาส่งซ้ำ
+// เพื่อให้ผู้เรียกรู้ว่า aggregate ใดยังมี event ค้างอยู่
+func (r *OutboxRepository) FindPending(ctx context.Context, limit int64) ([]models.OutboxEvent, error) {
+	cursor, err := r.Collection.Find(ctx, bson.M{"status": models.OutboxPending},
+		options.Find().SetSort(bson.M{"_id": 1}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []models.OutboxEvent
+	for cursor.Next(ctx) {
+		var event models.OutboxEvent
+		if err := cursor.Decode(&event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, cursor.Err()
+}
+
+// Claim จองรายการไว้จนถึง until โดยเทียบ next_attempt_at เดิม ถ้ามี worker อื่นจองไปก่อนจะคืน false
+func (r *OutboxRepository) Claim(ctx context.Context, event *models.OutboxEvent, until time.Time) (bool, error) {
+	result, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": event.ID, "status": models.OutboxPending, "next_attempt_at": event.NextAttemptAt},
+		bson.M{"$set": bson.M{"next_attempt_at": until}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *OutboxRepository) MarkDelivered(ctx context.Context, id primitive.ObjectID, sink string) error {
+	_, err := r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$addToSet": bson.M{"delivered": sink}})
+	return err
+}
+
+func (r *OutboxRepository) Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	_, err := r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	return err
+}
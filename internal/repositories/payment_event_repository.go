@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrDuplicateEvent = fmt.Errorf("event already received")
+
+type PaymentEventRepositoryInterface interface {
+	Insert(ctx context.Context, event *models.PaymentEvent) error
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, fields bson.M) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	EnsureIndexes(ctx context.Context) error
+}
+
+type PaymentEventRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewPaymentEventRepository(collection *mongo.Collection) *PaymentEventRepository {
+	return &PaymentEventRepository{Collection: collection}
+}
+
+func (r *PaymentEventRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "event_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// Insert คืน ErrDuplicateEvent ถ้าเคยรับ event นี้จากผู้ให้บริการเดียวกันแล้ว
+func (r *PaymentEventRepository) Insert(ctx context.Context, event *models.PaymentEvent) error {
+	result, err := r.Collection.InsertOne(ctx, event)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateEvent
+	}
+	if err != nil {
+		return err
+	}
+	event.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *PaymentEventRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	_, err := r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	return err
+}
+
+func (r *PaymentEventRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.Collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
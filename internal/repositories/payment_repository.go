@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PaymentRepositoryInterface interface {
+	FindByOrder(ctx context.Context, orderID primitive.ObjectID) ([]models.Payment, error)
+	Insert(ctx context.Context, payment *models.Payment) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	AddRefund(ctx context.Context, id primitive.ObjectID, amount int64) error
+}
+
+var ErrRefundExceedsPayment = fmt.Errorf("refund exceeds refundable amount of payment")
+
+type PaymentRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewPaymentRepository(collection *mongo.Collection) *PaymentRepository {
+	return &PaymentRepository{Collection: collection}
+}
+
+func (r *PaymentRepository) FindByOrder(ctx context.Context, orderID primitive.ObjectID) ([]models.Payment, error) {
+	cursor, err := r.Collection.Find(ctx, bson.M{"order_id": orderID}, options.Find().SetSort(bson.M{"paid_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	payments := []models.Payment{}
+	for cursor.Next(ctx) {
+		var payment models.Payment
+		if err := cursor.Decode(&payment); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, cursor.Err()
+}
+
+func (r *PaymentRepository) Insert(ctx context.Context, payment *models.Payment) error {
+	result, err := r.Collection.InsertOne(ctx, payment)
+	if err != nil {
+		return err
+	}
+	payment.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *PaymentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.Collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *PaymentRepository) AddRefund(ctx context.Context, id primitive.ObjectID, amount int64) error {
+	filter := bson.M{
+		"_id":   id,
+		"$expr": bson.M{"$lte": bson.A{bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$refunded.amount", 0}}, amount}}, "$amount.amount"}},
+	}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"refunded": bson.M{
+				"amount":   bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$refunded.amount", 0}}, amount}},
+				"currency": "$amount.currency",
+			},
+		}}},
+	}
+	result, err := r.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrRefundExceedsPayment
+	}
+	return nil
+}
@@ -2,14 +2,21 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/search"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const fallbackSearchScanLimit = 500
+
+var ErrInsufficientStock = errors.New("insufficient stock")
+
 type ProductRepositoryInterface interface {
 	FindAll(ctx context.Context) ([]models.Product, error)
 	FindByID(ctx context.Context, id primitive.ObjectID, is_active bool) (*models.Product, error)
@@ -18,10 +25,23 @@ type ProductRepositoryInterface interface {
 	Update(ctx context.Context, id primitive.ObjectID, filter bson.M, role string, userID primitive.ObjectID) (*mongo.UpdateResult, error)
 	Delete(ctx context.Context, productID primitive.ObjectID, role string, userID primitive.ObjectID) (*mongo.DeleteResult, error)
 	ExistsBySKU(ctx context.Context, sku string) (bool, error)
+	Search(ctx context.Context, query string, limit int) ([]models.Product, error)
+	FindBySKUs(ctx context.Context, skus []string) (map[string]*models.Product, error)
+	ForEach(ctx context.Context, fn func(product *models.Product) error) error
+	FindLowStock(ctx context.Context) ([]models.Product, error)
+	OnStockChanged(listener func(productID primitive.ObjectID))
+	OnStockIncreased(listener func(productID primitive.ObjectID))
+	UpdateLocationStock(ctx context.Context, id primitive.ObjectID, locationID primitive.ObjectID, delta int) error
+	SetLocationStock(ctx context.Context, id primitive.ObjectID, locationID primitive.ObjectID, quantity int) error
+	TransferLocationStock(ctx context.Context, id primitive.ObjectID, fromID primitive.ObjectID, toID primitive.ObjectID, quantity int) error
+	DefaultLocation() primitive.ObjectID
 }
 
 type ProductRepository struct {
-	Collection *mongo.Collection
+	Collection        *mongo.Collection
+	DefaultLocationID primitive.ObjectID
+	stockListeners    []func(productID primitive.ObjectID)
+	increaseListeners []func(productID primitive.ObjectID)
 }
 
 func NewProductRepository(collection *mongo.Collection) *ProductRepository {
@@ -46,6 +66,25 @@ func (r *ProductRepository) FindAll(ctx context.Context) ([]models.Product, erro
 	return Products, nil
 }
 
+func (r *ProductRepository) ForEach(ctx context.Context, fn func(product *models.Product) error) error {
+	cursor, err := r.Collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"sku": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var product models.Product
+		if err := cursor.Decode(&product); err != nil {
+			return err
+		}
+		if err := fn(&product); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
 func (r *ProductRepository) FindByID(ctx context.Context, id primitive.ObjectID, is_active bool) (*models.Product, error) {
 	var product models.Product
 	if err := r.Collection.FindOne(ctx, bson.M{"_id": id, "is_active": is_active}).Decode(&product); err != nil {
@@ -55,15 +94,192 @@ func (r *ProductRepository) FindByID(ctx context.Context, id primitive.ObjectID,
 }
 
 func (r *ProductRepository) Insert(ctx context.Context, product *models.Product) error {
-	_, err := r.Collection.InsertOne(ctx, product)
-	return err
+	if len(product.Locations) == 0 {
+		product.Locations = []models.LocationStock{{LocationID: r.DefaultLocationID, Quantity: product.Stock}}
+	}
+	product.Stock = 0
+	for _, location := range product.Locations {
+		product.Stock += location.Quantity
+	}
+	result, err := r.Collection.InsertOne(ctx, product)
+	if err != nil {
+		return err
+	}
+	product.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *ProductRepository) DefaultLocation() primitive.ObjectID {
+	return r.DefaultLocationID
 }
 
 func (r *ProductRepository) UpdateStock(ctx context.Context, id primitive.ObjectID, NewStock int) error {
-	_, err := r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"stock": NewStock}})
+	return r.UpdateLocationStock(ctx, id, r.DefaultLocationID, NewStock)
+}
+
+// UpdateLocationStock ปรับสต็อกของคลังที่ระบุพร้อมยอดรวม ถ้าเป็นการตัดสต็อกจะสำเร็จเฉพาะเมื่อคลังนั้นมีของพอ
+func (r *ProductRepository) UpdateLocationStock(ctx context.Context, id primitive.ObjectID, locationID primitive.ObjectID, delta int) error {
+	if locationID.IsZero() {
+		locationID = r.DefaultLocationID
+	}
+
+	filter := bson.M{"_id": id}
+	if delta < 0 {
+		filter["locations"] = bson.M{"$elemMatch": bson.M{"location_id": locationID, "quantity": bson.M{"$gte": -delta}}}
+	} else {
+		filter["locations.location_id"] = locationID
+	}
+
+	result, err := r.Collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"stock": delta, "locations.$.quantity": delta}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if delta < 0 {
+			return ErrInsufficientStock
+		}
+		result, err = r.Collection.UpdateOne(ctx,
+			bson.M{"_id": id, "locations.location_id": bson.M{"$ne": locationID}},
+			bson.M{
+				"$inc":  bson.M{"stock": delta},
+				"$push": bson.M{"locations": models.LocationStock{LocationID: locationID, Quantity: delta}},
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return mongo.ErrNoDocuments
+		}
+	}
+
+	r.notifyStockChanged(ctx, id)
+	if delta > 0 {
+		r.notifyStockIncreased(ctx, id)
+	}
+	return nil
+}
+
+func (r *ProductRepository) TransferLocationStock(ctx context.Context, id primitive.ObjectID, fromID primitive.ObjectID, toID primitive.ObjectID, quantity int) error {
+	// เพิ่มรายการคลังปลายทางไว้ก่อนถ้ายังไม่มี arrayFilters จะได้มีรายการให้เพิ่มจำนวน
+	if _, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "locations.location_id": bson.M{"$ne": toID}},
+		bson.M{"$push": bson.M{"locations": models.LocationStock{LocationID: toID}}},
+	); err != nil {
+		return err
+	}
+
+	result, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "locations": bson.M{"$elemMatch": bson.M{"location_id": fromID, "quantity": bson.M{"$gte": quantity}}}},
+		bson.M{"$inc": bson.M{
+			"locations.$[from].quantity": -quantity,
+			"locations.$[to].quantity":   quantity,
+		}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []any{
+			bson.M{"from.location_id": fromID},
+			bson.M{"to.location_id": toID},
+		}}),
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrInsufficientStock
+	}
+
+	r.notifyStockChanged(ctx, id)
+	r.notifyStockIncreased(ctx, id)
+	return nil
+}
+
+func (r *ProductRepository) SetLocationStock(ctx context.Context, id primitive.ObjectID, locationID primitive.ObjectID, quantity int) error {
+	if locationID.IsZero() {
+		locationID = r.DefaultLocationID
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"locations": bson.M{"$concatArrays": bson.A{
+			bson.M{"$filter": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$locations", bson.A{}}},
+				"cond":  bson.M{"$ne": bson.A{"$$this.location_id", locationID}},
+			}},
+			bson.A{bson.M{"location_id": locationID, "quantity": quantity}},
+		}}}}},
+		{{Key: "$set", Value: bson.M{"stock": bson.M{"$sum": "$locations.quantity"}}}},
+	}
+
+	var before models.Product
+	err := r.Collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, pipeline,
+		options.FindOneAndUpdate().SetReturnDocument(options.Before).SetProjection(bson.M{"locations": 1}),
+	).Decode(&before)
+	if err != nil {
+		return err
+	}
+
+	r.notifyStockChanged(ctx, id)
+	if quantity > before.StockAt(locationID) {
+		r.notifyStockIncreased(ctx, id)
+	}
+	return nil
+}
+
+func (r *ProductRepository) MigrateLegacyStock(ctx context.Context) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"locations": bson.A{bson.M{"location_id": r.DefaultLocationID, "quantity": "$stock"}}}}},
+	}
+	_, err := r.Collection.UpdateMany(ctx, bson.M{"locations": bson.M{"$in": bson.A{nil, bson.A{}}}}, pipeline)
 	return err
 }
 
+func (r *ProductRepository) OnStockChanged(listener func(productID primitive.ObjectID)) {
+	r.stockListeners = append(r.stockListeners, listener)
+}
+
+// OnStockIncreased ลงทะเบียน listener ที่สนใจเฉพาะตอนสต็อกของคลังใดคลังหนึ่งเพิ่มขึ้น เช่นรับของหรือปรับสต็อกขึ้น
+func (r *ProductRepository) OnStockIncreased(listener func(productID primitive.ObjectID)) {
+	r.increaseListeners = append(r.increaseListeners, listener)
+}
+
+func (r *ProductRepository) notifyStockIncreased(ctx context.Context, id primitive.ObjectID) {
+	AfterCommit(ctx, "stock-increased:"+id.Hex(), func() {
+		for _, listener := range r.increaseListeners {
+			listener(id)
+		}
+	})
+}
+
+// notifyStockChanged แจ้ง listener หลัง transaction commit เพื่อให้อ่านสต็อกที่บันทึกจริงแล้ว
+func (r *ProductRepository) notifyStockChanged(ctx context.Context, id primitive.ObjectID) {
+	AfterCommit(ctx, "stock:"+id.Hex(), func() {
+		for _, listener := range r.stockListeners {
+			listener(id)
+		}
+	})
+}
+
+func (r *ProductRepository) FindLowStock(ctx context.Context) ([]models.Product, error) {
+	filter := bson.M{
+		"is_active":     true,
+		"reorder_point": bson.M{"$gt": 0},
+		"$expr":         bson.M{"$lte": bson.A{"$stock", "$reorder_point"}},
+	}
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.M{"stock": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []models.Product
+	for cursor.Next(ctx) {
+		var product models.Product
+		if err := cursor.Decode(&product); err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, nil
+}
+
 func (r *ProductRepository) Update(ctx context.Context, productID primitive.ObjectID, fields bson.M, role string, userID primitive.ObjectID) (*mongo.UpdateResult, error) {
 
 	var filter bson.M
@@ -78,6 +294,11 @@ func (r *ProductRepository) Update(ctx context.Context, productID primitive.Obje
 	}
 
 	result, err := r.Collection.UpdateOne(ctx, filter, bson.M{"$set": fields})
+	if err == nil && result.MatchedCount > 0 {
+		if _, reorderChanged := fields["reorder_point"]; reorderChanged {
+			r.notifyStockChanged(ctx, productID)
+		}
+	}
 	return result, err
 }
 
@@ -102,3 +323,163 @@ func (r *ProductRepository) ExistsBySKU(ctx context.Context, sku string) (bool,
 	count, err := r.Collection.CountDocuments(ctx, bson.M{"sku": sku})
 	return count > 0, err
 }
+
+func (r *ProductRepository) FindBySKUs(ctx context.Context, skus []string) (map[string]*models.Product, error) {
+	cursor, err := r.Collection.Find(ctx, bson.M{"sku": bson.M{"$in": skus}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	products := map[string]*models.Product{}
+	for cursor.Next(ctx) {
+		var product models.Product
+		if err := cursor.Decode(&product); err != nil {
+			return nil, err
+		}
+		products[product.SKU] = &product
+	}
+	return products, cursor.Err()
+}
+
+func (r *ProductRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "name", Value: "text"},
+			{Key: "sku", Value: "text"},
+			{Key: "description", Value: "text"},
+			{Key: "tags", Value: "text"},
+		},
+		Options: options.Index().
+			SetName("product_text_search").
+			SetDefaultLanguage("none").
+			SetWeights(bson.D{
+				{Key: "name", Value: 10},
+				{Key: "sku", Value: 10},
+				{Key: "tags", Value: 5},
+				{Key: "description", Value: 1},
+			}),
+	})
+	return err
+}
+
+func (r *ProductRepository) Search(ctx context.Context, query string, limit int) ([]models.Product, error) {
+	var candidates []models.Product
+	seen := map[primitive.ObjectID]bool{}
+	textScores := map[primitive.ObjectID]float64{}
+
+	// $text ให้คะแนนตามความเกี่ยวข้อง แต่ไม่รองรับ prefix, คำสะกดผิด และภาษาไทยที่ไม่เว้นวรรค
+	// ถ้ายังไม่มี text index (เช่น สร้าง index ไม่สำเร็จตอนเริ่มระบบ) ก็ค้นด้วย regex อย่างเดียว
+	if err := r.collectTextCandidates(ctx, query, int64(limit)*5, &candidates, seen, textScores); err != nil && !isIndexNotFound(err) {
+		return nil, fmt.Errorf("text search: %w", err)
+	}
+
+	// ชื่อที่เก็บไว้ยังมีวรรณยุกต์ จึงใช้ regex ที่ข้ามวรรณยุกต์แทนการเทียบกับคำค้นที่ normalize แล้วตรงๆ
+	pattern := primitive.Regex{Pattern: search.Pattern(query), Options: "i"}
+	if err := r.collectCandidates(ctx, bson.M{
+		"is_active": true,
+		"$or": []bson.M{
+			{"name": pattern},
+			{"sku": pattern},
+			{"tags": pattern},
+			{"description": pattern},
+		},
+	}, int64(limit)*5, &candidates, seen); err != nil {
+		return nil, err
+	}
+
+	ranked := RankProducts(query, candidates, textScores, limit)
+	if len(ranked) >= limit {
+		return ranked, nil
+	}
+	// ผลยังไม่ครบ อาจเป็นคำสะกดผิดที่ทั้ง $text และ regex หาไม่เจอ จึงดึงสินค้าที่มีบางส่วนของคำค้นมาให้ fuzzy match จัดอันดับ
+	fuzzy := search.FuzzyPattern(query)
+	if fuzzy == "" {
+		return ranked, nil
+	}
+	pattern = primitive.Regex{Pattern: fuzzy, Options: "i"}
+	if err := r.collectCandidates(ctx, bson.M{
+		"is_active": true,
+		"$or": []bson.M{
+			{"name": pattern},
+			{"sku": pattern},
+			{"tags": pattern},
+		},
+	}, fallbackSearchScanLimit, &candidates, seen); err != nil {
+		return nil, err
+	}
+	return RankProducts(query, candidates, textScores, limit), nil
+}
+
+func (r *ProductRepository) collectTextCandidates(ctx context.Context, query string, limit int64, candidates *[]models.Product, seen map[primitive.ObjectID]bool, textScores map[primitive.ObjectID]float64) error {
+	cursor, err := r.Collection.Find(ctx,
+		bson.M{"$text": bson.M{"$search": query}, "is_active": true},
+		options.Find().
+			SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+			SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
+			SetLimit(limit),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var doc struct {
+			models.Product `bson:",inline"`
+			Score          float64 `bson:"score"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		*candidates = append(*candidates, doc.Product)
+		seen[doc.Product.ID] = true
+		textScores[doc.Product.ID] = doc.Score
+	}
+	return cursor.Err()
+}
+
+func isIndexNotFound(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(27)
+}
+
+func (r *ProductRepository) collectCandidates(ctx context.Context, filter bson.M, limit int64, candidates *[]models.Product, seen map[primitive.ObjectID]bool) error {
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetLimit(limit))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var product models.Product
+		if err := cursor.Decode(&product); err != nil {
+			return err
+		}
+		if !seen[product.ID] {
+			*candidates = append(*candidates, product)
+			seen[product.ID] = true
+		}
+	}
+	return cursor.Err()
+}
+
+func RankProducts(query string, candidates []models.Product, textScores map[primitive.ObjectID]float64, limit int) []models.Product {
+	var docs []search.Document
+	for i, product := range candidates {
+		docs = append(docs, search.Document{
+			Key: i,
+			Fields: []search.Field{
+				{Values: []string{product.Name}, Weight: 3},
+				{Values: []string{product.SKU}, Weight: 3},
+				{Values: product.Tags, Weight: 2},
+				{Values: []string{product.Description}, Weight: 1},
+			},
+			Boost: textScores[product.ID],
+		})
+	}
+
+	results := search.Rank(query, docs, limit)
+	ranked := make([]models.Product, 0, len(results))
+	for _, result := range results {
+		ranked = append(ranked, candidates[result.Key])
+	}
+	return ranked
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrPromotionUsageLimit = fmt.Errorf("promotion usage limit reached")
+
+type PromotionRepositoryInterface interface {
+	FindAll(ctx context.Context) ([]models.Promotion, error)
+	FindAutomatic(ctx context.Context, now time.Time) ([]models.Promotion, error)
+	FindByCode(ctx context.Context, code string) (*models.Promotion, error)
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Promotion, error)
+	Insert(ctx context.Context, promo *models.Promotion, role string) error
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error)
+	IncrementUsage(ctx context.Context, id primitive.ObjectID) error
+	DecrementUsage(ctx context.Context, id primitive.ObjectID) error
+	EnsureIndexes(ctx context.Context) error
+}
+
+type PromotionRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewPromotionRepository(collection *mongo.Collection) *PromotionRepository {
+	return &PromotionRepository{Collection: collection}
+}
+
+func (r *PromotionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{"code": 1},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"code": bson.M{"$type": "string"}}),
+	})
+	return err
+}
+
+func (r *PromotionRepository) FindAll(ctx context.Context) ([]models.Promotion, error) {
+	return r.find(ctx, bson.M{}, options.Find().SetSort(bson.M{"created_at": -1}))
+}
+
+func (r *PromotionRepository) FindAutomatic(ctx context.Context, now time.Time) ([]models.Promotion, error) {
+	filter := bson.M{
+		"is_active": true,
+		"code":      bson.M{"$exists": false},
+		"$and": []bson.M{
+			{"$or": []bson.M{{"starts_at": bson.M{"$exists": false}}, {"starts_at": bson.M{"$lte": now}}}},
+			{"$or": []bson.M{{"expires_at": bson.M{"$exists": false}}, {"expires_at": bson.M{"$gt": now}}}},
+		},
+	}
+	return r.find(ctx, filter, options.Find().SetSort(bson.M{"created_at": 1}))
+}
+
+func (r *PromotionRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Promotion, error) {
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetSort(bson.M{"created_at": 1}))
+}
+
+func (r *PromotionRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.Promotion, error) {
+	cursor, err := r.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var promos []models.Promotion
+	for cursor.Next(ctx) {
+		var promo models.Promotion
+		if err := cursor.Decode(&promo); err != nil {
+			return nil, err
+		}
+		promos = append(promos, promo)
+	}
+	return promos, nil
+}
+
+func (r *PromotionRepository) FindByCode(ctx context.Context, code string) (*models.Promotion, error) {
+	var promo models.Promotion
+	if err := r.Collection.FindOne(ctx, bson.M{"code": strings.ToUpper(strings.TrimSpace(code))}).Decode(&promo); err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *PromotionRepository) Insert(ctx context.Context, promo *models.Promotion, role string) error {
+	if role != "Admin" {
+		return fmt.Errorf("unauthorized role")
+	}
+	result, err := r.Collection.InsertOne(ctx, promo)
+	if err != nil {
+		return err
+	}
+	promo.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *PromotionRepository) Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	return r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+}
+
+// IncrementUsage นับการใช้งานแบบมีเงื่อนไขในคำสั่งเดียว จึงใช้เกิน usage_limit ไม่ได้แม้มีออเดอร์พร้อมกัน
+func (r *PromotionRepository) IncrementUsage(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{
+		"_id": id,
+		"$or": []bson.M{
+			{"usage_limit": bson.M{"$lte": 0}},
+			{"$expr": bson.M{"$lt": bson.A{"$usage_count", "$usage_limit"}}},
+		},
+	}
+	result, err := r.Collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"usage_count": 1}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPromotionUsageLimit
+	}
+	return nil
+}
+
+func (r *PromotionRepository) DecrementUsage(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.Collection.UpdateOne(ctx, bson.M{"_id": id, "usage_count": bson.M{"$gt": 0}}, bson.M{"$inc": bson.M{"usage_count": -1}})
+	return err
+}
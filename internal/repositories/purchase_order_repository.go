@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PurchaseOrderRepositoryInterface interface {
+	FindAll(ctx context.Context, filter bson.M, role string) ([]models.PurchaseOrder, error)
+	FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.PurchaseOrder, error)
+	Insert(ctx context.Context, po *models.PurchaseOrder, role string) error
+	UpdateIfUnchanged(ctx context.Context, po *models.PurchaseOrder, update bson.M, role string) (*mongo.UpdateResult, error)
+}
+
+type PurchaseOrderRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewPurchaseOrderRepository(collection *mongo.Collection) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{Collection: collection}
+}
+
+func (r *PurchaseOrderRepository) FindAll(ctx context.Context, filter bson.M, role string) ([]models.PurchaseOrder, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var purchaseOrders []models.PurchaseOrder
+	for cursor.Next(ctx) {
+		var po models.PurchaseOrder
+		if err := cursor.Decode(&po); err != nil {
+			return nil, err
+		}
+		purchaseOrders = append(purchaseOrders, po)
+	}
+	return purchaseOrders, nil
+}
+
+func (r *PurchaseOrderRepository) FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.PurchaseOrder, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	var po models.PurchaseOrder
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&po); err != nil {
+		return nil, err
+	}
+	return &po, nil
+}
+
+func (r *PurchaseOrderRepository) Insert(ctx context.Context, po *models.PurchaseOrder, role string) error {
+	if role != "Admin" && role != "Staff" {
+		return fmt.Errorf("unauthorized role")
+	}
+	result, err := r.Collection.InsertOne(ctx, po)
+	if err != nil {
+		return err
+	}
+	po.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// UpdateIfUnchanged อัปเดตเฉพาะเมื่อสถานะและจำนวนการรับของยังเท่ากับตอนที่อ่านมา
+// เพื่อกันการรับของซ้ำจากสองคำขอพร้อมกัน
+func (r *PurchaseOrderRepository) UpdateIfUnchanged(ctx context.Context, po *models.PurchaseOrder, update bson.M, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	filter := bson.M{
+		"_id":    po.ID,
+		"status": po.Status,
+		"$expr":  bson.M{"$eq": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$receipts", bson.A{}}}}, len(po.Receipts)}},
+	}
+	return r.Collection.UpdateOne(ctx, filter, update)
+}
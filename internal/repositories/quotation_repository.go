@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QuotationRepositoryInterface interface {
+	FindAll(ctx context.Context, status string, userID primitive.ObjectID, role string) ([]models.Quotation, error)
+	FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.Quotation, error)
+	Insert(ctx context.Context, quotation *models.Quotation, role string) error
+	UpdateIfUnchanged(ctx context.Context, quotation *models.Quotation, update bson.M, role string) (*mongo.UpdateResult, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type QuotationRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewQuotationRepository(collection *mongo.Collection) *QuotationRepository {
+	return &QuotationRepository{Collection: collection}
+}
+
+func (r *QuotationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"number": 1},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// FindAll คืนใบเสนอราคาเรียงจากใหม่ไปเก่า status ว่างคือทุกสถานะ Staff เห็นเฉพาะใบตามกติกาเดียวกับออเดอร์
+func (r *QuotationRepository) FindAll(ctx context.Context, status string, userID primitive.ObjectID, role string) ([]models.Quotation, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	switch role {
+	case "Admin":
+	case "Staff":
+		filter["$or"] = []bson.M{{"created_by": userID}, {"created_by": primitive.NilObjectID}}
+	default:
+		return nil, fmt.Errorf("unauthorized role")
+	}
+
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	quotations := []models.Quotation{}
+	if err := cursor.All(ctx, &quotations); err != nil {
+		return nil, err
+	}
+	return quotations, nil
+}
+
+func (r *QuotationRepository) FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.Quotation, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	var quotation models.Quotation
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&quotation); err != nil {
+		return nil, err
+	}
+	return &quotation, nil
+}
+
+func (r *QuotationRepository) Insert(ctx context.Context, quotation *models.Quotation, role string) error {
+	if role != "Admin" && role != "Staff" {
+		return fmt.Errorf("unauthorized role")
+	}
+	result, err := r.Collection.InsertOne(ctx, quotation)
+	if err != nil {
+		return err
+	}
+	quotation.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// UpdateIfUnchanged อัปเดตเฉพาะเมื่อสถานะยังเท่ากับตอนที่อ่านมา กันการแปลงเป็นออเดอร์ซ้ำจากสองคำขอพร้อมกัน
+func (r *QuotationRepository) UpdateIfUnchanged(ctx context.Context, quotation *models.Quotation, update bson.M, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	return r.Collection.UpdateOne(ctx, bson.M{"_id": quotation.ID, "status": quotation.Status}, update)
+}
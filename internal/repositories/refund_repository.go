@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RefundRepositoryInterface interface {
+	FindByOrder(ctx context.Context, orderID primitive.ObjectID) ([]models.Refund, error)
+	Insert(ctx context.Context, refund *models.Refund) error
+}
+
+type RefundRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewRefundRepository(collection *mongo.Collection) *RefundRepository {
+	return &RefundRepository{Collection: collection}
+}
+
+func (r *RefundRepository) FindByOrder(ctx context.Context, orderID primitive.ObjectID) ([]models.Refund, error) {
+	cursor, err := r.Collection.Find(ctx, bson.M{"order_id": orderID}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	refunds := []models.Refund{}
+	for cursor.Next(ctx) {
+		var refund models.Refund
+		if err := cursor.Decode(&refund); err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+	return refunds, cursor.Err()
+}
+
+func (r *RefundRepository) Insert(ctx context.Context, refund *models.Refund) error {
+	result, err := r.Collection.InsertOne(ctx, refund)
+	if err != nil {
+		return err
+	}
+	refund.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
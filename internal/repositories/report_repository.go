@@ -0,0 +1,318 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	ReportByDay   = "day"
+	ReportByWeek  = "week"
+	ReportByMonth = "month"
+)
+
+// รูปแบบ key ของแต่ละช่วงตรงกับ $dateToString ของ MongoDB สัปดาห์ใช้เลขสัปดาห์แบบ ISO
+var reportPeriodFormats = map[string]string{
+	ReportByDay:   "%Y-%m-%d",
+	ReportByWeek:  "%G-W%V",
+	ReportByMonth: "%Y-%m",
+}
+
+// ReportFilter ช่วงเวลาคือ [From, To) ถ้า From และ To เป็นค่าศูนย์จะนับทุกช่วงเวลา
+// ถ้าไม่ระบุ Statuses จะนับทุกออเดอร์ที่ไม่ถูกยกเลิก
+type ReportFilter struct {
+	From       time.Time
+	To         time.Time
+	Location   *time.Location
+	Currency   string
+	Statuses   []string
+	CustomerID primitive.ObjectID
+	UserID     primitive.ObjectID
+	Role       string
+}
+
+type ReportRepositoryInterface interface {
+	SalesTotals(ctx context.Context, filter ReportFilter) (*models.SalesTotals, error)
+	SalesByPeriod(ctx context.Context, filter ReportFilter, groupBy string) ([]models.SalesPeriod, error)
+	TopProducts(ctx context.Context, filter ReportFilter, sortBy string, limit int) ([]models.ProductSales, error)
+	SalesByStaff(ctx context.Context, filter ReportFilter) ([]models.StaffSales, error)
+	StatusCounts(ctx context.Context, filter ReportFilter) ([]models.StatusCount, error)
+	CustomerStats(ctx context.Context, filter ReportFilter) ([]models.CustomerStats, error)
+	Backorders(ctx context.Context, filter ReportFilter) ([]models.BackorderSummary, error)
+}
+
+type ReportRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewReportRepository(orderCollection *mongo.Collection) *ReportRepository {
+	return &ReportRepository{Collection: orderCollection}
+}
+
+// match สร้างเงื่อนไขเลือกออเดอร์ตามช่วงเวลา สกุลเงิน และสิทธิ์ของผู้ใช้ แบบเดียวกับ OrderRepository.FindAll
+func (f ReportFilter) match(defaultStatuses bool) (bson.M, error) {
+	match := bson.M{"total_amount.currency": f.Currency}
+	if !f.From.IsZero() || !f.To.IsZero() {
+		match["created_at"] = bson.M{"$gte": f.From, "$lt": f.To}
+	}
+	if !f.CustomerID.IsZero() {
+		match["customer_id"] = f.CustomerID
+	}
+	if len(f.Statuses) > 0 {
+		match["status"] = bson.M{"$in": f.Statuses}
+	} else if defaultStatuses {
+		match["status"] = bson.M{"$nin": bson.A{"Cancelled", "cancelled"}}
+	}
+
+	switch f.Role {
+	case "Admin":
+	case "Staff":
+		match["$or"] = bson.A{bson.M{"created_by": f.UserID}, bson.M{"created_by": primitive.NilObjectID}}
+	default:
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	return match, nil
+}
+
+func salesTotalsGroup(id any) bson.M {
+	return bson.M{
+		"_id":      id,
+		"orders":   bson.M{"$sum": 1},
+		"revenue":  bson.M{"$sum": "$total_amount.amount"},
+		"tax":      bson.M{"$sum": bson.M{"$ifNull": bson.A{"$tax_total.amount", 0}}},
+		"discount": bson.M{"$sum": bson.M{"$ifNull": bson.A{"$discount_total.amount", 0}}},
+		"refunded": bson.M{"$sum": bson.M{"$ifNull": bson.A{"$refunded_amount.amount", 0}}},
+	}
+}
+
+func (r *ReportRepository) SalesTotals(ctx context.Context, filter ReportFilter) (*models.SalesTotals, error) {
+	match, err := filter.match(true)
+	if err != nil {
+		return nil, err
+	}
+	var rows []models.SalesTotals
+	if err := r.aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: salesTotalsGroup(nil)}},
+	}, &rows); err != nil {
+		return nil, err
+	}
+
+	totals := models.SalesTotals{}
+	if len(rows) > 0 {
+		totals = rows[0]
+	}
+	totals.Finish()
+	return &totals, nil
+}
+
+// SalesByPeriod แบ่งยอดขายตามวัน สัปดาห์ หรือเดือนตามเวลาท้องถิ่นของ filter.Location ช่วงที่ไม่มียอดขายจะไม่อยู่ในผลลัพธ์
+func (r *ReportRepository) SalesByPeriod(ctx context.Context, filter ReportFilter, groupBy string) ([]models.SalesPeriod, error) {
+	format, ok := reportPeriodFormats[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid group_by: %s", groupBy)
+	}
+	match, err := filter.match(true)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.SalesPeriod
+	if err := r.aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: salesTotalsGroup(bson.M{"$dateToString": bson.M{
+			"format":   format,
+			"date":     "$created_at",
+			"timezone": filter.Location.String(),
+		}})}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}, &rows); err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].Finish()
+	}
+	return rows, nil
+}
+
+// TopProducts จัดอันดับสินค้าตามจำนวนที่ขาย (หักที่รับคืนแล้ว) หรือตามยอดขาย
+func (r *ReportRepository) TopProducts(ctx context.Context, filter ReportFilter, sortBy string, limit int) ([]models.ProductSales, error) {
+	match, err := filter.match(true)
+	if err != nil {
+		return nil, err
+	}
+	sort := bson.D{{Key: "quantity", Value: -1}, {Key: "revenue", Value: -1}}
+	if sortBy == "revenue" {
+		sort = bson.D{{Key: "revenue", Value: -1}, {Key: "quantity", Value: -1}}
+	}
+
+	var rows []models.ProductSales
+	if err := r.aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$items.product_id",
+			"quantity": bson.M{"$sum": bson.M{"$subtract": bson.A{"$items.quantity", bson.M{"$ifNull": bson.A{"$items.returned", 0}}}}},
+			"returned": bson.M{"$sum": bson.M{"$ifNull": bson.A{"$items.returned", 0}}},
+			"revenue":  bson.M{"$sum": "$items.line_total.amount"},
+			"orders":   bson.M{"$addToSet": "$_id"},
+		}}},
+		{{Key: "$set", Value: bson.M{"orders": bson.M{"$size": "$orders"}}}},
+		{{Key: "$sort", Value: sort}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$lookup", Value: bson.M{"from": "products", "localField": "_id", "foreignField": "_id", "as": "product"}}},
+		{{Key: "$set", Value: bson.M{
+			"name": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$product.name", 0}}, ""}},
+			"sku":  bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$product.sku", 0}}, ""}},
+		}}},
+		{{Key: "$project", Value: bson.M{"product": 0}}},
+	}, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// SalesByStaff แบ่งยอดขายตามผู้สร้างออเดอร์ ออเดอร์ที่ไม่มีผู้สร้างจะรวมอยู่ในแถวที่ user_id เป็นศูนย์
+func (r *ReportRepository) SalesByStaff(ctx context.Context, filter ReportFilter) ([]models.StaffSales, error) {
+	match, err := filter.match(true)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.StaffSales
+	if err := r.aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: salesTotalsGroup(bson.M{"$ifNull": bson.A{"$created_by", primitive.NilObjectID}})}},
+		{{Key: "$sort", Value: bson.M{"revenue": -1}}},
+		{{Key: "$lookup", Value: bson.M{"from": "users", "localField": "_id", "foreignField": "_id", "as": "user"}}},
+		{{Key: "$set", Value: bson.M{
+			"username": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$user.username", 0}}, ""}},
+			"email":    bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$user.email", 0}}, ""}},
+		}}},
+		{{Key: "$project", Value: bson.M{"user": 0}}},
+	}, &rows); err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].Finish()
+	}
+	return rows, nil
+}
+
+// StatusCounts นับออเดอร์ทุกสถานะรวมถึงที่ถูกยกเลิก เว้นแต่จะระบุ Statuses
+func (r *ReportRepository) StatusCounts(ctx context.Context, filter ReportFilter) ([]models.StatusCount, error) {
+	match, err := filter.match(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.StatusCount
+	if err := r.aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$status",
+			"orders":  bson.M{"$sum": 1},
+			"revenue": bson.M{"$sum": "$total_amount.amount"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"orders": -1}}},
+	}, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// CustomerStats สรุปยอดซื้อของลูกค้าแต่ละคน lifetime value คือยอดซื้อหักยอดที่คืนเงินแล้ว
+func (r *ReportRepository) CustomerStats(ctx context.Context, filter ReportFilter) ([]models.CustomerStats, error) {
+	match, err := filter.match(true)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.CustomerStats
+	if err := r.aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":            "$customer_id",
+			"orders":         bson.M{"$sum": 1},
+			"revenue":        bson.M{"$sum": "$total_amount.amount"},
+			"refunded":       bson.M{"$sum": bson.M{"$ifNull": bson.A{"$refunded_amount.amount", 0}}},
+			"first_order_at": bson.M{"$min": "$created_at"},
+			"last_order_at":  bson.M{"$max": "$created_at"},
+		}}},
+		{{Key: "$set", Value: bson.M{"lifetime_value": bson.M{"$subtract": bson.A{"$revenue", "$refunded"}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "lifetime_value", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$lookup", Value: bson.M{"from": "customers", "localField": "_id", "foreignField": "_id", "as": "customer"}}},
+		{{Key: "$set", Value: bson.M{
+			"full_name": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$customer.full_name", 0}}, ""}},
+			"email":     bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$customer.email", 0}}, ""}},
+			"phone":     bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$customer.phone", 0}}, ""}},
+		}}},
+		{{Key: "$project", Value: bson.M{"customer": 0}}},
+	}, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Backorders รวมจำนวนที่ยังรอสต็อกแยกตามสินค้า สินค้าที่รอนานที่สุดขึ้นก่อน
+// จำนวนสินค้าไม่ขึ้นกับสกุลเงิน จึงไม่กรองตาม Currency และถ้าไม่ระบุ Statuses จะไม่นับออเดอร์ที่คืนเงินแล้ว
+func (r *ReportRepository) Backorders(ctx context.Context, filter ReportFilter) ([]models.BackorderSummary, error) {
+	match, err := filter.match(true)
+	if err != nil {
+		return nil, err
+	}
+	delete(match, "total_amount.currency")
+	if len(filter.Statuses) == 0 {
+		match["status"] = bson.M{"$nin": bson.A{"Cancelled", "cancelled", "Refunded"}}
+	}
+	match["items.backordered"] = bson.M{"$gt": 0}
+
+	var rows []models.BackorderSummary
+	if err := r.aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$match", Value: bson.M{"items.backordered": bson.M{"$gt": 0}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":             "$items.product_id",
+			"backordered":     bson.M{"$sum": "$items.backordered"},
+			"orders":          bson.M{"$addToSet": "$_id"},
+			"oldest_order_at": bson.M{"$min": "$created_at"},
+			"waiting": bson.M{"$push": bson.M{
+				"order_id":    "$_id",
+				"customer_id": "$customer_id",
+				"status":      "$status",
+				"quantity":    "$items.quantity",
+				"backordered": "$items.backordered",
+				"created_at":  "$created_at",
+			}},
+		}}},
+		{{Key: "$set", Value: bson.M{"orders": bson.M{"$size": "$orders"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "oldest_order_at", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$lookup", Value: bson.M{"from": "products", "localField": "_id", "foreignField": "_id", "as": "product"}}},
+		{{Key: "$set", Value: bson.M{
+			"name":  bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$product.name", 0}}, ""}},
+			"sku":   bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$product.sku", 0}}, ""}},
+			"stock": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$product.stock", 0}}, 0}},
+		}}},
+		{{Key: "$project", Value: bson.M{"product": 0}}},
+	}, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *ReportRepository) aggregate(ctx context.Context, pipeline mongo.Pipeline, results any) error {
+	cursor, err := r.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, results)
+}
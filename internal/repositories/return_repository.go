@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReturnRepositoryInterface interface {
+	FindAll(ctx context.Context, filter bson.M, role string) ([]models.Return, error)
+	FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.Return, error)
+	Insert(ctx context.Context, ret *models.Return, role string) error
+	UpdateIfUnchanged(ctx context.Context, ret *models.Return, update bson.M, role string) (*mongo.UpdateResult, error)
+}
+
+type ReturnRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewReturnRepository(collection *mongo.Collection) *ReturnRepository {
+	return &ReturnRepository{Collection: collection}
+}
+
+func (r *ReturnRepository) FindAll(ctx context.Context, filter bson.M, role string) ([]models.Return, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var returns []models.Return
+	for cursor.Next(ctx) {
+		var ret models.Return
+		if err := cursor.Decode(&ret); err != nil {
+			return nil, err
+		}
+		returns = append(returns, ret)
+	}
+	return returns, nil
+}
+
+func (r *ReturnRepository) FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.Return, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	var ret models.Return
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (r *ReturnRepository) Insert(ctx context.Context, ret *models.Return, role string) error {
+	if role != "Admin" && role != "Staff" {
+		return fmt.Errorf("unauthorized role")
+	}
+	result, err := r.Collection.InsertOne(ctx, ret)
+	if err != nil {
+		return err
+	}
+	ret.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// UpdateIfUnchanged อัปเดตเฉพาะเมื่อสถานะและยอดที่คืนเงินแล้วยังเท่ากับตอนที่อ่านมา
+// กันการอนุมัติ รับของ หรือคืนเงินซ้ำจากสองคำขอพร้อมกัน
+func (r *ReturnRepository) UpdateIfUnchanged(ctx context.Context, ret *models.Return, update bson.M, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	filter := bson.M{
+		"_id":    ret.ID,
+		"status": ret.Status,
+		"$expr":  bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$refunded_amount.amount", 0}}, ret.RefundedAmount.Amount}},
+	}
+	return r.Collection.UpdateOne(ctx, filter, update)
+}
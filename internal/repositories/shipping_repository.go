@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CarrierRepositoryInterface interface {
+	FindAll(ctx context.Context, activeOnly bool) ([]models.Carrier, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Carrier, error)
+	Insert(ctx context.Context, carrier *models.Carrier, role string) error
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error)
+	Delete(ctx context.Context, id primitive.ObjectID, role string) (*mongo.DeleteResult, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type ShipmentRepositoryInterface interface {
+	FindByOrder(ctx context.Context, orderID primitive.ObjectID, role string) ([]models.Shipment, error)
+	FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.Shipment, error)
+	Insert(ctx context.Context, shipment *models.Shipment, role string) error
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type CarrierRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewCarrierRepository(collection *mongo.Collection) *CarrierRepository {
+	return &CarrierRepository{Collection: collection}
+}
+
+func (r *CarrierRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"code": 1},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *CarrierRepository) FindAll(ctx context.Context, activeOnly bool) ([]models.Carrier, error) {
+	filter := bson.M{}
+	if activeOnly {
+		filter["is_active"] = true
+	}
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	carriers := []models.Carrier{}
+	if err := cursor.All(ctx, &carriers); err != nil {
+		return nil, err
+	}
+	return carriers, nil
+}
+
+func (r *CarrierRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Carrier, error) {
+	var carrier models.Carrier
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&carrier); err != nil {
+		return nil, err
+	}
+	return &carrier, nil
+}
+
+func (r *CarrierRepository) Insert(ctx context.Context, carrier *models.Carrier, role string) error {
+	if role != "Admin" {
+		return fmt.Errorf("unauthorized role")
+	}
+	result, err := r.Collection.InsertOne(ctx, carrier)
+	if err != nil {
+		return err
+	}
+	carrier.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *CarrierRepository) Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	return r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+}
+
+func (r *CarrierRepository) Delete(ctx context.Context, id primitive.ObjectID, role string) (*mongo.DeleteResult, error) {
+	if role != "Admin" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	return r.Collection.DeleteOne(ctx, bson.M{"_id": id})
+}
+
+type ShipmentRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewShipmentRepository(collection *mongo.Collection) *ShipmentRepository {
+	return &ShipmentRepository{Collection: collection}
+}
+
+func (r *ShipmentRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.M{"order_id": 1}})
+	return err
+}
+
+func (r *ShipmentRepository) FindByOrder(ctx context.Context, orderID primitive.ObjectID, role string) ([]models.Shipment, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	cursor, err := r.Collection.Find(ctx, bson.M{"order_id": orderID}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	shipments := []models.Shipment{}
+	if err := cursor.All(ctx, &shipments); err != nil {
+		return nil, err
+	}
+	return shipments, nil
+}
+
+func (r *ShipmentRepository) FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.Shipment, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	var shipment models.Shipment
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&shipment); err != nil {
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+func (r *ShipmentRepository) Insert(ctx context.Context, shipment *models.Shipment, role string) error {
+	if role != "Admin" && role != "Staff" {
+		return fmt.Errorf("unauthorized role")
+	}
+	result, err := r.Collection.InsertOne(ctx, shipment)
+	if err != nil {
+		return err
+	}
+	shipment.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *ShipmentRepository) Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	return r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+}
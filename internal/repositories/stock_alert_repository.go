@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type StockAlertRepositoryInterface interface {
+	FindOpenByProduct(ctx context.Context, productID primitive.ObjectID) (*models.StockAlert, error)
+	FindAll(ctx context.Context, status string) ([]models.StockAlert, error)
+	Insert(ctx context.Context, alert *models.StockAlert) error
+	Resolve(ctx context.Context, productID primitive.ObjectID) (*mongo.UpdateResult, error)
+}
+
+type StockAlertRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewStockAlertRepository(collection *mongo.Collection) *StockAlertRepository {
+	return &StockAlertRepository{Collection: collection}
+}
+
+func (r *StockAlertRepository) FindOpenByProduct(ctx context.Context, productID primitive.ObjectID) (*models.StockAlert, error) {
+	var alert models.StockAlert
+	if err := r.Collection.FindOne(ctx, bson.M{"product_id": productID, "status": "Open"}).Decode(&alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (r *StockAlertRepository) FindAll(ctx context.Context, status string) ([]models.StockAlert, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var alerts []models.StockAlert
+	for cursor.Next(ctx) {
+		var alert models.StockAlert
+		if err := cursor.Decode(&alert); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+func (r *StockAlertRepository) Insert(ctx context.Context, alert *models.StockAlert) error {
+	result, err := r.Collection.InsertOne(ctx, alert)
+	if err != nil {
+		return err
+	}
+	alert.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *StockAlertRepository) Resolve(ctx context.Context, productID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return r.Collection.UpdateMany(ctx,
+		bson.M{"product_id": productID, "status": "Open"},
+		bson.M{"$set": bson.M{"status": "Resolved", "resolved_at": time.Now()}},
+	)
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type StockTransferRepositoryInterface interface {
+	FindAll(ctx context.Context, filter bson.M) ([]models.StockTransfer, error)
+	Insert(ctx context.Context, transfer *models.StockTransfer) error
+}
+
+type StockTransferRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewStockTransferRepository(collection *mongo.Collection) *StockTransferRepository {
+	return &StockTransferRepository{Collection: collection}
+}
+
+func (r *StockTransferRepository) FindAll(ctx context.Context, filter bson.M) ([]models.StockTransfer, error) {
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(500))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var transfers []models.StockTransfer
+	for cursor.Next(ctx) {
+		var transfer models.StockTransfer
+		if err := cursor.Decode(&transfer); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+	return transfers, nil
+}
+
+func (r *StockTransferRepository) Insert(ctx context.Context, transfer *models.StockTransfer) error {
+	result, err := r.Collection.InsertOne(ctx, transfer)
+	if err != nil {
+		return err
+	}
+	transfer.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
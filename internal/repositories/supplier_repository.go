@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type SupplierRepositoryInterface interface {
+	FindAll(ctx context.Context, role string) ([]models.Supplier, error)
+	FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.Supplier, error)
+	Insert(ctx context.Context, supplier *models.Supplier, role string) error
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error)
+}
+
+type SupplierRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewSupplierRepository(collection *mongo.Collection) *SupplierRepository {
+	return &SupplierRepository{Collection: collection}
+}
+
+func (r *SupplierRepository) FindAll(ctx context.Context, role string) ([]models.Supplier, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	cursor, err := r.Collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var suppliers []models.Supplier
+	for cursor.Next(ctx) {
+		var supplier models.Supplier
+		if err := cursor.Decode(&supplier); err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, supplier)
+	}
+	return suppliers, nil
+}
+
+func (r *SupplierRepository) FindByID(ctx context.Context, id primitive.ObjectID, role string) (*models.Supplier, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	var supplier models.Supplier
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&supplier); err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+func (r *SupplierRepository) Insert(ctx context.Context, supplier *models.Supplier, role string) error {
+	if role != "Admin" && role != "Staff" {
+		return fmt.Errorf("unauthorized role")
+	}
+	result, err := r.Collection.InsertOne(ctx, supplier)
+	if err != nil {
+		return err
+	}
+	supplier.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *SupplierRepository) Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" && role != "Staff" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	return r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+}
@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WebhookSubscriptionRepositoryInterface interface {
+	FindAll(ctx context.Context) ([]models.WebhookSubscription, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.WebhookSubscription, error)
+	FindActiveForEvent(ctx context.Context, event string) ([]models.WebhookSubscription, error)
+	Insert(ctx context.Context, sub *models.WebhookSubscription, role string) error
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error)
+	Delete(ctx context.Context, id primitive.ObjectID, role string) (*mongo.DeleteResult, error)
+}
+
+type WebhookDeliveryRepositoryInterface interface {
+	FindAll(ctx context.Context, filter bson.M, limit int64) ([]models.WebhookDelivery, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.WebhookDelivery, error)
+	Insert(ctx context.Context, delivery *models.WebhookDelivery) error
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error)
+	FindEarlierPending(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error)
+	ReleaseNext(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) error
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error
+}
+
+type WebhookSubscriptionRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewWebhookSubscriptionRepository(collection *mongo.Collection) *WebhookSubscriptionRepository {
+	return &WebhookSubscriptionRepository{Collection: collection}
+}
+
+func (r *WebhookSubscriptionRepository) FindAll(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *WebhookSubscriptionRepository) FindActiveForEvent(ctx context.Context, event string) ([]models.WebhookSubscription, error) {
+	return r.find(ctx, bson.M{"is_active": true, "events": bson.M{"$in": bson.A{event, "*"}}})
+}
+
+func (r *WebhookSubscriptionRepository) find(ctx context.Context, filter bson.M) ([]models.WebhookSubscription, error) {
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var subs []models.WebhookSubscription
+	for cursor.Next(ctx) {
+		var sub models.WebhookSubscription
+		if err := cursor.Decode(&sub); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+func (r *WebhookSubscriptionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *WebhookSubscriptionRepository) Insert(ctx context.Context, sub *models.WebhookSubscription, role string) error {
+	if role != "Admin" {
+		return fmt.Errorf("unauthorized role")
+	}
+	result, err := r.Collection.InsertOne(ctx, sub)
+	if err != nil {
+		return err
+	}
+	sub.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *WebhookSubscriptionRepository) Update(ctx context.Context, id primitive.ObjectID, fields bson.M, role string) (*mongo.UpdateResult, error) {
+	if role != "Admin" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	return r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+}
+
+func (r *WebhookSubscriptionRepository) Delete(ctx context.Context, id primitive.ObjectID, role string) (*mongo.DeleteResult, error) {
+	if role != "Admin" {
+		return nil, fmt.Errorf("unauthorized role")
+	}
+	return r.Collection.DeleteOne(ctx, bson.M{"_id": id})
+}
+
+type WebhookDeliveryRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewWebhookDeliveryRepository(collection *mongo.Collection) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{Collection: collection}
+}
+
+func (r *WebhookDeliveryRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "subscription_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "subscription_id", Value: 1}, {Key: "event_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "subscription_id", Value: 1}, {Key: "aggregate_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+	return err
+}
+
+func (r *WebhookDeliveryRepository) FindAll(ctx context.Context, filter bson.M, limit int64) ([]models.WebhookDelivery, error) {
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var deliveries []models.WebhookDelivery
+	for cursor.Next(ctx) {
+		var delivery models.WebhookDelivery
+		if err := cursor.Decode(&delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookDeliveryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *WebhookDeliveryRepository) Insert(ctx context.Context, delivery *models.WebhookDelivery) error {
+	result, err := r.Collection.InsertOne(ctx, delivery)
+	if err != nil {
+		return err
+	}
+	delivery.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// ClaimDue จองรายการที่ถึงเวลาส่งหนึ่งรายการโดยเลื่อน next_attempt_at ออกไปเท่ากับ lease
+// ถ้า worker ตายระหว่างส่ง รายการจะกลับมาให้ส่งใหม่เมื่อ lease หมด
+func (r *WebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.Collection.FindOneAndUpdate(ctx,
+		bson.M{"status": models.WebhookDeliveryPending, "next_attempt_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}},
+		options.FindOneAndUpdate().SetSort(bson.M{"next_attempt_at": 1}),
+	).Decode(&delivery)
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindEarlierPending คืนรายการของ aggregate และ subscription เดียวกันที่สร้างก่อนและยังส่งไม่สำเร็จ
+// รายการหลังต้องรอให้รายการนี้ส่งสำเร็จหรือล้มเหลวถาวรก่อน ผู้รับจึงได้ event ของออเดอร์หนึ่งตามลำดับ
+func (r *WebhookDeliveryRepository) FindEarlierPending(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	var earlier models.WebhookDelivery
+	err := r.Collection.FindOne(ctx, bson.M{
+		"subscription_id": delivery.SubscriptionID,
+		"aggregate_id":    delivery.AggregateID,
+		"status":          models.WebhookDeliveryPending,
+		"$or": []bson.M{
+			{"created_at": bson.M{"$lt": delivery.CreatedAt}},
+			{"created_at": delivery.CreatedAt, "_id": bson.M{"$lt": delivery.ID}},
+		},
+	}, options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})).Decode(&earlier)
+	if err != nil {
+		return nil, err
+	}
+	return &earlier, nil
+}
+
+func (r *WebhookDeliveryRepository) ReleaseNext(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) error {
+	_, err := r.Collection.UpdateMany(ctx, bson.M{
+		"subscription_id": delivery.SubscriptionID,
+		"aggregate_id":    delivery.AggregateID,
+		"status":          models.WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": bson.M{"$gt": now},
+	}, bson.M{"$set": bson.M{"next_attempt_at": now}})
+	return err
+}
+
+func (r *WebhookDeliveryRepository) Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	_, err := r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	return err
+}
@@ -1,13 +1,46 @@
 package routes
 
 import (
+	"context"
+	"log"
+	"time"
+
+	"net"
+	"net/smtp"
+
 	"github.com/gin-gonic/gin"
+	"github.com/simple-business-management-api/go-backend-api/config"
 	"github.com/simple-business-management-api/go-backend-api/internal/handlers"
 	"github.com/simple-business-management-api/go-backend-api/internal/middleware"
+	"github.com/simple-business-management-api/go-backend-api/internal/migrations"
+	"github.com/simple-business-management-api/go-backend-api/internal/models"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/document"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/gateway"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/inventory"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/money"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/notify"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/outbox"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/stream"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/tax"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/thaiaddress"
+	"github.com/simple-business-management-api/go-backend-api/internal/pkg/webhook"
 	"github.com/simple-business-management-api/go-backend-api/internal/repositories"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// แต่ละขั้นตอนตอนเริ่มระบบมีเวลาของตัวเอง ขั้นที่ช้าจะไม่กินเวลาของขั้นถัดไป
+// migration และ backfill ทำงานกับทั้ง collection จึงได้เวลานานกว่าการสร้าง index
+const (
+	setupTimeout     = 10 * time.Second
+	migrationTimeout = 30 * time.Minute
+)
+
+func withTimeout(timeout time.Duration, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
 func SetRoutes(db *mongo.Client) *gin.Engine {
 	r := gin.Default()
 
@@ -21,12 +54,157 @@ func SetRoutes(db *mongo.Client) *gin.Engine {
 	ProductCollection := db.Database("Simple-Business-Management").Collection("products")
 	OrderCollection := db.Database("Simple-Business-Management").Collection("orders")
 	CustomerCollection := db.Database("Simple-Business-Management").Collection("customers")
+	StockAlertCollection := db.Database("Simple-Business-Management").Collection("stock_alerts")
+	NotificationCollection := db.Database("Simple-Business-Management").Collection("notifications")
+	SupplierCollection := db.Database("Simple-Business-Management").Collection("suppliers")
+	PurchaseOrderCollection := db.Database("Simple-Business-Management").Collection("purchase_orders")
+	CounterCollection := db.Database("Simple-Business-Management").Collection("counters")
+	LocationCollection := db.Database("Simple-Business-Management").Collection("locations")
+	StockTransferCollection := db.Database("Simple-Business-Management").Collection("stock_transfers")
+	PromotionCollection := db.Database("Simple-Business-Management").Collection("promotions")
+	DocumentCollection := db.Database("Simple-Business-Management").Collection("documents")
+	PaymentCollection := db.Database("Simple-Business-Management").Collection("payments")
+	PaymentEventCollection := db.Database("Simple-Business-Management").Collection("payment_events")
+	ReturnCollection := db.Database("Simple-Business-Management").Collection("returns")
+	RefundCollection := db.Database("Simple-Business-Management").Collection("refunds")
+	WebhookSubscriptionCollection := db.Database("Simple-Business-Management").Collection("webhook_subscriptions")
+	WebhookDeliveryCollection := db.Database("Simple-Business-Management").Collection("webhook_deliveries")
+	OutboxCollection := db.Database("Simple-Business-Management").Collection("outbox")
+	CarrierCollection := db.Database("Simple-Business-Management").Collection("carriers")
+	ShipmentCollection := db.Database("Simple-Business-Management").Collection("shipments")
+	QuotationCollection := db.Database("Simple-Business-Management").Collection("quotations")
 	AuthHandle := handlers.NewAuthHandle(UserCollection)
 	productRepo := repositories.NewProductRepository(ProductCollection)
+	locationRepo := repositories.NewLocationRepository(LocationCollection)
+	stockTransferRepo := repositories.NewStockTransferRepository(StockTransferCollection)
+	currency := money.DefaultCurrency()
+	if err := withTimeout(migrationTimeout, func(ctx context.Context) error {
+		return migrations.MigrateMoney(ctx, db.Database("Simple-Business-Management"), currency, money.Exponent(currency))
+	}); err != nil {
+		log.Fatalf("Failed to migrate money fields: %v", err)
+	}
+	if err := withTimeout(setupTimeout, productRepo.EnsureIndexes); err != nil {
+		log.Printf("Failed to create product indexes: %v", err)
+	}
+	var defaultLocation *models.Location
+	err := withTimeout(setupTimeout, func(ctx context.Context) (err error) {
+		defaultLocation, err = locationRepo.EnsureDefault(ctx)
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to load default stock location: %v", err)
+	}
+	productRepo.DefaultLocationID = defaultLocation.ID
+	if err := withTimeout(migrationTimeout, productRepo.MigrateLegacyStock); err != nil {
+		log.Printf("Failed to migrate legacy stock: %v", err)
+	}
 	orderRepo := repositories.NewOrderRepository(OrderCollection)
 	customerRepo := repositories.NewCustomerRepository(CustomerCollection)
-	OrderHandle := handlers.NewOrderHandle((orderRepo), (customerRepo), (productRepo))
-	productHandler := handlers.NewProductHandle(productRepo)
+	if err := withTimeout(migrationTimeout, customerRepo.EnsureIndexes); err != nil {
+		log.Printf("Failed to create customer indexes: %v", err)
+	}
+	addressDataset, err := thaiaddress.Load(config.LoadAddressConfig().DatasetPath)
+	if err != nil {
+		log.Fatalf("Failed to load Thai address dataset: %v", err)
+	}
+	carrierRepo := repositories.NewCarrierRepository(CarrierCollection)
+	if err := withTimeout(setupTimeout, carrierRepo.EnsureIndexes); err != nil {
+		log.Printf("Failed to create carrier indexes: %v", err)
+	}
+	shipmentRepo := repositories.NewShipmentRepository(ShipmentCollection)
+	if err := withTimeout(setupTimeout, shipmentRepo.EnsureIndexes); err != nil {
+		log.Printf("Failed to create shipment indexes: %v", err)
+	}
+	taxCalc, err := tax.NewCalculatorFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid tax configuration: %v", err)
+	}
+	promotionRepo := repositories.NewPromotionRepository(PromotionCollection)
+	if err := withTimeout(setupTimeout, promotionRepo.EnsureIndexes); err != nil {
+		log.Printf("Failed to create promotion indexes: %v", err)
+	}
+	webhookSubscriptionRepo := repositories.NewWebhookSubscriptionRepository(WebhookSubscriptionCollection)
+	webhookDeliveryRepo := repositories.NewWebhookDeliveryRepository(WebhookDeliveryCollection)
+	if err := withTimeout(setupTimeout, webhookDeliveryRepo.EnsureIndexes); err != nil {
+		log.Printf("Failed to create webhook delivery indexes: %v", err)
+	}
+	webhookDispatcher := webhook.NewDispatcher(webhookSubscriptionRepo, webhookDeliveryRepo)
+	webhookDispatcher.Start(context.Background())
+	webhookHandler := handlers.NewWebhookHandle(webhookSubscriptionRepo, webhookDeliveryRepo, webhookDispatcher)
+	outboxRepo := repositories.NewOutboxRepository(OutboxCollection)
+	if err := withTimeout(setupTimeout, outboxRepo.EnsureIndexes); err != nil {
+		log.Printf("Failed to create outbox indexes: %v", err)
+	}
+	orderBroker := stream.NewMemoryBroker()
+	streamTickets := stream.NewTickets(30 * time.Second)
+	orderStreamHandler := handlers.NewOrderStreamHandle(orderBroker, streamTickets, config.LoadStreamConfig().AllowedOrigins)
+	outboxConfig := config.LoadOutboxConfig()
+	var eventOutbox *outbox.Outbox
+	err = withTimeout(setupTimeout, func(ctx context.Context) (err error) {
+		eventOutbox, err = outbox.NewOutbox(ctx, outboxRepo, db, outboxConfig.AllowStandalone, outbox.NewWebhookSink(webhookDispatcher), stream.NewOutboxSink(orderBroker))
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to start outbox: %v (set OUTBOX_ALLOW_STANDALONE=true to run without transactions)", err)
+	}
+	if outboxConfig.LogEvents {
+		eventOutbox.Add(outbox.NewLogSink())
+	}
+	eventOutbox.Start(context.Background())
+	OrderHandle := handlers.NewOrderHandle((orderRepo), (customerRepo), (productRepo), (promotionRepo), taxCalc, eventOutbox, addressDataset, carrierRepo, shipmentRepo)
+	promotionHandler := handlers.NewPromotionHandle(promotionRepo)
+	productHandler := handlers.NewProductHandle(productRepo, locationRepo, taxCalc, eventOutbox)
+	stockAlertRepo := repositories.NewStockAlertRepository(StockAlertCollection)
+	notificationRepo := repositories.NewNotificationRepository(NotificationCollection)
+	inventoryHandler := handlers.NewInventoryHandle(productRepo, stockAlertRepo, locationRepo, stockTransferRepo)
+	notificationHandler := handlers.NewNotificationHandle(notificationRepo)
+	counterRepo := repositories.NewCounterRepository(CounterCollection)
+	supplierRepo := repositories.NewSupplierRepository(SupplierCollection)
+	purchaseOrderRepo := repositories.NewPurchaseOrderRepository(PurchaseOrderCollection)
+	supplierHandler := handlers.NewSupplierHandle(supplierRepo)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandle(purchaseOrderRepo, supplierRepo, productRepo, counterRepo, locationRepo, eventOutbox)
+	locationHandler := handlers.NewLocationHandle(locationRepo)
+	documentRepo := repositories.NewDocumentRepository(DocumentCollection)
+	if err := withTimeout(setupTimeout, documentRepo.EnsureIndexes); err != nil {
+		log.Printf("Failed to create document indexes: %v", err)
+	}
+	paymentRepo := repositories.NewPaymentRepository(PaymentCollection)
+	paymentConfig := config.LoadPaymentConfig()
+	paymentHandler := handlers.NewPaymentHandle(orderRepo, paymentRepo, paymentConfig.PromptPayID, eventOutbox)
+	paymentEventRepo := repositories.NewPaymentEventRepository(PaymentEventCollection)
+	if err := withTimeout(setupTimeout, paymentEventRepo.EnsureIndexes); err != nil {
+		log.Printf("Failed to create payment event indexes: %v", err)
+	}
+	paymentProviders := gateway.NewRegistry()
+	if paymentConfig.FakeWebhookSecret != "" {
+		paymentProviders.Register(gateway.NewFakeProvider(paymentConfig.FakeWebhookSecret))
+	}
+	paymentWebhookHandler := handlers.NewPaymentWebhookHandle(orderRepo, paymentRepo, paymentEventRepo, paymentProviders, eventOutbox)
+	returnRepo := repositories.NewReturnRepository(ReturnCollection)
+	refundRepo := repositories.NewRefundRepository(RefundCollection)
+	returnHandler := handlers.NewReturnHandle(returnRepo, orderRepo, productRepo, paymentRepo, refundRepo, counterRepo, eventOutbox)
+	reportRepo := repositories.NewReportRepository(OrderCollection)
+	reportHandler := handlers.NewReportHandle(reportRepo)
+	customerHandler := handlers.NewCustomerHandle(customerRepo, orderRepo, reportRepo, eventOutbox)
+	addressHandler := handlers.NewAddressHandle(customerRepo, addressDataset)
+	shippingHandler := handlers.NewShippingHandle(carrierRepo, shipmentRepo, orderRepo, productRepo, eventOutbox)
+	documentRenderer := newDocumentRenderer()
+	documentHandler := handlers.NewDocumentHandle(orderRepo, customerRepo, productRepo, documentRepo, counterRepo, shipmentRepo, documentRenderer)
+	quotationRepo := repositories.NewQuotationRepository(QuotationCollection)
+	if err := withTimeout(setupTimeout, quotationRepo.EnsureIndexes); err != nil {
+		log.Printf("Failed to create quotation indexes: %v", err)
+	}
+	quotationHandler := handlers.NewQuotationHandle(quotationRepo, counterRepo, OrderHandle, documentRenderer)
+
+	notifier := newNotifier(notificationRepo)
+	notifier.Add(outbox.NewNotificationSink(eventOutbox))
+	lowStockChecker := inventory.NewLowStockChecker(productRepo, stockAlertRepo, notifier)
+	lowStockChecker.Start(context.Background())
+	productRepo.OnStockChanged(lowStockChecker.Enqueue)
+	backorderAllocator := inventory.NewBackorderAllocator(productRepo, orderRepo, eventOutbox, notifier)
+	backorderAllocator.Start(context.Background())
+	productRepo.OnStockIncreased(backorderAllocator.Enqueue)
+
 	api := r.Group("/api")
 	{
 		auth := api.Group("/auth")
@@ -37,6 +215,7 @@ func SetRoutes(db *mongo.Client) *gin.Engine {
 		product := api.Group("/product")
 		{
 			product.GET("/", productHandler.GetProducts)
+			product.GET("/search", productHandler.SearchProducts)
 		}
 		productMiddleware := api.Group("/product")
 		productMiddleware.Use(middleware.AuthMiddleware())
@@ -44,6 +223,8 @@ func SetRoutes(db *mongo.Client) *gin.Engine {
 			productMiddleware.POST("/", productHandler.CreateProduct)
 			productMiddleware.PUT("", productHandler.UpdateProduct)
 			productMiddleware.DELETE("", productHandler.DeleteProduct)
+			productMiddleware.POST("/import", productHandler.ImportProducts)
+			productMiddleware.GET("/export", productHandler.ExportProducts)
 		}
 		orderMiddleware := api.Group("/order")
 		orderMiddleware.Use(middleware.AuthMiddleware())
@@ -52,8 +233,190 @@ func SetRoutes(db *mongo.Client) *gin.Engine {
 			orderMiddleware.GET("/", OrderHandle.GetOrders)
 			orderMiddleware.PUT("", OrderHandle.UpdateOrder)
 			orderMiddleware.DELETE("", OrderHandle.DeleteOrder)
+			orderMiddleware.PUT("/:id/items", OrderHandle.EditOrderItems)
+			orderMiddleware.GET("/:id/payments", paymentHandler.GetPayments)
+			orderMiddleware.POST("/:id/payments", paymentHandler.RecordPayment)
+			orderMiddleware.GET("/:id/promptpay-qr", paymentHandler.GetPromptPayQR)
+			orderMiddleware.GET("/:id/invoice.pdf", documentHandler.GetInvoicePDF)
+			orderMiddleware.GET("/:id/receipt.pdf", documentHandler.GetReceiptPDF)
+			orderMiddleware.GET("/:id/label.pdf", documentHandler.GetLabelPDF)
+			orderMiddleware.GET("/:id/packing-slip.pdf", documentHandler.GetPackingSlipPDF)
+			orderMiddleware.GET("/labels.pdf", documentHandler.GetPaidLabelsPDF)
+			orderMiddleware.GET("/packing-slips.pdf", documentHandler.GetPaidPackingSlipsPDF)
+			orderMiddleware.GET("/:id/shipments", shippingHandler.GetShipments)
+			orderMiddleware.POST("/:id/shipments", shippingHandler.CreateShipment)
+			orderMiddleware.PUT("/:id/shipments/:shipmentId", shippingHandler.UpdateShipment)
+			orderMiddleware.POST("/stream/ticket", orderStreamHandler.IssueStreamTicket)
+		}
+		orderStream := api.Group("/order")
+		orderStream.Use(middleware.StreamAuthMiddleware(streamTickets))
+		{
+			orderStream.GET("/stream", orderStreamHandler.StreamOrders)
+			orderStream.GET("/ws", orderStreamHandler.StreamOrdersWebSocket)
+		}
+		payments := api.Group("/payments")
+		{
+			// gateway ยืนยันตัวตนด้วยลายเซ็นของ webhook ไม่ใช่ JWT
+			payments.POST("/webhook/:provider", paymentWebhookHandler.HandleWebhook)
+		}
+		returnMiddleware := api.Group("/return")
+		returnMiddleware.Use(middleware.AuthMiddleware())
+		{
+			returnMiddleware.GET("/", returnHandler.GetReturns)
+			returnMiddleware.GET("/detail", returnHandler.GetReturn)
+			returnMiddleware.POST("/", returnHandler.CreateReturn)
+			returnMiddleware.PUT("/approve", returnHandler.ApproveReturn)
+			returnMiddleware.PUT("/reject", returnHandler.RejectReturn)
+			returnMiddleware.POST("/receive", returnHandler.ReceiveReturn)
+			returnMiddleware.POST("/refund", returnHandler.RefundReturn)
+		}
+		reportMiddleware := api.Group("/reports")
+		reportMiddleware.Use(middleware.AuthMiddleware())
+		{
+			reportMiddleware.GET("/summary", reportHandler.GetSummary)
+			reportMiddleware.GET("/sales", reportHandler.GetSales)
+			reportMiddleware.GET("/top-products", reportHandler.GetTopProducts)
+			reportMiddleware.GET("/staff", reportHandler.GetStaffSales)
+			reportMiddleware.GET("/status", reportHandler.GetStatusCounts)
+			reportMiddleware.GET("/backorders", reportHandler.GetBackorders)
+		}
+		customerMiddleware := api.Group("/customer")
+		customerMiddleware.Use(middleware.AuthMiddleware())
+		{
+			customerMiddleware.GET("/", customerHandler.GetCustomers)
+			customerMiddleware.GET("/rfm", customerHandler.GetRFM)
+			customerMiddleware.POST("/merge", customerHandler.MergeCustomers)
+			customerMiddleware.GET("/:id/orders", customerHandler.GetCustomerOrders)
+			customerMiddleware.GET("/:id/summary", customerHandler.GetCustomerSummary)
+			customerMiddleware.GET("/:id/addresses", addressHandler.GetAddresses)
+			customerMiddleware.POST("/:id/addresses", addressHandler.CreateAddress)
+			customerMiddleware.PUT("/:id/addresses/:addressId", addressHandler.UpdateAddress)
+			customerMiddleware.DELETE("/:id/addresses/:addressId", addressHandler.DeleteAddress)
+		}
+		shippingMiddleware := api.Group("/shipping")
+		shippingMiddleware.Use(middleware.AuthMiddleware())
+		{
+			shippingMiddleware.GET("/carriers", shippingHandler.GetCarriers)
+			shippingMiddleware.POST("/carriers", shippingHandler.CreateCarrier)
+			shippingMiddleware.PUT("/carriers", shippingHandler.UpdateCarrier)
+			shippingMiddleware.DELETE("/carriers", shippingHandler.DeleteCarrier)
+			shippingMiddleware.POST("/quote", shippingHandler.Quote)
+		}
+		addressMiddleware := api.Group("/address")
+		addressMiddleware.Use(middleware.AuthMiddleware())
+		{
+			addressMiddleware.GET("/lookup", addressHandler.Lookup)
+		}
+		inventoryMiddleware := api.Group("/inventory")
+		inventoryMiddleware.Use(middleware.AuthMiddleware())
+		{
+			inventoryMiddleware.GET("/low-stock", inventoryHandler.GetLowStock)
+			inventoryMiddleware.GET("/alerts", inventoryHandler.GetStockAlerts)
+			inventoryMiddleware.POST("/transfer", inventoryHandler.TransferStock)
+			inventoryMiddleware.GET("/transfers", inventoryHandler.GetStockTransfers)
+		}
+		locationMiddleware := api.Group("/location")
+		locationMiddleware.Use(middleware.AuthMiddleware())
+		{
+			locationMiddleware.GET("/", locationHandler.GetLocations)
+			locationMiddleware.POST("/", locationHandler.CreateLocation)
+			locationMiddleware.PUT("", locationHandler.UpdateLocation)
+		}
+		supplierMiddleware := api.Group("/supplier")
+		supplierMiddleware.Use(middleware.AuthMiddleware())
+		{
+			supplierMiddleware.GET("/", supplierHandler.GetSuppliers)
+			supplierMiddleware.POST("/", supplierHandler.CreateSupplier)
+			supplierMiddleware.PUT("", supplierHandler.UpdateSupplier)
+		}
+		purchaseOrderMiddleware := api.Group("/purchase-order")
+		purchaseOrderMiddleware.Use(middleware.AuthMiddleware())
+		{
+			purchaseOrderMiddleware.GET("/", purchaseOrderHandler.GetPurchaseOrders)
+			purchaseOrderMiddleware.GET("/detail", purchaseOrderHandler.GetPurchaseOrder)
+			purchaseOrderMiddleware.POST("/", purchaseOrderHandler.CreatePurchaseOrder)
+			purchaseOrderMiddleware.PUT("", purchaseOrderHandler.UpdatePurchaseOrder)
+			purchaseOrderMiddleware.PUT("/submit", purchaseOrderHandler.SubmitPurchaseOrder)
+			purchaseOrderMiddleware.PUT("/cancel", purchaseOrderHandler.CancelPurchaseOrder)
+			purchaseOrderMiddleware.POST("/receive", purchaseOrderHandler.ReceivePurchaseOrder)
+		}
+		quotationMiddleware := api.Group("/quotation")
+		quotationMiddleware.Use(middleware.AuthMiddleware())
+		{
+			quotationMiddleware.GET("/", quotationHandler.GetQuotations)
+			quotationMiddleware.POST("/", quotationHandler.CreateQuotation)
+			quotationMiddleware.GET("/:id", quotationHandler.GetQuotation)
+			quotationMiddleware.PUT("/:id", quotationHandler.UpdateQuotation)
+			quotationMiddleware.PUT("/:id/send", quotationHandler.SendQuotation)
+			quotationMiddleware.PUT("/:id/cancel", quotationHandler.CancelQuotation)
+			quotationMiddleware.GET("/:id/quotation.pdf", quotationHandler.GetQuotationPDF)
+			quotationMiddleware.POST("/:id/convert", quotationHandler.ConvertQuotation)
+		}
+		promotionMiddleware := api.Group("/promotion")
+		promotionMiddleware.Use(middleware.AuthMiddleware())
+		{
+			promotionMiddleware.GET("/", promotionHandler.GetPromotions)
+			promotionMiddleware.POST("/", promotionHandler.CreatePromotion)
+			promotionMiddleware.PUT("", promotionHandler.UpdatePromotion)
+		}
+		webhookMiddleware := api.Group("/webhook")
+		webhookMiddleware.Use(middleware.AuthMiddleware())
+		{
+			webhookMiddleware.GET("/", webhookHandler.GetSubscriptions)
+			webhookMiddleware.POST("/", webhookHandler.CreateSubscription)
+			webhookMiddleware.PUT("", webhookHandler.UpdateSubscription)
+			webhookMiddleware.DELETE("", webhookHandler.DeleteSubscription)
+			webhookMiddleware.POST("/test", webhookHandler.TestSubscription)
+			webhookMiddleware.GET("/deliveries", webhookHandler.GetDeliveries)
+			webhookMiddleware.POST("/deliveries/retry", webhookHandler.RetryDelivery)
+		}
+		notificationMiddleware := api.Group("/notification")
+		notificationMiddleware.Use(middleware.AuthMiddleware())
+		{
+			notificationMiddleware.GET("/", notificationHandler.GetNotifications)
+			notificationMiddleware.PUT("/read", notificationHandler.MarkNotificationRead)
 		}
 	}
 
 	return r
 }
+
+func newNotifier(notificationRepo repositories.NotificationRepositoryInterface) *notify.Dispatcher {
+	cfg := config.LoadNotificationConfig()
+	notifier := notify.NewDispatcher(notify.NewInAppSink(notificationRepo))
+
+	if cfg.WebhookURL != "" {
+		notifier.Add(notify.NewWebhookSink(cfg.WebhookURL))
+	}
+	if cfg.SMTPHost != "" && cfg.SMTPFrom != "" && len(cfg.SMTPTo) > 0 {
+		var auth smtp.Auth
+		if cfg.SMTPUsername != "" {
+			auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+		}
+		addr := net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort)
+		notifier.Add(notify.NewEmailSink(addr, cfg.SMTPFrom, cfg.SMTPTo, auth))
+	}
+	return notifier
+}
+
+// newDocumentRenderer คืน nil ถ้าไม่ได้ตั้ง PDF_FONT_PATH ระบบยังทำงานได้แต่ออกเอกสาร PDF ไม่ได้
+// ถ้าตั้งไว้แต่อ่านฟอนต์ไม่ได้จะหยุดทำงาน เพื่อไม่ให้ปิดการออกเอกสารไปเงียบๆ
+func newDocumentRenderer() *document.Renderer {
+	cfg := config.LoadDocumentConfig()
+	if cfg.FontPath == "" {
+		log.Printf("PDF documents disabled: PDF_FONT_PATH is not set")
+		return nil
+	}
+	company := document.Company{
+		Name:    cfg.CompanyName,
+		Address: cfg.CompanyAddress,
+		TaxID:   cfg.CompanyTaxID,
+		Branch:  cfg.CompanyBranch,
+		Phone:   cfg.CompanyPhone,
+	}
+	renderer, err := document.NewRenderer(company, cfg.FontPath, cfg.BoldFontPath)
+	if err != nil {
+		log.Fatalf("Failed to load PDF fonts: %v (install a Thai TrueType font such as Sarabun and set PDF_FONT_PATH/PDF_FONT_BOLD_PATH, or leave PDF_FONT_PATH empty to disable PDF documents)", err)
+	}
+	return renderer
+}